@@ -8,13 +8,20 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata"
 
+	handlerEvents "github.com/avraam311/tasks-service/internal/api/handlers/events"
 	handlerTasks "github.com/avraam311/tasks-service/internal/api/handlers/tasks"
+	handlerWebhooks "github.com/avraam311/tasks-service/internal/api/handlers/webhooks"
 	"github.com/avraam311/tasks-service/internal/api/server"
 	"github.com/avraam311/tasks-service/internal/infra/config"
 	"github.com/avraam311/tasks-service/internal/infra/logger"
+	"github.com/avraam311/tasks-service/internal/infra/notifier"
 	repoTasks "github.com/avraam311/tasks-service/internal/repository/tasks"
+	repoWebhooks "github.com/avraam311/tasks-service/internal/repository/webhooks"
+	serviceEvents "github.com/avraam311/tasks-service/internal/service/events"
 	serviceTasks "github.com/avraam311/tasks-service/internal/service/tasks"
+	serviceWebhooks "github.com/avraam311/tasks-service/internal/service/webhooks"
 )
 
 const (
@@ -36,12 +43,69 @@ func main() {
 		os.Exit(1)
 	}
 
-	repo := repoTasks.New()
-	service := serviceTasks.New(repo)
+	var repo serviceTasks.Repo
+	var revisions serviceTasks.RevisionStore
+	switch cfg.Storage.Backend {
+	case config.StorageFile:
+		fileRepo, err := repoTasks.NewFile(cfg.Storage.Dir, time.Duration(cfg.Storage.SnapshotIntervalSec)*time.Second)
+		if err != nil {
+			slog.Error("failed to open file storage", "error", err)
+			os.Exit(1)
+		}
+		defer func() {
+			if err := fileRepo.Close(); err != nil {
+				slog.Warn("failed to close file storage", "error", err)
+			}
+		}()
+		repo, revisions = fileRepo, fileRepo
+	case config.StorageMemory, "":
+		memRepo := repoTasks.New()
+		repo, revisions = memRepo, memRepo
+	default:
+		slog.Error("unknown storage backend", "backend", cfg.Storage.Backend)
+		os.Exit(1)
+	}
+
+	repo = serviceTasks.NewHistoryRepo(repo, revisions, serviceTasks.SystemClock{})
+
+	workflow := serviceTasks.DefaultWorkflow()
+	if cfg.Workflow != nil && len(cfg.Workflow.Transitions) > 0 {
+		workflow, err = serviceTasks.NewWorkflow(cfg.Workflow.Transitions)
+		if err != nil {
+			slog.Error("failed to load workflow", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	webhooks := serviceWebhooks.New(repoWebhooks.New(), serviceTasks.SystemClock{}, serviceWebhooks.Options{
+		MaxAttempts:  cfg.Webhooks.MaxAttempts,
+		Backoff:      time.Duration(cfg.Webhooks.BackoffMs) * time.Millisecond,
+		MaxBackoff:   time.Duration(cfg.Webhooks.MaxBackoffSec) * time.Second,
+		Timeout:      time.Duration(cfg.Webhooks.TimeoutSec) * time.Second,
+		DisableAfter: cfg.Webhooks.DisableAfter,
+		Workers:      cfg.Webhooks.Workers,
+		QueueSize:    cfg.Webhooks.QueueSize,
+	})
+
+	broker := serviceEvents.New(cfg.Events.BufferSize)
+
+	service := serviceTasks.New(repo, serviceTasks.SystemClock{}, workflow,
+		serviceTasks.Publishers{webhooks, broker})
 	handler := handlerTasks.New(service)
 
-	router := server.NewRouter(handler)
+	reminders := serviceTasks.NewReminderScheduler(service, notifier.Log{},
+		time.Duration(cfg.Reminders.IntervalSec)*time.Second)
+	reminders.Start()
+
+	purger := serviceTasks.NewTrashPurger(service, time.Duration(cfg.Trash.RetentionHours)*time.Hour,
+		time.Duration(cfg.Trash.PurgeIntervalSec)*time.Second)
+	purger.Start()
+
+	router := server.NewRouter(handler, handlerWebhooks.New(webhooks),
+		handlerEvents.New(broker, time.Duration(cfg.Events.HeartbeatSec)*time.Second))
 	srv := server.NewServer(cfg.Server.Port, router)
+	// Event streams never end on their own, so Shutdown would wait for them.
+	srv.RegisterOnShutdown(broker.Close)
 	go func() {
 		if err := srv.ListenAndServe(); err != nil {
 			slog.Error("failed to run server", "error", err)
@@ -62,6 +126,9 @@ func main() {
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		slog.Warn("failed to shutdown server", "error", err)
 	}
+	reminders.Stop()
+	purger.Stop()
+	webhooks.Close(shutdownCtx)
 	if errors.Is(shutdownCtx.Err(), context.DeadlineExceeded) {
 		slog.Info("timeout exceeded, forcing shutdown")
 	}
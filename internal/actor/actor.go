@@ -0,0 +1,16 @@
+package actor
+
+import "context"
+
+type key struct{}
+
+func With(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, key{}, actor)
+}
+
+// From returns "" when ctx carries no actor.
+func From(ctx context.Context) string {
+	actor, _ := ctx.Value(key{}).(string)
+
+	return actor
+}
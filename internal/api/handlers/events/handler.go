@@ -0,0 +1,29 @@
+package events
+
+import (
+	"time"
+
+	serviceEvents "github.com/avraam311/tasks-service/internal/service/events"
+)
+
+const DefaultHeartbeat = 15 * time.Second
+
+type Broker interface {
+	Subscribe(lastID string) *serviceEvents.Subscription
+}
+
+type Handler struct {
+	broker    Broker
+	heartbeat time.Duration
+}
+
+func New(broker Broker, heartbeat time.Duration) Handler {
+	if heartbeat <= 0 {
+		heartbeat = DefaultHeartbeat
+	}
+
+	return Handler{
+		broker:    broker,
+		heartbeat: heartbeat,
+	}
+}
@@ -0,0 +1,148 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/avraam311/tasks-service/internal/api/middlewares"
+	"github.com/avraam311/tasks-service/internal/models"
+	serviceEvents "github.com/avraam311/tasks-service/internal/service/events"
+)
+
+// sseEvent is one event read off the stream; comment lines such as
+// heartbeats come back with only Comment set.
+type sseEvent struct {
+	ID      string
+	Type    string
+	Data    string
+	Comment string
+}
+
+// stream opens the event stream behind LoggingMiddleware, the way the router
+// serves it, and returns the events as they are read.
+func stream(t *testing.T, handler Handler, lastID string) (*http.Response, <-chan sseEvent) {
+	server := httptest.NewServer(middlewares.LoggingMiddleware(http.HandlerFunc(handler.StreamEvents)))
+	t.Cleanup(server.Close)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = resp.Body.Close() })
+
+	events := make(chan sseEvent, 16)
+	go func() {
+		defer close(events)
+		var event sseEvent
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if comment, ok := strings.CutPrefix(line, ": "); ok {
+				event.Comment = comment
+				continue
+			}
+			field, value, _ := strings.Cut(line, ": ")
+			switch field {
+			case "":
+				if event.Comment != "" || event.Type != "" {
+					events <- event
+				}
+				event = sseEvent{}
+			case "id":
+				event.ID = value
+			case "event":
+				event.Type = value
+			case "data":
+				event.Data = value
+			}
+		}
+	}()
+
+	return resp, events
+}
+
+func next(t *testing.T, events <-chan sseEvent) sseEvent {
+	select {
+	case event, ok := <-events:
+		require.True(t, ok, "stream ended")
+		return event
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "no event received")
+		return sseEvent{}
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	broker := serviceEvents.New(10)
+	handler := New(broker, time.Hour)
+
+	t.Run("MethodNotAllowed", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.StreamEvents(w, httptest.NewRequest(http.MethodPost, "/todos/events", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+
+	t.Run("Live", func(t *testing.T) {
+		resp, events := stream(t, handler, "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		broker.Publish(&models.TaskEvent{ID: "evt-1", Type: models.EventTaskCreated, TaskID: 1,
+			Task: &models.TaskDomain{ID: 1, Header: "Release"}})
+		event := next(t, events)
+		assert.Equal(t, models.EventTaskCreated, event.Type)
+		assert.NotEmpty(t, event.ID)
+
+		var payload models.TaskEvent
+		require.NoError(t, json.Unmarshal([]byte(event.Data), &payload))
+		assert.Equal(t, uint(1), payload.TaskID)
+		assert.Equal(t, "Release", payload.Task.Header)
+	})
+
+	t.Run("Resume", func(t *testing.T) {
+		first := broker.Subscribe("")
+		broker.Publish(&models.TaskEvent{ID: "evt-2", Type: models.EventTaskUpdated, TaskID: 1})
+		broker.Publish(&models.TaskEvent{ID: "evt-3", Type: models.EventTaskDeleted, TaskID: 1})
+		lastID := (<-first.C).ID
+		first.Close()
+
+		_, events := stream(t, handler, lastID)
+		event := next(t, events)
+		assert.Equal(t, models.EventTaskDeleted, event.Type)
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		_, events := stream(t, handler, "unknown-1")
+		assert.Equal(t, EventReset, next(t, events).Type)
+	})
+
+	t.Run("Heartbeat", func(t *testing.T) {
+		_, events := stream(t, New(broker, 10*time.Millisecond), "")
+		assert.Equal(t, "heartbeat", next(t, events).Comment)
+	})
+
+	t.Run("BrokerClosed", func(t *testing.T) {
+		broker := serviceEvents.New(10)
+		_, events := stream(t, New(broker, time.Hour), "")
+		require.Eventually(t, func() bool {
+			broker.Close()
+			select {
+			case _, ok := <-events:
+				return !ok
+			default:
+				return false
+			}
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+}
@@ -0,0 +1,99 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/avraam311/tasks-service/internal/api/responses"
+	serviceEvents "github.com/avraam311/tasks-service/internal/service/events"
+)
+
+const EventReset = "reset"
+
+// last_event_id stands in for Last-Event-ID on the first EventSource
+// connection.
+func (h *Handler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		slog.Error("not allowed method", slog.String("method", r.Method))
+		err := responses.ResponseError(w, responses.ErrMethodNotAllowed, "only GET allowed", http.StatusMethodNotAllowed)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	sub := h.broker.Subscribe(lastID)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		slog.Error("failed to flush event stream", slog.Any("error", err))
+		return
+	}
+
+	if sub.Reset {
+		if err := writeReset(w); err != nil {
+			return
+		}
+	}
+	for _, msg := range sub.Replay {
+		if err := writeMessage(w, msg); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case msg, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			err = writeMessage(w, msg)
+		case <-heartbeat.C:
+			_, err = io.WriteString(w, ": heartbeat\n\n")
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			slog.Info("event stream closed", slog.Any("error", err))
+			return
+		}
+	}
+}
+
+func writeMessage(w io.Writer, msg serviceEvents.Message) error {
+	data, err := json.Marshal(msg.Event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", msg.ID, msg.Event.Type, data)
+
+	return err
+}
+
+func writeReset(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "event: %s\ndata: {}\n\n", EventReset)
+
+	return err
+}
@@ -0,0 +1,104 @@
+package tasks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/avraam311/tasks-service/internal/api/responses"
+	"github.com/avraam311/tasks-service/internal/models"
+	serviceTasks "github.com/avraam311/tasks-service/internal/service/tasks"
+)
+
+type batchResult struct {
+	*models.BatchResult
+	Error *taskError `json:"error,omitempty"`
+}
+
+func (h *Handler) BatchTasks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		slog.Error("not allowed method", slog.String("method", r.Method))
+		err := responses.ResponseError(w, responses.ErrMethodNotAllowed, "only POST allowed", http.StatusMethodNotAllowed)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	var batch models.BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+		slog.Error("failed to decode JSON", slog.Any("error", err))
+		err := responses.ResponseError(w, responses.ErrInvalidJSON, fmt.Sprintf("invalid request body: %s", err.Error()),
+			http.StatusBadRequest)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	switch batch.Mode {
+	case "", models.BatchAtomic, models.BatchBestEffort:
+	default:
+		slog.Error("invalid batch mode", slog.String("mode", batch.Mode))
+		err := responses.ResponseError(w, responses.ErrInvalidParam, "mode must be atomic or best_effort",
+			http.StatusBadRequest)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	if len(batch.Operations) == 0 || len(batch.Operations) > serviceTasks.MaxBatchOperations {
+		slog.Error("invalid batch size", slog.Int("operations", len(batch.Operations)))
+		err := responses.ResponseError(w, responses.ErrInvalidParam,
+			fmt.Sprintf("operations must hold 1 to %d operations", serviceTasks.MaxBatchOperations),
+			http.StatusBadRequest)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	results, err := h.service.BatchTasks(r.Context(), batch.Operations, batch.Mode != models.BatchBestEffort)
+	if err != nil {
+		var batchErr *serviceTasks.BatchError
+		if errors.As(err, &batchErr) {
+			slog.Error("batch rolled back", slog.Int("index", batchErr.Index), slog.Any("error", err))
+			statusCode, _ := taskFailure(batchErr.Err)
+			err := responses.ResponseErrorDetails(w, responses.ErrBatchFailed,
+				fmt.Sprintf("operation %d failed, no changes were applied", batchErr.Index),
+				batchResults(results), statusCode)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+
+		slog.Error("failed to run batch", slog.Any("error", err))
+		err := responses.ResponseError(w, responses.ErrInternalServer, "internal server error", http.StatusInternalServerError)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	err = responses.ResponseOK(w, batchResults(results))
+	if err != nil {
+		slog.Error("failed to send json response", slog.Any("err", err))
+	}
+}
+
+func batchResults(results []*models.BatchResult) []batchResult {
+	response := make([]batchResult, 0, len(results))
+	for _, result := range results {
+		item := batchResult{BatchResult: result}
+		if result.Err != nil {
+			_, item.Error = taskFailure(result.Err)
+		}
+		response = append(response, item)
+	}
+
+	return response
+}
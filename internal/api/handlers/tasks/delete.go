@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/avraam311/tasks-service/internal/api/responses"
+	"github.com/avraam311/tasks-service/internal/models"
 	"github.com/avraam311/tasks-service/internal/repository/tasks"
 )
 
@@ -22,7 +23,7 @@ func (h *Handler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	taskIDStr := strings.TrimPrefix(r.URL.Path, "/todos/")
-	taskIDInt, err := strconv.Atoi(taskIDStr)
+	taskIDUint, err := strconv.ParseUint(taskIDStr, 10, strconv.IntSize)
 	if err != nil {
 		slog.Error("failed to convert task id into int", slog.String("tasks id str", taskIDStr))
 		err := responses.ResponseError(w, responses.ErrInvalidID, "invalid task id", http.StatusBadRequest)
@@ -31,13 +32,59 @@ func (h *Handler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	taskID := uint(taskIDInt)
+	taskID := uint(taskIDUint)
 
-	err = h.service.DeleteTask(r.Context(), taskID)
+	version, conditional, err := parseIfMatch(r)
+	if err != nil {
+		slog.Error("invalid If-Match header", slog.String("if_match", r.Header.Get("If-Match")))
+		err := responses.ResponseError(w, responses.ErrInvalidIfMatch, "If-Match must be a single strong ETag or *",
+			http.StatusBadRequest)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	mode := r.URL.Query().Get("children")
+	switch mode {
+	case "", models.ChildrenReject, models.ChildrenCascade, models.ChildrenOrphan:
+	default:
+		slog.Error("invalid children mode", slog.String("children", mode))
+		err := responses.ResponseError(w, responses.ErrInvalidParam, "children must be reject, cascade or orphan",
+			http.StatusBadRequest)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	if conditional {
+		err = h.service.DeleteTaskIfMatch(r.Context(), taskID, version, mode)
+	} else {
+		err = h.service.DeleteTask(r.Context(), taskID, mode)
+	}
 	if err != nil {
 		if errors.Is(err, tasks.ErrTaskNotFound) {
 			slog.Error("task not found", slog.Any("task_id", taskID))
-			err := responses.ResponseError(w, responses.ErrTaskNotFound, "task not found", http.StatusBadRequest)
+			err := responses.ResponseError(w, responses.ErrTaskNotFound, "task not found", http.StatusNotFound)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+		if errors.Is(err, tasks.ErrTaskHasChildren) {
+			slog.Error("task has children", slog.Any("task_id", taskID))
+			err := responses.ResponseError(w, responses.ErrTaskHasChildren,
+				"task has children, use children=cascade or children=orphan", http.StatusConflict)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+		if errors.Is(err, tasks.ErrVersionMismatch) {
+			slog.Error("task version mismatch", slog.Any("task_id", taskID), slog.Uint64("version", version))
+			err := responses.ResponseError(w, responses.ErrVersionMismatch, "task was modified by another request",
+				http.StatusPreconditionFailed)
 			if err != nil {
 				slog.Error("failed to send json response", slog.Any("err", err))
 			}
@@ -0,0 +1,111 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/avraam311/tasks-service/internal/api/responses"
+	"github.com/avraam311/tasks-service/internal/models"
+	"github.com/avraam311/tasks-service/internal/repository/tasks"
+)
+
+func (h *Handler) AddDependency(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		slog.Error("not allowed method", slog.String("method", r.Method))
+		err := responses.ResponseError(w, responses.ErrMethodNotAllowed, "only POST allowed", http.StatusMethodNotAllowed)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	h.changeDependency(w, r, h.service.AddDependency)
+}
+
+func (h *Handler) RemoveDependency(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		slog.Error("not allowed method", slog.String("method", r.Method))
+		err := responses.ResponseError(w, responses.ErrMethodNotAllowed, "only DELETE allowed", http.StatusMethodNotAllowed)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	h.changeDependency(w, r, h.service.RemoveDependency)
+}
+
+func (h *Handler) changeDependency(w http.ResponseWriter, r *http.Request,
+	change func(ctx context.Context, taskID, dependencyID uint) (*models.TaskDomain, error)) {
+	taskIDStr := r.PathValue("id")
+	taskIDUint, err := strconv.ParseUint(taskIDStr, 10, strconv.IntSize)
+	if err != nil {
+		slog.Error("failed to convert task id into int", slog.String("tasks id str", taskIDStr))
+		err := responses.ResponseError(w, responses.ErrInvalidID, "invalid task id", http.StatusBadRequest)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+	taskID := uint(taskIDUint)
+
+	dependencyIDStr := r.PathValue("depId")
+	dependencyIDUint, err := strconv.ParseUint(dependencyIDStr, 10, strconv.IntSize)
+	if err != nil {
+		slog.Error("failed to convert dependency id into int", slog.String("dependency id str", dependencyIDStr))
+		err := responses.ResponseError(w, responses.ErrInvalidID, "invalid dependency id", http.StatusBadRequest)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+	dependencyID := uint(dependencyIDUint)
+
+	task, err := change(r.Context(), taskID, dependencyID)
+	if err != nil {
+		var cycleErr *tasks.DependencyCycleError
+		if errors.As(err, &cycleErr) {
+			slog.Error("dependency cycle", slog.Any("task_id", taskID), slog.Any("error", err))
+			err := responses.ResponseErrorDetails(w, responses.ErrDependencyCycle, cycleErr.Error(), cycleErr,
+				http.StatusConflict)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+		if errors.Is(err, tasks.ErrTaskNotFound) {
+			slog.Error("task not found", slog.Any("task_id", taskID))
+			err := responses.ResponseError(w, responses.ErrTaskNotFound, "task not found", http.StatusNotFound)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+		if errors.Is(err, tasks.ErrDependencyNotFound) {
+			slog.Error("dependency not found", slog.Any("task_id", taskID), slog.Any("dependency_id", dependencyID))
+			err := responses.ResponseError(w, responses.ErrDependencyNotFound, "dependency not found",
+				http.StatusNotFound)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+
+		slog.Error("failed to change dependency", slog.Any("task id", taskID), slog.Any("dependency id", dependencyID),
+			slog.Any("error", err))
+		err := responses.ResponseError(w, responses.ErrInternalServer, "internal server error", http.StatusInternalServerError)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(task.Version))
+	err = responses.ResponseOK(w, task)
+	if err != nil {
+		slog.Error("failed to send json response", slog.Any("err", err))
+	}
+}
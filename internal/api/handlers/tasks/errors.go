@@ -0,0 +1,80 @@
+package tasks
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/avraam311/tasks-service/internal/api/responses"
+	"github.com/avraam311/tasks-service/internal/repository/tasks"
+	serviceTasks "github.com/avraam311/tasks-service/internal/service/tasks"
+)
+
+type taskError struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// taskFailure is shared by the single-task endpoints and the batch results.
+func taskFailure(err error) (int, *taskError) {
+	var validationErr *serviceTasks.ValidationError
+	if errors.As(err, &validationErr) {
+		return http.StatusUnprocessableEntity,
+			&taskError{Code: responses.ErrValidation, Message: "task validation failed", Details: validationErr.Fields}
+	}
+	var transitionErr *serviceTasks.TransitionError
+	if errors.As(err, &transitionErr) {
+		return http.StatusConflict,
+			&taskError{Code: responses.ErrIllegalTransition, Message: transitionErr.Error(), Details: transitionErr}
+	}
+
+	switch {
+	case errors.Is(err, tasks.ErrTaskNotFound):
+		return http.StatusNotFound, &taskError{Code: responses.ErrTaskNotFound, Message: "task not found"}
+	case errors.Is(err, serviceTasks.ErrTransitionNotFound):
+		return http.StatusNotFound, &taskError{Code: responses.ErrTransitionNotFound, Message: "transition not found"}
+	case errors.Is(err, tasks.ErrVersionMismatch):
+		return http.StatusPreconditionFailed,
+			&taskError{Code: responses.ErrVersionMismatch, Message: "task was modified by another request"}
+	case errors.Is(err, tasks.ErrTaskHasChildren):
+		return http.StatusConflict, &taskError{Code: responses.ErrTaskHasChildren,
+			Message: "task has children, use children=cascade or children=orphan"}
+	case errors.Is(err, serviceTasks.ErrTaskBlocked):
+		return http.StatusConflict, &taskError{Code: responses.ErrTaskBlocked,
+			Message: "task has unfinished dependencies, use force=true to finish it anyway"}
+	case errors.Is(err, tasks.ErrTaskIDOutOfRange):
+		return http.StatusBadRequest, &taskError{Code: responses.ErrInvalidID, Message: "invalid task id"}
+	case errors.Is(err, tasks.ErrTaskTrashed):
+		return http.StatusConflict, &taskError{Code: responses.ErrTaskTrashed,
+			Message: "task is in the trash, restore it first"}
+	case errors.Is(err, tasks.ErrParentNotFound):
+		return http.StatusUnprocessableEntity, fieldFailure("parent_id", serviceTasks.RuleParent,
+			"parent task does not exist")
+	case errors.Is(err, tasks.ErrParentCycle):
+		return http.StatusUnprocessableEntity, fieldFailure("parent_id", serviceTasks.RuleParent,
+			"must not make the task its own ancestor")
+	case errors.Is(err, serviceTasks.ErrInvalidRecurrence):
+		return http.StatusUnprocessableEntity, fieldFailure("recurrence", serviceTasks.RuleRecurrence, err.Error())
+	}
+
+	slog.Error("task write failed", slog.Any("error", err))
+	return http.StatusInternalServerError,
+		&taskError{Code: responses.ErrInternalServer, Message: "internal server error"}
+}
+
+func fieldFailure(field, rule, message string) *taskError {
+	return &taskError{Code: responses.ErrValidation, Message: "task validation failed",
+		Details: []serviceTasks.FieldError{{Field: field, Rule: rule, Message: message}}}
+}
+
+func responseTaskFailure(w http.ResponseWriter, err error) {
+	statusCode, failure := taskFailure(err)
+	if statusCode != http.StatusInternalServerError {
+		slog.Error("task write rejected", slog.Any("error", err))
+	}
+	err = responses.ResponseErrorDetails(w, failure.Code, failure.Message, failure.Details, statusCode)
+	if err != nil {
+		slog.Error("failed to send json response", slog.Any("err", err))
+	}
+}
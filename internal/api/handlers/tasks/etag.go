@@ -0,0 +1,32 @@
+package tasks
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var errInvalidIfMatch = errors.New("invalid If-Match header")
+
+func formatETag(version uint64) string {
+	return `"` + strconv.FormatUint(version, 10) + `"`
+}
+
+// ok is false when the header is absent or "*".
+func parseIfMatch(r *http.Request) (version uint64, ok bool, err error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, false, nil
+	}
+
+	if !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) || len(header) < 2 {
+		return 0, false, errInvalidIfMatch
+	}
+	version, err = strconv.ParseUint(header[1:len(header)-1], 10, 64)
+	if err != nil {
+		return 0, false, errInvalidIfMatch
+	}
+
+	return version, true, nil
+}
@@ -0,0 +1,224 @@
+package tasks
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avraam311/tasks-service/internal/api/responses"
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+const (
+	formatCSV   = "csv"
+	formatJSONL = "jsonl"
+)
+
+// Tags are separated by line breaks, which a tag never contains; depends_on
+// and reminders by commas.
+var csvColumns = []string{
+	"id", "header", "description", "status", "finished", "due_at", "tags", "parent_id", "depends_on",
+	"recurrence", "reminders", "version", "created_at", "updated_at", "completed_at",
+}
+
+func (h *Handler) ExportTasks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		slog.Error("not allowed method", slog.String("method", r.Method))
+		err := responses.ResponseError(w, responses.ErrMethodNotAllowed, "only GET allowed", http.StatusMethodNotAllowed)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != formatCSV && format != formatJSONL {
+		slog.Error("invalid export format", slog.String("format", format))
+		err := responses.ResponseError(w, responses.ErrInvalidParam, "format must be csv or jsonl", http.StatusBadRequest)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	h.exportTasks(w, r, format)
+}
+
+func (h *Handler) ExportICal(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		slog.Error("not allowed method", slog.String("method", r.Method))
+		err := responses.ResponseError(w, responses.ErrMethodNotAllowed, "only GET allowed", http.StatusMethodNotAllowed)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	h.exportTasks(w, r, formatICal)
+}
+
+func (h *Handler) exportTasks(w http.ResponseWriter, r *http.Request, format string) {
+	query, queryErr := parseTaskQuery(r)
+	if queryErr != nil {
+		slog.Error("invalid task query", slog.String("query", r.URL.RawQuery), slog.Any("error", queryErr))
+		err := responses.ResponseError(w, queryErr.code, queryErr.message, http.StatusBadRequest)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	out := &exportWriter{w: w}
+	var encoder taskEncoder
+	switch format {
+	case formatCSV:
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="tasks.csv"`)
+		encoder = newCSVEncoder(out)
+	case formatJSONL:
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", `attachment; filename="tasks.jsonl"`)
+		encoder = newJSONLEncoder(out)
+	case formatICal:
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		encoder = newICalEncoder(out)
+	}
+
+	err := h.service.ExportTasks(r.Context(), query, encoder.encode)
+	if err == nil {
+		err = encoder.flush()
+	}
+	if err != nil {
+		slog.Error("failed to export tasks", slog.String("format", format), slog.Any("error", err))
+		if out.written {
+			// The status line is gone, all that is left is to cut the
+			// export short.
+			return
+		}
+		w.Header().Del("Content-Disposition")
+		err := responses.ResponseError(w, responses.ErrInternalServer, "internal server error", http.StatusInternalServerError)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+	}
+}
+
+type exportWriter struct {
+	w       io.Writer
+	written bool
+}
+
+func (ew *exportWriter) Write(p []byte) (int, error) {
+	ew.written = true
+	return ew.w.Write(p)
+}
+
+type taskEncoder interface {
+	encode(task *models.TaskDomain) error
+	flush() error
+}
+
+type csvEncoder struct {
+	w      *csv.Writer
+	header bool
+}
+
+func newCSVEncoder(w io.Writer) *csvEncoder {
+	return &csvEncoder{w: csv.NewWriter(w)}
+}
+
+func (e *csvEncoder) encode(task *models.TaskDomain) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	return e.w.Write(csvRecord(task))
+}
+
+func (e *csvEncoder) flush() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.w.Flush()
+
+	return e.w.Error()
+}
+
+// An empty export still names its columns.
+func (e *csvEncoder) writeHeader() error {
+	if e.header {
+		return nil
+	}
+	e.header = true
+
+	return e.w.Write(csvColumns)
+}
+
+func csvRecord(task *models.TaskDomain) []string {
+	dependsOn := make([]string, 0, len(task.DependsOn))
+	for _, taskID := range task.DependsOn {
+		dependsOn = append(dependsOn, strconv.FormatUint(uint64(taskID), 10))
+	}
+	reminders := make([]string, 0, len(task.Reminders))
+	for _, reminder := range task.Reminders {
+		reminders = append(reminders, time.Duration(reminder.Before).String())
+	}
+
+	return []string{
+		strconv.FormatUint(uint64(task.ID), 10),
+		task.Header,
+		task.Description,
+		task.Status,
+		strconv.FormatBool(task.Finished),
+		formatCSVTime(task.DueAt),
+		strings.Join(task.Tags, "\n"),
+		formatCSVID(task.ParentID),
+		strings.Join(dependsOn, ","),
+		task.Recurrence,
+		strings.Join(reminders, ","),
+		strconv.FormatUint(task.Version, 10),
+		formatCSVTime(&task.CreatedAt),
+		formatCSVTime(&task.UpdatedAt),
+		formatCSVTime(task.CompletedAt),
+	}
+}
+
+func formatCSVTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+func formatCSVID(taskID *uint) string {
+	if taskID == nil {
+		return ""
+	}
+
+	return strconv.FormatUint(uint64(*taskID), 10)
+}
+
+type jsonlEncoder struct {
+	buf *bufio.Writer
+	enc *json.Encoder
+}
+
+func newJSONLEncoder(w io.Writer) *jsonlEncoder {
+	buf := bufio.NewWriter(w)
+	return &jsonlEncoder{buf: buf, enc: json.NewEncoder(buf)}
+}
+
+func (e *jsonlEncoder) encode(task *models.TaskDomain) error {
+	return e.enc.Encode(task)
+}
+
+func (e *jsonlEncoder) flush() error {
+	return e.buf.Flush()
+}
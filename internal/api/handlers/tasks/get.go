@@ -9,6 +9,7 @@ import (
 
 	"github.com/avraam311/tasks-service/internal/api/responses"
 	"github.com/avraam311/tasks-service/internal/repository/tasks"
+	serviceTasks "github.com/avraam311/tasks-service/internal/service/tasks"
 )
 
 func (h *Handler) GetAllTasks(w http.ResponseWriter, r *http.Request) {
@@ -21,8 +22,27 @@ func (h *Handler) GetAllTasks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tasks, err := h.service.GetAllTasks(r.Context())
+	query, queryErr := parseTaskQuery(r)
+	if queryErr != nil {
+		slog.Error("invalid task query", slog.String("query", r.URL.RawQuery), slog.Any("error", queryErr))
+		err := responses.ResponseError(w, queryErr.code, queryErr.message, http.StatusBadRequest)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	page, err := h.service.GetAllTasks(r.Context(), query)
 	if err != nil {
+		if errors.Is(err, serviceTasks.ErrInvalidCursor) {
+			slog.Error("invalid cursor", slog.String("cursor", query.Cursor))
+			err := responses.ResponseError(w, responses.ErrInvalidCursor, "invalid cursor", http.StatusBadRequest)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+
 		slog.Error("failed to get all tasks", slog.Any("error", err))
 		err := responses.ResponseError(w, responses.ErrInternalServer, "internal server error", http.StatusInternalServerError)
 		if err != nil {
@@ -31,7 +51,11 @@ func (h *Handler) GetAllTasks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = responses.ResponseOK(w, tasks)
+	if query.Tree {
+		err = responses.ResponsePage(w, page.Trees, page.NextCursor)
+	} else {
+		err = responses.ResponsePage(w, page.Tasks, page.NextCursor)
+	}
 	if err != nil {
 		slog.Error("failed to send json response", slog.Any("err", err))
 	}
@@ -48,7 +72,7 @@ func (h *Handler) GetTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	taskIDStr := strings.TrimPrefix(r.URL.Path, "/todos/")
-	taskIDInt, err := strconv.Atoi(taskIDStr)
+	taskIDUint, err := strconv.ParseUint(taskIDStr, 10, strconv.IntSize)
 	if err != nil {
 		slog.Error("failed to convert task id into int", slog.String("tasks id str", taskIDStr))
 		err := responses.ResponseError(w, responses.ErrInvalidID, "invalid task id", http.StatusBadRequest)
@@ -57,7 +81,24 @@ func (h *Handler) GetTask(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	taskID := uint(taskIDInt)
+	taskID := uint(taskIDUint)
+
+	var tree bool
+	if treeStr := r.URL.Query().Get("tree"); treeStr != "" {
+		tree, err = strconv.ParseBool(treeStr)
+		if err != nil {
+			slog.Error("invalid tree parameter", slog.String("tree", treeStr))
+			err := responses.ResponseError(w, responses.ErrInvalidParam, "tree must be true or false", http.StatusBadRequest)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+	}
+	if tree {
+		h.getTaskTree(w, r, taskID)
+		return
+	}
 
 	task, err := h.service.GetTask(r.Context(), taskID)
 	if err != nil {
@@ -78,6 +119,7 @@ func (h *Handler) GetTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("ETag", formatETag(task.Version))
 	err = responses.ResponseOK(w, task)
 	if err != nil {
 		slog.Error("failed to send json response", slog.Any("err", err))
@@ -2,16 +2,41 @@ package tasks
 
 import (
 	"context"
+	"time"
 
 	"github.com/avraam311/tasks-service/internal/models"
 )
 
 type Service interface {
 	CreateTask(ctx context.Context, task *models.TaskDTO) (uint, error)
-	GetAllTasks(ctx context.Context) ([]*models.TaskDomain, error)
+	GetAllTasks(ctx context.Context, query *models.TaskQuery) (*models.TaskPage, error)
+	ExportTasks(ctx context.Context, query *models.TaskQuery, write func(task *models.TaskDomain) error) error
 	GetTask(ctx context.Context, taskID uint) (*models.TaskDomain, error)
-	UpdateTask(ctx context.Context, taskID uint, task *models.TaskDTO) error
-	DeleteTask(ctx context.Context, taskID uint) error
+	SearchTasks(ctx context.Context, query string, limit int) ([]*models.SearchResult, error)
+	UpdateTask(ctx context.Context, taskID uint, task *models.TaskDTO, force bool) error
+	UpsertTask(ctx context.Context, taskID uint, task *models.TaskDTO, force bool) (bool, error)
+	UpdateTaskIfMatch(ctx context.Context, taskID uint, version uint64, task *models.TaskDTO,
+		force bool) (uint64, error)
+	PatchTask(ctx context.Context, taskID uint, patch []byte, force bool) (*models.TaskDomain, error)
+	JSONPatchTask(ctx context.Context, taskID uint, patch []byte, force bool) (*models.TaskDomain, error)
+	GetChildren(ctx context.Context, taskID uint) ([]*models.TaskDomain, error)
+	GetTaskTree(ctx context.Context, taskID uint) (*models.TaskTree, error)
+	TransitionTask(ctx context.Context, taskID uint, name string, force bool) (*models.TaskDomain, error)
+	PreviewOccurrences(ctx context.Context, taskID uint, n int) ([]time.Time, error)
+	GetHistory(ctx context.Context, taskID uint) ([]*models.RevisionDiff, error)
+	RevertTask(ctx context.Context, taskID uint, number int) (*models.TaskDomain, error)
+	AddDependency(ctx context.Context, taskID, dependencyID uint) (*models.TaskDomain, error)
+	RemoveDependency(ctx context.Context, taskID, dependencyID uint) (*models.TaskDomain, error)
+	DeleteTask(ctx context.Context, taskID uint, mode string) error
+	DeleteTaskIfMatch(ctx context.Context, taskID uint, version uint64, mode string) error
+	BatchTasks(ctx context.Context, ops []models.BatchOperation, atomic bool) ([]*models.BatchResult, error)
+	ImportTasks(ctx context.Context, next func() (*models.ImportRow, error), dryRun,
+		abort bool) (*models.ImportReport, error)
+	GetTrash(ctx context.Context) ([]*models.TaskDomain, error)
+	RestoreTask(ctx context.Context, taskID uint) (*models.TaskDomain, error)
+	PurgeTask(ctx context.Context, taskID uint) error
+	GetTags(ctx context.Context) ([]*models.TagCount, error)
+	RenameTag(ctx context.Context, oldName, newName string) (*models.TagCount, error)
 }
 
 type Handler struct {
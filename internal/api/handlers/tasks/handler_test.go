@@ -2,19 +2,27 @@ package tasks
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/avraam311/tasks-service/internal/api/responses"
 	"github.com/avraam311/tasks-service/internal/mocks"
 	"github.com/avraam311/tasks-service/internal/models"
 	"github.com/avraam311/tasks-service/internal/repository/tasks"
+	serviceTasks "github.com/avraam311/tasks-service/internal/service/tasks"
 )
 
 func TestCreateTask(t *testing.T) {
@@ -46,13 +54,33 @@ func TestCreateTask(t *testing.T) {
 			expectedCode: http.StatusBadRequest,
 			expectedErr:  responses.ErrInvalidJSON,
 		},
+		{
+			name:         "InvalidReminder",
+			method:       http.MethodPost,
+			body:         map[string]interface{}{"header": "Standup", "reminders": []string{"soon"}},
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidJSON,
+		},
+		{
+			name:         "ValidationFailed",
+			method:       http.MethodPost,
+			body:         models.TaskDTO{},
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedErr:  responses.ErrValidation,
+			serviceMock: func() {
+				mockService.EXPECT().CreateTask(gomock.Any(), gomock.Any()).
+					Return(uint(0), &serviceTasks.ValidationError{Fields: []serviceTasks.FieldError{
+						{Field: "header", Rule: serviceTasks.RuleRequired, Message: "is required"},
+					}})
+			},
+		},
 		{
 			name:   "ServiceError",
 			method: http.MethodPost,
 			body: models.TaskDTO{
 				Header:      "Test Task",
 				Description: "Test Description",
-				Finished:    false,
+				Status:      models.StatusTodo,
 			},
 			expectedCode: http.StatusInternalServerError,
 			expectedErr:  responses.ErrInternalServer,
@@ -67,7 +95,7 @@ func TestCreateTask(t *testing.T) {
 			body: models.TaskDTO{
 				Header:      "Test Task",
 				Description: "Test Description",
-				Finished:    false,
+				Status:      models.StatusTodo,
 			},
 			expectedCode: http.StatusCreated,
 			expectedErr:  "",
@@ -76,6 +104,19 @@ func TestCreateTask(t *testing.T) {
 					Return(uint(1), nil)
 			},
 		},
+		{
+			name:         "Reminders",
+			method:       http.MethodPost,
+			body:         map[string]interface{}{"header": "Standup", "reminders": []string{"1h", "15m"}},
+			expectedCode: http.StatusCreated,
+			expectedErr:  "",
+			serviceMock: func() {
+				mockService.EXPECT().CreateTask(gomock.Any(), &models.TaskDTO{
+					Header:    "Standup",
+					Reminders: []models.Duration{models.Duration(time.Hour), models.Duration(15 * time.Minute)},
+				}).Return(uint(1), nil)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -123,6 +164,7 @@ func TestGetAllTasks(t *testing.T) {
 	tests := []struct {
 		name         string
 		method       string
+		query        string
 		expectedCode int
 		expectedErr  string
 		serviceMock  func()
@@ -133,19 +175,88 @@ func TestGetAllTasks(t *testing.T) {
 			expectedCode: http.StatusMethodNotAllowed,
 			expectedErr:  responses.ErrMethodNotAllowed,
 		},
+		{
+			name:         "InvalidLimit",
+			method:       http.MethodGet,
+			query:        "?limit=0",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidLimit,
+		},
+		{
+			name:         "InvalidSort",
+			method:       http.MethodGet,
+			query:        "?sort=priority",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidSort,
+		},
+		{
+			name:         "InvalidFilter",
+			method:       http.MethodGet,
+			query:        "?finished=maybe",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidFilter,
+		},
+		{
+			name:         "InvalidDueBefore",
+			method:       http.MethodGet,
+			query:        "?due_before=2024-05-01T15:00:00",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidFilter,
+		},
+		{
+			name:         "InvalidTagMode",
+			method:       http.MethodGet,
+			query:        "?tag=ops&tag_mode=none",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidFilter,
+		},
+		{
+			name:         "InvalidOverdue",
+			method:       http.MethodGet,
+			query:        "?overdue=soon",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidFilter,
+		},
+		{
+			name:         "InvalidStatus",
+			method:       http.MethodGet,
+			query:        "?status=todo&status=blocked",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidFilter,
+		},
+		{
+			name:         "InvalidUpdatedSince",
+			method:       http.MethodGet,
+			query:        "?updated_since=yesterday",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidFilter,
+		},
+		{
+			name:         "InvalidCursor",
+			method:       http.MethodGet,
+			query:        "?cursor=garbage",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidCursor,
+			serviceMock: func() {
+				mockService.EXPECT().GetAllTasks(gomock.Any(), gomock.Any()).
+					Return(nil, serviceTasks.ErrInvalidCursor)
+			},
+		},
 		{
 			name:         "ServiceError",
 			method:       http.MethodGet,
 			expectedCode: http.StatusInternalServerError,
 			expectedErr:  responses.ErrInternalServer,
 			serviceMock: func() {
-				mockService.EXPECT().GetAllTasks(gomock.Any()).
+				mockService.EXPECT().GetAllTasks(gomock.Any(), gomock.Any()).
 					Return(nil, assert.AnError)
 			},
 		},
 		{
-			name:         "Success",
-			method:       http.MethodGet,
+			name:   "Success",
+			method: http.MethodGet,
+			query: "?limit=1&sort=header,-id&finished=false&header_prefix=Test&updated_since=2024-05-01T15:00:00%2B03:00" +
+				"&overdue=true&due_after=2024-05-01T00:00:00Z&tag=backend&tag=ops&tag_mode=all&status=todo&status=in_review",
 			expectedCode: http.StatusOK,
 			expectedErr:  "",
 			serviceMock: func() {
@@ -157,15 +268,28 @@ func TestGetAllTasks(t *testing.T) {
 						Finished:    false,
 					},
 				}
-				mockService.EXPECT().GetAllTasks(gomock.Any()).
-					Return(tasks, nil)
+				finished := false
+				since := time.Date(2024, 5, 1, 15, 0, 0, 0, time.FixedZone("", 3*60*60))
+				dueAfter := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+				mockService.EXPECT().GetAllTasks(gomock.Any(), &models.TaskQuery{
+					Finished:     &finished,
+					HeaderPrefix: "Test",
+					UpdatedSince: &since,
+					DueAfter:     &dueAfter,
+					Overdue:      true,
+					Statuses:     []string{models.StatusTodo, models.StatusInReview},
+					Tags:         []string{"backend", "ops"},
+					TagMode:      models.TagModeAll,
+					Sort:         []models.SortKey{{Field: models.SortByHeader}, {Field: models.SortByID, Desc: true}},
+					Limit:        1,
+				}).Return(&models.TaskPage{Tasks: tasks, NextCursor: "next"}, nil)
 			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			req := httptest.NewRequest(tt.method, "/todos", nil)
+			req := httptest.NewRequest(tt.method, "/todos"+tt.query, nil)
 			w := httptest.NewRecorder()
 
 			if tt.serviceMock != nil {
@@ -186,6 +310,7 @@ func TestGetAllTasks(t *testing.T) {
 				err := json.Unmarshal(w.Body.Bytes(), &successResp)
 				assert.NoError(t, err)
 				assert.NotNil(t, successResp.Result)
+				assert.Equal(t, "next", successResp.NextCursor)
 			}
 		})
 	}
@@ -254,11 +379,36 @@ func TestGetTask(t *testing.T) {
 					Header:      "Test Task",
 					Description: "Test Description",
 					Finished:    false,
+					Version:     4,
 				}
 				mockService.EXPECT().GetTask(gomock.Any(), uint(1)).
 					Return(task, nil)
 			},
 		},
+		{
+			name:         "InvalidTree",
+			method:       http.MethodGet,
+			path:         "/todos/1?tree=deep",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidParam,
+		},
+		{
+			name:         "Tree",
+			method:       http.MethodGet,
+			path:         "/todos/1?tree=true",
+			expectedCode: http.StatusOK,
+			expectedErr:  "",
+			serviceMock: func() {
+				tree := &models.TaskTree{
+					TaskDomain: &models.TaskDomain{ID: 1, Header: "Parent", Version: 4},
+					Subtasks: []*models.TaskTree{
+						{TaskDomain: &models.TaskDomain{ID: 2, Header: "Child"}, Subtasks: []*models.TaskTree{}},
+					},
+				}
+				mockService.EXPECT().GetTaskTree(gomock.Any(), uint(1)).
+					Return(tree, nil)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -273,6 +423,9 @@ func TestGetTask(t *testing.T) {
 			handler.GetTask(w, req)
 
 			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode == http.StatusOK {
+				assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+			}
 
 			if tt.expectedErr != "" {
 				var errorResp responses.ErrorResponse
@@ -300,6 +453,7 @@ func TestUpdateTask(t *testing.T) {
 		name         string
 		method       string
 		path         string
+		ifMatch      string
 		body         interface{}
 		expectedCode int
 		expectedErr  string
@@ -336,15 +490,56 @@ func TestUpdateTask(t *testing.T) {
 			body: models.TaskDTO{
 				Header:      "Updated Task",
 				Description: "Updated Description",
-				Finished:    true,
+				Status:      models.StatusDone,
 			},
-			expectedCode: http.StatusBadRequest,
+			expectedCode: http.StatusNotFound,
 			expectedErr:  responses.ErrTaskNotFound,
 			serviceMock: func() {
-				mockService.EXPECT().UpdateTask(gomock.Any(), uint(1), gomock.Any()).
+				mockService.EXPECT().UpdateTask(gomock.Any(), uint(1), gomock.Any(), false).
 					Return(tasks.ErrTaskNotFound)
 			},
 		},
+		{
+			name:   "InvalidForce",
+			method: http.MethodPut,
+			path:   "/todos/1?force=maybe",
+			body: models.TaskDTO{
+				Header: "Updated Task",
+				Status: models.StatusDone,
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidParam,
+		},
+		{
+			name:   "TaskBlocked",
+			method: http.MethodPut,
+			path:   "/todos/1",
+			body: models.TaskDTO{
+				Header: "Updated Task",
+				Status: models.StatusDone,
+			},
+			expectedCode: http.StatusConflict,
+			expectedErr:  responses.ErrTaskBlocked,
+			serviceMock: func() {
+				mockService.EXPECT().UpdateTask(gomock.Any(), uint(1), gomock.Any(), false).
+					Return(serviceTasks.ErrTaskBlocked)
+			},
+		},
+		{
+			name:   "ForceFinish",
+			method: http.MethodPut,
+			path:   "/todos/1?force=true",
+			body: models.TaskDTO{
+				Header: "Updated Task",
+				Status: models.StatusDone,
+			},
+			expectedCode: http.StatusCreated,
+			expectedErr:  "",
+			serviceMock: func() {
+				mockService.EXPECT().UpdateTask(gomock.Any(), uint(1), gomock.Any(), true).
+					Return(nil)
+			},
+		},
 		{
 			name:   "ServiceError",
 			method: http.MethodPut,
@@ -352,15 +547,108 @@ func TestUpdateTask(t *testing.T) {
 			body: models.TaskDTO{
 				Header:      "Updated Task",
 				Description: "Updated Description",
-				Finished:    true,
+				Status:      models.StatusDone,
 			},
 			expectedCode: http.StatusInternalServerError,
 			expectedErr:  responses.ErrInternalServer,
 			serviceMock: func() {
-				mockService.EXPECT().UpdateTask(gomock.Any(), uint(1), gomock.Any()).
+				mockService.EXPECT().UpdateTask(gomock.Any(), uint(1), gomock.Any(), false).
 					Return(assert.AnError)
 			},
 		},
+		{
+			name:   "InvalidUpsert",
+			method: http.MethodPut,
+			path:   "/todos/1?upsert=maybe",
+			body: models.TaskDTO{
+				Header: "Updated Task",
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidParam,
+		},
+		{
+			name:   "UpsertCreated",
+			method: http.MethodPut,
+			path:   "/todos/42?upsert=true",
+			body: models.TaskDTO{
+				Header: "New Task",
+			},
+			expectedCode: http.StatusCreated,
+			expectedErr:  "",
+			serviceMock: func() {
+				mockService.EXPECT().UpsertTask(gomock.Any(), uint(42), gomock.Any(), false).
+					Return(true, nil)
+			},
+		},
+		{
+			name:         "NegativeID",
+			method:       http.MethodPut,
+			path:         "/todos/-1?upsert=true",
+			body:         models.TaskDTO{Header: "New Task"},
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidID,
+		},
+		{
+			name:         "IDOutOfRange",
+			method:       http.MethodPut,
+			path:         "/todos/18446744073709551616?upsert=true",
+			body:         models.TaskDTO{Header: "New Task"},
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidID,
+		},
+		{
+			name:         "LargestID",
+			method:       http.MethodPut,
+			path:         "/todos/18446744073709551615?upsert=true",
+			body:         models.TaskDTO{Header: "New Task"},
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidID,
+			serviceMock: func() {
+				mockService.EXPECT().UpsertTask(gomock.Any(), uint(math.MaxUint), gomock.Any(), false).
+					Return(false, tasks.ErrTaskIDOutOfRange)
+			},
+		},
+		{
+			name:    "InvalidIfMatch",
+			method:  http.MethodPut,
+			path:    "/todos/1",
+			ifMatch: "W/\"1\"",
+			body: models.TaskDTO{
+				Header: "Updated Task",
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidIfMatch,
+		},
+		{
+			name:    "VersionMismatch",
+			method:  http.MethodPut,
+			path:    "/todos/1",
+			ifMatch: "\"2\"",
+			body: models.TaskDTO{
+				Header: "Updated Task",
+			},
+			expectedCode: http.StatusPreconditionFailed,
+			expectedErr:  responses.ErrVersionMismatch,
+			serviceMock: func() {
+				mockService.EXPECT().UpdateTaskIfMatch(gomock.Any(), uint(1), uint64(2), gomock.Any(), false).
+					Return(uint64(0), tasks.ErrVersionMismatch)
+			},
+		},
+		{
+			name:    "SuccessIfMatch",
+			method:  http.MethodPut,
+			path:    "/todos/1",
+			ifMatch: "\"2\"",
+			body: models.TaskDTO{
+				Header: "Updated Task",
+			},
+			expectedCode: http.StatusCreated,
+			expectedErr:  "",
+			serviceMock: func() {
+				mockService.EXPECT().UpdateTaskIfMatch(gomock.Any(), uint(1), uint64(2), gomock.Any(), false).
+					Return(uint64(3), nil)
+			},
+		},
 		{
 			name:   "Success",
 			method: http.MethodPut,
@@ -368,12 +656,12 @@ func TestUpdateTask(t *testing.T) {
 			body: models.TaskDTO{
 				Header:      "Updated Task",
 				Description: "Updated Description",
-				Finished:    true,
+				Status:      models.StatusDone,
 			},
 			expectedCode: http.StatusCreated,
 			expectedErr:  "",
 			serviceMock: func() {
-				mockService.EXPECT().UpdateTask(gomock.Any(), uint(1), gomock.Any()).
+				mockService.EXPECT().UpdateTask(gomock.Any(), uint(1), gomock.Any(), false).
 					Return(nil)
 			},
 		},
@@ -393,6 +681,10 @@ func TestUpdateTask(t *testing.T) {
 				req = httptest.NewRequest(tt.method, tt.path, nil)
 			}
 
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+
 			w := httptest.NewRecorder()
 
 			if tt.serviceMock != nil {
@@ -412,7 +704,11 @@ func TestUpdateTask(t *testing.T) {
 				var successResp responses.Success
 				err := json.Unmarshal(w.Body.Bytes(), &successResp)
 				assert.NoError(t, err)
-				assert.Equal(t, responses.SuccessTaskUpdated, successResp.Result)
+				if tt.name == "UpsertCreated" {
+					assert.Equal(t, responses.SuccessTaskCreated, successResp.Result)
+				} else {
+					assert.Equal(t, responses.SuccessTaskUpdated, successResp.Result)
+				}
 			}
 		})
 	}
@@ -429,6 +725,7 @@ func TestDeleteTask(t *testing.T) {
 		name         string
 		method       string
 		path         string
+		ifMatch      string
 		expectedCode int
 		expectedErr  string
 		serviceMock  func()
@@ -451,10 +748,10 @@ func TestDeleteTask(t *testing.T) {
 			name:         "TaskNotFound",
 			method:       http.MethodDelete,
 			path:         "/todos/1",
-			expectedCode: http.StatusBadRequest,
+			expectedCode: http.StatusNotFound,
 			expectedErr:  responses.ErrTaskNotFound,
 			serviceMock: func() {
-				mockService.EXPECT().DeleteTask(gomock.Any(), uint(1)).
+				mockService.EXPECT().DeleteTask(gomock.Any(), uint(1), "").
 					Return(tasks.ErrTaskNotFound)
 			},
 		},
@@ -465,10 +762,51 @@ func TestDeleteTask(t *testing.T) {
 			expectedCode: http.StatusInternalServerError,
 			expectedErr:  responses.ErrInternalServer,
 			serviceMock: func() {
-				mockService.EXPECT().DeleteTask(gomock.Any(), uint(1)).
+				mockService.EXPECT().DeleteTask(gomock.Any(), uint(1), "").
 					Return(assert.AnError)
 			},
 		},
+		{
+			name:         "InvalidChildrenMode",
+			method:       http.MethodDelete,
+			path:         "/todos/1?children=keep",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidParam,
+		},
+		{
+			name:         "TaskHasChildren",
+			method:       http.MethodDelete,
+			path:         "/todos/1?children=reject",
+			expectedCode: http.StatusConflict,
+			expectedErr:  responses.ErrTaskHasChildren,
+			serviceMock: func() {
+				mockService.EXPECT().DeleteTask(gomock.Any(), uint(1), models.ChildrenReject).
+					Return(tasks.ErrTaskHasChildren)
+			},
+		},
+		{
+			name:         "Cascade",
+			method:       http.MethodDelete,
+			path:         "/todos/1?children=cascade",
+			expectedCode: http.StatusOK,
+			expectedErr:  "",
+			serviceMock: func() {
+				mockService.EXPECT().DeleteTask(gomock.Any(), uint(1), models.ChildrenCascade).
+					Return(nil)
+			},
+		},
+		{
+			name:         "VersionMismatch",
+			method:       http.MethodDelete,
+			path:         "/todos/1",
+			ifMatch:      "\"2\"",
+			expectedCode: http.StatusPreconditionFailed,
+			expectedErr:  responses.ErrVersionMismatch,
+			serviceMock: func() {
+				mockService.EXPECT().DeleteTaskIfMatch(gomock.Any(), uint(1), uint64(2), "").
+					Return(tasks.ErrVersionMismatch)
+			},
+		},
 		{
 			name:         "Success",
 			method:       http.MethodDelete,
@@ -476,7 +814,7 @@ func TestDeleteTask(t *testing.T) {
 			expectedCode: http.StatusOK,
 			expectedErr:  "",
 			serviceMock: func() {
-				mockService.EXPECT().DeleteTask(gomock.Any(), uint(1)).
+				mockService.EXPECT().DeleteTask(gomock.Any(), uint(1), "").
 					Return(nil)
 			},
 		},
@@ -485,6 +823,9 @@ func TestDeleteTask(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
 			w := httptest.NewRecorder()
 
 			if tt.serviceMock != nil {
@@ -509,3 +850,2216 @@ func TestDeleteTask(t *testing.T) {
 		})
 	}
 }
+
+func TestPatchTask(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := New(mockService)
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		contentType  string
+		body         string
+		expectedCode int
+		expectedErr  string
+		serviceMock  func()
+	}{
+		{
+			name:         "MethodNotAllowed",
+			method:       http.MethodPut,
+			path:         "/todos/1",
+			expectedCode: http.StatusMethodNotAllowed,
+			expectedErr:  responses.ErrMethodNotAllowed,
+		},
+		{
+			name:         "InvalidID",
+			method:       http.MethodPatch,
+			path:         "/todos/invalid",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidID,
+		},
+		{
+			name:         "UnsupportedContentType",
+			method:       http.MethodPatch,
+			path:         "/todos/1",
+			contentType:  "text/plain",
+			body:         `{"finished": true}`,
+			expectedCode: http.StatusUnsupportedMediaType,
+			expectedErr:  responses.ErrUnsupportedMedia,
+		},
+		{
+			name:         "TaskNotFound",
+			method:       http.MethodPatch,
+			path:         "/todos/1",
+			contentType:  "application/merge-patch+json",
+			body:         `{"finished": true}`,
+			expectedCode: http.StatusNotFound,
+			expectedErr:  responses.ErrTaskNotFound,
+			serviceMock: func() {
+				mockService.EXPECT().PatchTask(gomock.Any(), uint(1), []byte(`{"finished": true}`), false).
+					Return(nil, tasks.ErrTaskNotFound)
+			},
+		},
+		{
+			name:         "InvalidPatch",
+			method:       http.MethodPatch,
+			path:         "/todos/1",
+			contentType:  "application/merge-patch+json",
+			body:         `[]`,
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidPatch,
+			serviceMock: func() {
+				mockService.EXPECT().PatchTask(gomock.Any(), uint(1), gomock.Any(), false).
+					Return(nil, serviceTasks.ErrInvalidPatch)
+			},
+		},
+		{
+			name:         "JSONPatchTestFailed",
+			method:       http.MethodPatch,
+			path:         "/todos/1",
+			contentType:  "application/json-patch+json",
+			body:         `[{"op": "test", "path": "/finished", "value": true}]`,
+			expectedCode: http.StatusConflict,
+			expectedErr:  responses.ErrPatchTestFailed,
+			serviceMock: func() {
+				mockService.EXPECT().JSONPatchTask(gomock.Any(), uint(1), gomock.Any(), false).
+					Return(nil, &serviceTasks.PatchTestError{Index: 0, Path: "/finished"})
+			},
+		},
+		{
+			name:         "JSONPatchSuccess",
+			method:       http.MethodPatch,
+			path:         "/todos/1",
+			contentType:  "application/json-patch+json",
+			body:         `[{"op": "replace", "path": "/finished", "value": true}]`,
+			expectedCode: http.StatusOK,
+			expectedErr:  "",
+			serviceMock: func() {
+				task := &models.TaskDomain{
+					ID:       1,
+					Header:   "Test Task",
+					Finished: true,
+					Version:  2,
+				}
+				mockService.EXPECT().JSONPatchTask(gomock.Any(), uint(1), gomock.Any(), false).
+					Return(task, nil)
+			},
+		},
+		{
+			name:         "TaskBlocked",
+			method:       http.MethodPatch,
+			path:         "/todos/1",
+			contentType:  "application/merge-patch+json",
+			body:         `{"finished": true}`,
+			expectedCode: http.StatusConflict,
+			expectedErr:  responses.ErrTaskBlocked,
+			serviceMock: func() {
+				mockService.EXPECT().PatchTask(gomock.Any(), uint(1), gomock.Any(), false).
+					Return(nil, serviceTasks.ErrTaskBlocked)
+			},
+		},
+		{
+			name:         "ForceFinish",
+			method:       http.MethodPatch,
+			path:         "/todos/1?force=true",
+			contentType:  "application/merge-patch+json",
+			body:         `{"finished": true}`,
+			expectedCode: http.StatusOK,
+			expectedErr:  "",
+			serviceMock: func() {
+				task := &models.TaskDomain{
+					ID:       1,
+					Header:   "Test Task",
+					Finished: true,
+					Version:  2,
+				}
+				mockService.EXPECT().PatchTask(gomock.Any(), uint(1), gomock.Any(), true).
+					Return(task, nil)
+			},
+		},
+		{
+			name:         "Success",
+			method:       http.MethodPatch,
+			path:         "/todos/1",
+			contentType:  "application/merge-patch+json; charset=utf-8",
+			body:         `{"finished": true}`,
+			expectedCode: http.StatusOK,
+			expectedErr:  "",
+			serviceMock: func() {
+				task := &models.TaskDomain{
+					ID:       1,
+					Header:   "Test Task",
+					Finished: true,
+					Version:  2,
+				}
+				mockService.EXPECT().PatchTask(gomock.Any(), uint(1), gomock.Any(), false).
+					Return(task, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+
+			if tt.serviceMock != nil {
+				tt.serviceMock()
+			}
+
+			handler.PatchTask(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			if tt.expectedErr != "" {
+				var errorResp responses.ErrorResponse
+				err := json.Unmarshal(w.Body.Bytes(), &errorResp)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedErr, errorResp.Error.Code)
+				if tt.expectedErr == responses.ErrPatchTestFailed {
+					assert.Equal(t, map[string]interface{}{"index": float64(0), "path": "/finished"}, errorResp.Error.Details)
+				}
+			} else {
+				var successResp responses.Success
+				err := json.Unmarshal(w.Body.Bytes(), &successResp)
+				assert.NoError(t, err)
+				assert.NotNil(t, successResp.Result)
+				assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestFinishedOnWrite(t *testing.T) {
+	ctx := context.Background()
+	repo := tasks.New()
+	service := serviceTasks.New(repo, serviceTasks.SystemClock{}, serviceTasks.DefaultWorkflow(), nil)
+	handler := New(service)
+	_, err := service.CreateTask(ctx, &models.TaskDTO{Header: "a"})
+	require.NoError(t, err)
+
+	steps := []struct {
+		name           string
+		method         string
+		body           string
+		expectedCode   int
+		expectedStatus string
+	}{
+		{"PutFinished", http.MethodPut, `{"header": "a", "finished": true}`, http.StatusCreated, models.StatusDone},
+		{"PutUnfinished", http.MethodPut, `{"header": "a", "finished": false}`, http.StatusCreated, models.StatusTodo},
+		{"PutContradicts", http.MethodPut, `{"header": "a", "status": "done", "finished": false}`,
+			http.StatusUnprocessableEntity, models.StatusTodo},
+		{"PatchFinished", http.MethodPatch, `{"finished": true}`, http.StatusOK, models.StatusDone},
+		{"PatchUnfinished", http.MethodPatch, `{"finished": false}`, http.StatusOK, models.StatusTodo},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			req := httptest.NewRequest(step.method, "/todos/0", strings.NewReader(step.body))
+			w := httptest.NewRecorder()
+			if step.method == http.MethodPatch {
+				req.Header.Set("Content-Type", "application/merge-patch+json")
+				handler.PatchTask(w, req)
+			} else {
+				handler.UpdateTask(w, req)
+			}
+
+			assert.Equal(t, step.expectedCode, w.Code, w.Body.String())
+			task, err := repo.LoadTask(ctx, 0)
+			require.NoError(t, err)
+			assert.Equal(t, step.expectedStatus, task.Status)
+			assert.Equal(t, step.expectedStatus == models.StatusDone, task.Finished)
+		})
+	}
+}
+
+func TestSearchTasks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := New(mockService)
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		expectedCode int
+		expectedErr  string
+		serviceMock  func()
+	}{
+		{
+			name:         "MethodNotAllowed",
+			method:       http.MethodPost,
+			path:         "/todos/search?q=deploy",
+			expectedCode: http.StatusMethodNotAllowed,
+			expectedErr:  responses.ErrMethodNotAllowed,
+		},
+		{
+			name:         "InvalidLimit",
+			method:       http.MethodGet,
+			path:         "/todos/search?q=deploy&limit=abc",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidLimit,
+		},
+		{
+			name:         "EmptyQuery",
+			method:       http.MethodGet,
+			path:         "/todos/search",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidSearch,
+			serviceMock: func() {
+				mockService.EXPECT().SearchTasks(gomock.Any(), "", 0).
+					Return(nil, serviceTasks.ErrEmptySearchQuery)
+			},
+		},
+		{
+			name:         "Success",
+			method:       http.MethodGet,
+			path:         "/todos/search?q=%D0%B4%D0%B5%D0%BF%D0%BB%D0%BE%D0%B9&limit=5",
+			expectedCode: http.StatusOK,
+			expectedErr:  "",
+			serviceMock: func() {
+				results := []*models.SearchResult{
+					{
+						Task:       &models.TaskDomain{ID: 1, Header: "Деплой"},
+						Score:      1.5,
+						Highlights: []models.Highlight{{Field: "header", Start: 0, End: 6}},
+					},
+				}
+				mockService.EXPECT().SearchTasks(gomock.Any(), "деплой", 5).
+					Return(results, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+
+			if tt.serviceMock != nil {
+				tt.serviceMock()
+			}
+
+			handler.SearchTasks(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			if tt.expectedErr != "" {
+				var errorResp responses.ErrorResponse
+				err := json.Unmarshal(w.Body.Bytes(), &errorResp)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedErr, errorResp.Error.Code)
+			} else {
+				var successResp responses.Success
+				err := json.Unmarshal(w.Body.Bytes(), &successResp)
+				assert.NoError(t, err)
+				assert.NotNil(t, successResp.Result)
+			}
+		})
+	}
+}
+
+func TestGetTags(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := New(mockService)
+
+	tests := []struct {
+		name         string
+		method       string
+		expectedCode int
+		expectedErr  string
+		serviceMock  func()
+	}{
+		{
+			name:         "MethodNotAllowed",
+			method:       http.MethodPost,
+			expectedCode: http.StatusMethodNotAllowed,
+			expectedErr:  responses.ErrMethodNotAllowed,
+		},
+		{
+			name:         "ServiceError",
+			method:       http.MethodGet,
+			expectedCode: http.StatusInternalServerError,
+			expectedErr:  responses.ErrInternalServer,
+			serviceMock: func() {
+				mockService.EXPECT().GetTags(gomock.Any()).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name:         "Success",
+			method:       http.MethodGet,
+			expectedCode: http.StatusOK,
+			expectedErr:  "",
+			serviceMock: func() {
+				mockService.EXPECT().GetTags(gomock.Any()).
+					Return([]*models.TagCount{{Name: "backend", Count: 2}, {Name: "ops", Count: 1}}, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/tags", nil)
+			w := httptest.NewRecorder()
+
+			if tt.serviceMock != nil {
+				tt.serviceMock()
+			}
+
+			handler.GetTags(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			if tt.expectedErr != "" {
+				var errorResp responses.ErrorResponse
+				err := json.Unmarshal(w.Body.Bytes(), &errorResp)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedErr, errorResp.Error.Code)
+			} else {
+				var successResp responses.Success
+				err := json.Unmarshal(w.Body.Bytes(), &successResp)
+				assert.NoError(t, err)
+				assert.NotNil(t, successResp.Result)
+			}
+		})
+	}
+}
+
+func TestRenameTag(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := New(mockService)
+
+	tests := []struct {
+		name         string
+		method       string
+		body         string
+		expectedCode int
+		expectedErr  string
+		serviceMock  func()
+	}{
+		{
+			name:         "MethodNotAllowed",
+			method:       http.MethodPost,
+			body:         `{"name": "platform"}`,
+			expectedCode: http.StatusMethodNotAllowed,
+			expectedErr:  responses.ErrMethodNotAllowed,
+		},
+		{
+			name:         "InvalidJSON",
+			method:       http.MethodPut,
+			body:         `invalid json`,
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidJSON,
+		},
+		{
+			name:         "ValidationFailed",
+			method:       http.MethodPut,
+			body:         `{"name": " "}`,
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedErr:  responses.ErrValidation,
+			serviceMock: func() {
+				mockService.EXPECT().RenameTag(gomock.Any(), "ops", " ").Return(nil, &serviceTasks.ValidationError{
+					Fields: []serviceTasks.FieldError{{Field: "name", Rule: serviceTasks.RuleTags, Message: "must not contain blank tags"}},
+				})
+			},
+		},
+		{
+			name:         "TagNotFound",
+			method:       http.MethodPut,
+			body:         `{"name": "platform"}`,
+			expectedCode: http.StatusNotFound,
+			expectedErr:  responses.ErrTagNotFound,
+			serviceMock: func() {
+				mockService.EXPECT().RenameTag(gomock.Any(), "ops", "platform").Return(nil, tasks.ErrTagNotFound)
+			},
+		},
+		{
+			name:         "Success",
+			method:       http.MethodPut,
+			body:         `{"name": "platform"}`,
+			expectedCode: http.StatusOK,
+			expectedErr:  "",
+			serviceMock: func() {
+				mockService.EXPECT().RenameTag(gomock.Any(), "ops", "platform").
+					Return(&models.TagCount{Name: "platform", Count: 3}, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/tags/ops", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			if tt.serviceMock != nil {
+				tt.serviceMock()
+			}
+
+			handler.RenameTag(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			if tt.expectedErr != "" {
+				var errorResp responses.ErrorResponse
+				err := json.Unmarshal(w.Body.Bytes(), &errorResp)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedErr, errorResp.Error.Code)
+			} else {
+				var successResp responses.Success
+				err := json.Unmarshal(w.Body.Bytes(), &successResp)
+				assert.NoError(t, err)
+				assert.NotNil(t, successResp.Result)
+			}
+		})
+	}
+}
+
+func TestGetChildren(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := New(mockService)
+
+	tests := []struct {
+		name         string
+		method       string
+		taskID       string
+		expectedCode int
+		expectedErr  string
+		serviceMock  func()
+	}{
+		{
+			name:         "MethodNotAllowed",
+			method:       http.MethodPost,
+			taskID:       "1",
+			expectedCode: http.StatusMethodNotAllowed,
+			expectedErr:  responses.ErrMethodNotAllowed,
+		},
+		{
+			name:         "InvalidID",
+			method:       http.MethodGet,
+			taskID:       "invalid",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidID,
+		},
+		{
+			name:         "TaskNotFound",
+			method:       http.MethodGet,
+			taskID:       "1",
+			expectedCode: http.StatusNotFound,
+			expectedErr:  responses.ErrTaskNotFound,
+			serviceMock: func() {
+				mockService.EXPECT().GetChildren(gomock.Any(), uint(1)).Return(nil, tasks.ErrTaskNotFound)
+			},
+		},
+		{
+			name:         "Success",
+			method:       http.MethodGet,
+			taskID:       "1",
+			expectedCode: http.StatusOK,
+			expectedErr:  "",
+			serviceMock: func() {
+				parentID := uint(1)
+				mockService.EXPECT().GetChildren(gomock.Any(), uint(1)).
+					Return([]*models.TaskDomain{{ID: 2, Header: "Subtask", ParentID: &parentID}}, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/todos/"+tt.taskID+"/children", nil)
+			req.SetPathValue("id", tt.taskID)
+			w := httptest.NewRecorder()
+
+			if tt.serviceMock != nil {
+				tt.serviceMock()
+			}
+
+			handler.GetChildren(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			if tt.expectedErr != "" {
+				var errorResp responses.ErrorResponse
+				err := json.Unmarshal(w.Body.Bytes(), &errorResp)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedErr, errorResp.Error.Code)
+			} else {
+				var successResp responses.Success
+				err := json.Unmarshal(w.Body.Bytes(), &successResp)
+				assert.NoError(t, err)
+				assert.NotNil(t, successResp.Result)
+			}
+		})
+	}
+}
+
+func TestDependencies(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := New(mockService)
+
+	tests := []struct {
+		name            string
+		method          string
+		taskID          string
+		dependencyID    string
+		expectedCode    int
+		expectedErr     string
+		expectedDetails interface{}
+		serviceMock     func()
+	}{
+		{
+			name:         "MethodNotAllowed",
+			method:       http.MethodGet,
+			taskID:       "1",
+			dependencyID: "2",
+			expectedCode: http.StatusMethodNotAllowed,
+			expectedErr:  responses.ErrMethodNotAllowed,
+		},
+		{
+			name:         "InvalidDependencyID",
+			method:       http.MethodPost,
+			taskID:       "1",
+			dependencyID: "invalid",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidID,
+		},
+		{
+			name:         "DependencyNotFound",
+			method:       http.MethodPost,
+			taskID:       "1",
+			dependencyID: "99",
+			expectedCode: http.StatusNotFound,
+			expectedErr:  responses.ErrDependencyNotFound,
+			serviceMock: func() {
+				mockService.EXPECT().AddDependency(gomock.Any(), uint(1), uint(99)).
+					Return(nil, tasks.ErrDependencyNotFound)
+			},
+		},
+		{
+			name:            "Cycle",
+			method:          http.MethodPost,
+			taskID:          "1",
+			dependencyID:    "2",
+			expectedCode:    http.StatusConflict,
+			expectedErr:     responses.ErrDependencyCycle,
+			expectedDetails: map[string]interface{}{"cycle": []interface{}{float64(1), float64(2), float64(1)}},
+			serviceMock: func() {
+				mockService.EXPECT().AddDependency(gomock.Any(), uint(1), uint(2)).
+					Return(nil, &tasks.DependencyCycleError{Path: []uint{1, 2, 1}})
+			},
+		},
+		{
+			name:         "Add",
+			method:       http.MethodPost,
+			taskID:       "1",
+			dependencyID: "2",
+			expectedCode: http.StatusOK,
+			expectedErr:  "",
+			serviceMock: func() {
+				mockService.EXPECT().AddDependency(gomock.Any(), uint(1), uint(2)).
+					Return(&models.TaskDomain{ID: 1, DependsOn: []uint{2}, Blocked: true, Version: 2}, nil)
+			},
+		},
+		{
+			name:         "RemoveMissing",
+			method:       http.MethodDelete,
+			taskID:       "1",
+			dependencyID: "3",
+			expectedCode: http.StatusNotFound,
+			expectedErr:  responses.ErrDependencyNotFound,
+			serviceMock: func() {
+				mockService.EXPECT().RemoveDependency(gomock.Any(), uint(1), uint(3)).
+					Return(nil, tasks.ErrDependencyNotFound)
+			},
+		},
+		{
+			name:         "Remove",
+			method:       http.MethodDelete,
+			taskID:       "1",
+			dependencyID: "2",
+			expectedCode: http.StatusOK,
+			expectedErr:  "",
+			serviceMock: func() {
+				mockService.EXPECT().RemoveDependency(gomock.Any(), uint(1), uint(2)).
+					Return(&models.TaskDomain{ID: 1, Version: 2}, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/todos/"+tt.taskID+"/dependencies/"+tt.dependencyID, nil)
+			req.SetPathValue("id", tt.taskID)
+			req.SetPathValue("depId", tt.dependencyID)
+			w := httptest.NewRecorder()
+
+			if tt.serviceMock != nil {
+				tt.serviceMock()
+			}
+
+			if tt.method == http.MethodDelete {
+				handler.RemoveDependency(w, req)
+			} else {
+				handler.AddDependency(w, req)
+			}
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			if tt.expectedErr != "" {
+				var errorResp responses.ErrorResponse
+				err := json.Unmarshal(w.Body.Bytes(), &errorResp)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedErr, errorResp.Error.Code)
+				if tt.expectedDetails != nil {
+					assert.Equal(t, tt.expectedDetails, errorResp.Error.Details)
+				}
+			} else {
+				var successResp responses.Success
+				err := json.Unmarshal(w.Body.Bytes(), &successResp)
+				assert.NoError(t, err)
+				assert.NotNil(t, successResp.Result)
+				assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestTransitionTask(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := New(mockService)
+
+	tests := []struct {
+		name            string
+		method          string
+		taskID          string
+		transition      string
+		query           string
+		expectedCode    int
+		expectedErr     string
+		expectedDetails interface{}
+		serviceMock     func()
+	}{
+		{
+			name:         "MethodNotAllowed",
+			method:       http.MethodGet,
+			taskID:       "1",
+			transition:   "start",
+			expectedCode: http.StatusMethodNotAllowed,
+			expectedErr:  responses.ErrMethodNotAllowed,
+		},
+		{
+			name:         "InvalidID",
+			method:       http.MethodPost,
+			taskID:       "invalid",
+			transition:   "start",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidID,
+		},
+		{
+			name:         "InvalidForce",
+			method:       http.MethodPost,
+			taskID:       "1",
+			transition:   "complete",
+			query:        "?force=maybe",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidParam,
+		},
+		{
+			name:         "TaskNotFound",
+			method:       http.MethodPost,
+			taskID:       "99",
+			transition:   "start",
+			expectedCode: http.StatusNotFound,
+			expectedErr:  responses.ErrTaskNotFound,
+			serviceMock: func() {
+				mockService.EXPECT().TransitionTask(gomock.Any(), uint(99), "start", false).
+					Return(nil, tasks.ErrTaskNotFound)
+			},
+		},
+		{
+			name:         "TransitionNotFound",
+			method:       http.MethodPost,
+			taskID:       "1",
+			transition:   "ship",
+			expectedCode: http.StatusNotFound,
+			expectedErr:  responses.ErrTransitionNotFound,
+			serviceMock: func() {
+				mockService.EXPECT().TransitionTask(gomock.Any(), uint(1), "ship", false).
+					Return(nil, serviceTasks.ErrTransitionNotFound)
+			},
+		},
+		{
+			name:         "IllegalTransition",
+			method:       http.MethodPost,
+			taskID:       "1",
+			transition:   "start",
+			expectedCode: http.StatusConflict,
+			expectedErr:  responses.ErrIllegalTransition,
+			expectedDetails: map[string]interface{}{
+				"transition": "start",
+				"from":       models.StatusDone,
+				"allowed":    []interface{}{"reopen"},
+			},
+			serviceMock: func() {
+				mockService.EXPECT().TransitionTask(gomock.Any(), uint(1), "start", false).
+					Return(nil, &serviceTasks.TransitionError{
+						Transition: "start",
+						From:       models.StatusDone,
+						Allowed:    []string{"reopen"},
+					})
+			},
+		},
+		{
+			name:         "TaskBlocked",
+			method:       http.MethodPost,
+			taskID:       "1",
+			transition:   "complete",
+			expectedCode: http.StatusConflict,
+			expectedErr:  responses.ErrTaskBlocked,
+			serviceMock: func() {
+				mockService.EXPECT().TransitionTask(gomock.Any(), uint(1), "complete", false).
+					Return(nil, serviceTasks.ErrTaskBlocked)
+			},
+		},
+		{
+			name:         "Success",
+			method:       http.MethodPost,
+			taskID:       "1",
+			transition:   "complete",
+			query:        "?force=true",
+			expectedCode: http.StatusOK,
+			expectedErr:  "",
+			serviceMock: func() {
+				mockService.EXPECT().TransitionTask(gomock.Any(), uint(1), "complete", true).
+					Return(&models.TaskDomain{ID: 1, Status: models.StatusDone, Finished: true, Version: 2}, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/todos/"+tt.taskID+"/transitions/"+tt.transition+tt.query, nil)
+			req.SetPathValue("id", tt.taskID)
+			req.SetPathValue("name", tt.transition)
+			w := httptest.NewRecorder()
+
+			if tt.serviceMock != nil {
+				tt.serviceMock()
+			}
+
+			handler.TransitionTask(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			if tt.expectedErr != "" {
+				var errorResp responses.ErrorResponse
+				err := json.Unmarshal(w.Body.Bytes(), &errorResp)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedErr, errorResp.Error.Code)
+				if tt.expectedDetails != nil {
+					assert.Equal(t, tt.expectedDetails, errorResp.Error.Details)
+				}
+			} else {
+				var successResp responses.Success
+				err := json.Unmarshal(w.Body.Bytes(), &successResp)
+				assert.NoError(t, err)
+				assert.NotNil(t, successResp.Result)
+				assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestGetOccurrences(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := New(mockService)
+
+	tests := []struct {
+		name         string
+		method       string
+		taskID       string
+		query        string
+		expectedCode int
+		expectedErr  string
+		serviceMock  func()
+	}{
+		{
+			name:         "MethodNotAllowed",
+			method:       http.MethodPost,
+			taskID:       "1",
+			expectedCode: http.StatusMethodNotAllowed,
+			expectedErr:  responses.ErrMethodNotAllowed,
+		},
+		{
+			name:         "InvalidID",
+			method:       http.MethodGet,
+			taskID:       "invalid",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidID,
+		},
+		{
+			name:         "InvalidLimit",
+			method:       http.MethodGet,
+			taskID:       "1",
+			query:        "?limit=101",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidLimit,
+		},
+		{
+			name:         "TaskNotFound",
+			method:       http.MethodGet,
+			taskID:       "99",
+			expectedCode: http.StatusNotFound,
+			expectedErr:  responses.ErrTaskNotFound,
+			serviceMock: func() {
+				mockService.EXPECT().PreviewOccurrences(gomock.Any(), uint(99), 0).
+					Return(nil, tasks.ErrTaskNotFound)
+			},
+		},
+		{
+			name:         "NotRecurring",
+			method:       http.MethodGet,
+			taskID:       "1",
+			expectedCode: http.StatusConflict,
+			expectedErr:  responses.ErrNotRecurring,
+			serviceMock: func() {
+				mockService.EXPECT().PreviewOccurrences(gomock.Any(), uint(1), 0).
+					Return(nil, serviceTasks.ErrNotRecurring)
+			},
+		},
+		{
+			name:         "Success",
+			method:       http.MethodGet,
+			taskID:       "1",
+			query:        "?limit=2",
+			expectedCode: http.StatusOK,
+			expectedErr:  "",
+			serviceMock: func() {
+				mockService.EXPECT().PreviewOccurrences(gomock.Any(), uint(1), 2).Return([]time.Time{
+					time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC),
+					time.Date(2024, 5, 9, 9, 0, 0, 0, time.UTC),
+				}, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/todos/"+tt.taskID+"/occurrences"+tt.query, nil)
+			req.SetPathValue("id", tt.taskID)
+			w := httptest.NewRecorder()
+
+			if tt.serviceMock != nil {
+				tt.serviceMock()
+			}
+
+			handler.GetOccurrences(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			if tt.expectedErr != "" {
+				var errorResp responses.ErrorResponse
+				err := json.Unmarshal(w.Body.Bytes(), &errorResp)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedErr, errorResp.Error.Code)
+			} else {
+				var successResp responses.Success
+				err := json.Unmarshal(w.Body.Bytes(), &successResp)
+				assert.NoError(t, err)
+				assert.Equal(t, []interface{}{"2024-05-06T09:00:00Z", "2024-05-09T09:00:00Z"}, successResp.Result)
+			}
+		})
+	}
+}
+
+func TestGetHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := New(mockService)
+
+	tests := []struct {
+		name         string
+		method       string
+		taskID       string
+		expectedCode int
+		expectedErr  string
+		serviceMock  func()
+	}{
+		{
+			name:         "MethodNotAllowed",
+			method:       http.MethodPost,
+			taskID:       "1",
+			expectedCode: http.StatusMethodNotAllowed,
+			expectedErr:  responses.ErrMethodNotAllowed,
+		},
+		{
+			name:         "InvalidID",
+			method:       http.MethodGet,
+			taskID:       "invalid",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidID,
+		},
+		{
+			name:         "TaskNotFound",
+			method:       http.MethodGet,
+			taskID:       "99",
+			expectedCode: http.StatusNotFound,
+			expectedErr:  responses.ErrTaskNotFound,
+			serviceMock: func() {
+				mockService.EXPECT().GetHistory(gomock.Any(), uint(99)).Return(nil, tasks.ErrTaskNotFound)
+			},
+		},
+		{
+			name:         "Success",
+			method:       http.MethodGet,
+			taskID:       "1",
+			expectedCode: http.StatusOK,
+			expectedErr:  "",
+			serviceMock: func() {
+				mockService.EXPECT().GetHistory(gomock.Any(), uint(1)).Return([]*models.RevisionDiff{{
+					Number:  2,
+					Op:      models.RevisionUpdated,
+					Version: 2,
+					Changes: []models.FieldChange{{Field: "header", From: "Task", To: "Renamed"}},
+				}}, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/todos/"+tt.taskID+"/history", nil)
+			req.SetPathValue("id", tt.taskID)
+			w := httptest.NewRecorder()
+
+			if tt.serviceMock != nil {
+				tt.serviceMock()
+			}
+
+			handler.GetHistory(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			if tt.expectedErr != "" {
+				var errorResp responses.ErrorResponse
+				err := json.Unmarshal(w.Body.Bytes(), &errorResp)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedErr, errorResp.Error.Code)
+			} else {
+				var successResp responses.Success
+				err := json.Unmarshal(w.Body.Bytes(), &successResp)
+				assert.NoError(t, err)
+				revisions := successResp.Result.([]interface{})
+				assert.Equal(t, []interface{}{map[string]interface{}{
+					"field": "header", "from": "Task", "to": "Renamed",
+				}}, revisions[0].(map[string]interface{})["changes"])
+			}
+		})
+	}
+}
+
+func TestRevertTask(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := New(mockService)
+
+	tests := []struct {
+		name         string
+		method       string
+		taskID       string
+		revision     string
+		expectedCode int
+		expectedErr  string
+		serviceMock  func()
+	}{
+		{
+			name:         "MethodNotAllowed",
+			method:       http.MethodGet,
+			taskID:       "1",
+			revision:     "1",
+			expectedCode: http.StatusMethodNotAllowed,
+			expectedErr:  responses.ErrMethodNotAllowed,
+		},
+		{
+			name:         "InvalidID",
+			method:       http.MethodPost,
+			taskID:       "invalid",
+			revision:     "1",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidID,
+		},
+		{
+			name:         "InvalidRevision",
+			method:       http.MethodPost,
+			taskID:       "1",
+			revision:     "0",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidParam,
+		},
+		{
+			name:         "TaskNotFound",
+			method:       http.MethodPost,
+			taskID:       "99",
+			revision:     "1",
+			expectedCode: http.StatusNotFound,
+			expectedErr:  responses.ErrTaskNotFound,
+			serviceMock: func() {
+				mockService.EXPECT().RevertTask(gomock.Any(), uint(99), 1).Return(nil, tasks.ErrTaskNotFound)
+			},
+		},
+		{
+			name:         "RevisionNotFound",
+			method:       http.MethodPost,
+			taskID:       "1",
+			revision:     "9",
+			expectedCode: http.StatusNotFound,
+			expectedErr:  responses.ErrRevisionNotFound,
+			serviceMock: func() {
+				mockService.EXPECT().RevertTask(gomock.Any(), uint(1), 9).Return(nil, serviceTasks.ErrRevisionNotFound)
+			},
+		},
+		{
+			name:         "NotRevertible",
+			method:       http.MethodPost,
+			taskID:       "1",
+			revision:     "3",
+			expectedCode: http.StatusConflict,
+			expectedErr:  responses.ErrNotRevertible,
+			serviceMock: func() {
+				mockService.EXPECT().RevertTask(gomock.Any(), uint(1), 3).Return(nil, serviceTasks.ErrNotRevertible)
+			},
+		},
+		{
+			name:         "Trashed",
+			method:       http.MethodPost,
+			taskID:       "1",
+			revision:     "2",
+			expectedCode: http.StatusConflict,
+			expectedErr:  responses.ErrTaskTrashed,
+			serviceMock: func() {
+				mockService.EXPECT().RevertTask(gomock.Any(), uint(1), 2).Return(nil, tasks.ErrTaskTrashed)
+			},
+		},
+		{
+			name:         "ParentGone",
+			method:       http.MethodPost,
+			taskID:       "1",
+			revision:     "1",
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedErr:  responses.ErrValidation,
+			serviceMock: func() {
+				mockService.EXPECT().RevertTask(gomock.Any(), uint(1), 1).
+					Return(nil, &serviceTasks.ValidationError{Fields: []serviceTasks.FieldError{
+						{Field: "parent_id", Rule: serviceTasks.RuleParent, Message: "parent task does not exist"},
+					}})
+			},
+		},
+		{
+			name:         "Success",
+			method:       http.MethodPost,
+			taskID:       "1",
+			revision:     "1",
+			expectedCode: http.StatusOK,
+			expectedErr:  "",
+			serviceMock: func() {
+				mockService.EXPECT().RevertTask(gomock.Any(), uint(1), 1).
+					Return(&models.TaskDomain{ID: 1, Header: "Task", Version: 4}, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/todos/"+tt.taskID+"/revert/"+tt.revision, nil)
+			req.SetPathValue("id", tt.taskID)
+			req.SetPathValue("revision", tt.revision)
+			w := httptest.NewRecorder()
+
+			if tt.serviceMock != nil {
+				tt.serviceMock()
+			}
+
+			handler.RevertTask(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			if tt.expectedErr != "" {
+				var errorResp responses.ErrorResponse
+				err := json.Unmarshal(w.Body.Bytes(), &errorResp)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedErr, errorResp.Error.Code)
+			} else {
+				var successResp responses.Success
+				err := json.Unmarshal(w.Body.Bytes(), &successResp)
+				assert.NoError(t, err)
+				assert.NotNil(t, successResp.Result)
+				assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestGetTrash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := New(mockService)
+
+	deletedAt := time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name         string
+		method       string
+		expectedCode int
+		expectedErr  string
+		serviceMock  func()
+	}{
+		{
+			name:         "MethodNotAllowed",
+			method:       http.MethodPost,
+			expectedCode: http.StatusMethodNotAllowed,
+			expectedErr:  responses.ErrMethodNotAllowed,
+		},
+		{
+			name:         "ServiceError",
+			method:       http.MethodGet,
+			expectedCode: http.StatusInternalServerError,
+			expectedErr:  responses.ErrInternalServer,
+			serviceMock: func() {
+				mockService.EXPECT().GetTrash(gomock.Any()).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name:         "Success",
+			method:       http.MethodGet,
+			expectedCode: http.StatusOK,
+			expectedErr:  "",
+			serviceMock: func() {
+				mockService.EXPECT().GetTrash(gomock.Any()).
+					Return([]*models.TaskDomain{{ID: 1, Header: "Task", DeletedAt: &deletedAt}}, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/trash", nil)
+			w := httptest.NewRecorder()
+
+			if tt.serviceMock != nil {
+				tt.serviceMock()
+			}
+
+			handler.GetTrash(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			if tt.expectedErr != "" {
+				var errorResp responses.ErrorResponse
+				err := json.Unmarshal(w.Body.Bytes(), &errorResp)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedErr, errorResp.Error.Code)
+			} else {
+				assert.Contains(t, w.Body.String(), `"deleted_at":"2024-05-02T12:00:00Z"`)
+			}
+		})
+	}
+}
+
+func TestRestoreTask(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := New(mockService)
+
+	tests := []struct {
+		name         string
+		method       string
+		taskID       string
+		expectedCode int
+		expectedErr  string
+		serviceMock  func()
+	}{
+		{
+			name:         "MethodNotAllowed",
+			method:       http.MethodGet,
+			taskID:       "1",
+			expectedCode: http.StatusMethodNotAllowed,
+			expectedErr:  responses.ErrMethodNotAllowed,
+		},
+		{
+			name:         "InvalidID",
+			method:       http.MethodPost,
+			taskID:       "invalid",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidID,
+		},
+		{
+			name:         "NotInTrash",
+			method:       http.MethodPost,
+			taskID:       "99",
+			expectedCode: http.StatusNotFound,
+			expectedErr:  responses.ErrTaskNotFound,
+			serviceMock: func() {
+				mockService.EXPECT().RestoreTask(gomock.Any(), uint(99)).Return(nil, tasks.ErrTaskNotFound)
+			},
+		},
+		{
+			name:         "ParentTrashed",
+			method:       http.MethodPost,
+			taskID:       "2",
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedErr:  responses.ErrValidation,
+			serviceMock: func() {
+				mockService.EXPECT().RestoreTask(gomock.Any(), uint(2)).
+					Return(nil, &serviceTasks.ValidationError{Fields: []serviceTasks.FieldError{
+						{Field: "parent_id", Rule: serviceTasks.RuleParent, Message: "parent task does not exist"},
+					}})
+			},
+		},
+		{
+			name:         "Success",
+			method:       http.MethodPost,
+			taskID:       "1",
+			expectedCode: http.StatusOK,
+			expectedErr:  "",
+			serviceMock: func() {
+				mockService.EXPECT().RestoreTask(gomock.Any(), uint(1)).
+					Return(&models.TaskDomain{ID: 1, Header: "Task", Version: 3}, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/trash/"+tt.taskID+"/restore", nil)
+			req.SetPathValue("id", tt.taskID)
+			w := httptest.NewRecorder()
+
+			if tt.serviceMock != nil {
+				tt.serviceMock()
+			}
+
+			handler.RestoreTask(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			if tt.expectedErr != "" {
+				var errorResp responses.ErrorResponse
+				err := json.Unmarshal(w.Body.Bytes(), &errorResp)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedErr, errorResp.Error.Code)
+			} else {
+				var successResp responses.Success
+				err := json.Unmarshal(w.Body.Bytes(), &successResp)
+				assert.NoError(t, err)
+				assert.NotNil(t, successResp.Result)
+				assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestPurgeTask(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := New(mockService)
+
+	tests := []struct {
+		name         string
+		method       string
+		taskID       string
+		expectedCode int
+		expectedErr  string
+		serviceMock  func()
+	}{
+		{
+			name:         "MethodNotAllowed",
+			method:       http.MethodPost,
+			taskID:       "1",
+			expectedCode: http.StatusMethodNotAllowed,
+			expectedErr:  responses.ErrMethodNotAllowed,
+		},
+		{
+			name:         "InvalidID",
+			method:       http.MethodDelete,
+			taskID:       "invalid",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidID,
+		},
+		{
+			name:         "NotInTrash",
+			method:       http.MethodDelete,
+			taskID:       "99",
+			expectedCode: http.StatusNotFound,
+			expectedErr:  responses.ErrTaskNotFound,
+			serviceMock: func() {
+				mockService.EXPECT().PurgeTask(gomock.Any(), uint(99)).Return(tasks.ErrTaskNotFound)
+			},
+		},
+		{
+			name:         "ServiceError",
+			method:       http.MethodDelete,
+			taskID:       "1",
+			expectedCode: http.StatusInternalServerError,
+			expectedErr:  responses.ErrInternalServer,
+			serviceMock: func() {
+				mockService.EXPECT().PurgeTask(gomock.Any(), uint(1)).Return(assert.AnError)
+			},
+		},
+		{
+			name:         "Success",
+			method:       http.MethodDelete,
+			taskID:       "1",
+			expectedCode: http.StatusOK,
+			expectedErr:  "",
+			serviceMock: func() {
+				mockService.EXPECT().PurgeTask(gomock.Any(), uint(1)).Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/trash/"+tt.taskID, nil)
+			req.SetPathValue("id", tt.taskID)
+			w := httptest.NewRecorder()
+
+			if tt.serviceMock != nil {
+				tt.serviceMock()
+			}
+
+			handler.PurgeTask(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			if tt.expectedErr != "" {
+				var errorResp responses.ErrorResponse
+				err := json.Unmarshal(w.Body.Bytes(), &errorResp)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedErr, errorResp.Error.Code)
+			} else {
+				var successResp responses.Success
+				err := json.Unmarshal(w.Body.Bytes(), &successResp)
+				assert.NoError(t, err)
+				assert.Equal(t, responses.SuccessTaskPurged, successResp.Result)
+			}
+		})
+	}
+}
+
+func TestBatchTasks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := New(mockService)
+
+	taskID := uint(1)
+	tests := []struct {
+		name         string
+		method       string
+		body         string
+		expectedCode int
+		expectedErr  string
+		expectedBody string
+		serviceMock  func()
+	}{
+		{
+			name:         "MethodNotAllowed",
+			method:       http.MethodGet,
+			expectedCode: http.StatusMethodNotAllowed,
+			expectedErr:  responses.ErrMethodNotAllowed,
+		},
+		{
+			name:         "InvalidJSON",
+			method:       http.MethodPost,
+			body:         `{"operations": [`,
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidJSON,
+		},
+		{
+			name:         "InvalidMode",
+			method:       http.MethodPost,
+			body:         `{"mode": "eventually", "operations": [{"op": "delete", "id": 1}]}`,
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidParam,
+		},
+		{
+			name:         "Empty",
+			method:       http.MethodPost,
+			body:         `{"operations": []}`,
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidParam,
+		},
+		{
+			name:         "AtomicFailure",
+			method:       http.MethodPost,
+			body:         `{"operations": [{"op": "create", "task": {"header": "A"}}, {"op": "delete", "id": 9}]}`,
+			expectedCode: http.StatusNotFound,
+			expectedErr:  responses.ErrBatchFailed,
+			expectedBody: `"status":"failed","id":9,"error":{"code":"TASK_NOT_FOUND","message":"task not found"}`,
+			serviceMock: func() {
+				failedID := uint(9)
+				mockService.EXPECT().BatchTasks(gomock.Any(), gomock.Len(2), true).Return([]*models.BatchResult{
+					{Index: 0, Op: models.BatchCreate, Status: models.BatchRolledBack},
+					{Index: 1, Op: models.BatchDelete, Status: models.BatchFailed, ID: &failedID,
+						Err: tasks.ErrTaskNotFound},
+				}, &serviceTasks.BatchError{Index: 1, Err: tasks.ErrTaskNotFound})
+			},
+		},
+		{
+			name:         "DanglingParent",
+			method:       http.MethodPost,
+			body:         `{"operations": [{"op": "create", "task": {"header": "A", "parent_id": 9}}, {"op": "delete", "id": 1}]}`,
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedErr:  responses.ErrBatchFailed,
+			expectedBody: `"error":{"code":"VALIDATION_FAILED","message":"task validation failed",` +
+				`"details":[{"field":"parent_id","rule":"parent","message":"parent task does not exist"}]}`,
+			serviceMock: func() {
+				err := fmt.Errorf("repository/tx.go - %w", tasks.ErrParentNotFound)
+				mockService.EXPECT().BatchTasks(gomock.Any(), gomock.Len(2), true).Return([]*models.BatchResult{
+					{Index: 0, Op: models.BatchCreate, Status: models.BatchFailed, Err: err},
+					{Index: 1, Op: models.BatchDelete, Status: models.BatchSkipped, ID: &taskID},
+				}, &serviceTasks.BatchError{Index: 0, Err: err})
+			},
+		},
+		{
+			name:         "ServiceError",
+			method:       http.MethodPost,
+			body:         `{"operations": [{"op": "delete", "id": 1}]}`,
+			expectedCode: http.StatusInternalServerError,
+			expectedErr:  responses.ErrInternalServer,
+			serviceMock: func() {
+				mockService.EXPECT().BatchTasks(gomock.Any(), gomock.Any(), true).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name:   "BestEffort",
+			method: http.MethodPost,
+			body: `{"mode": "best_effort", "operations": [{"op": "create", "task": {"header": "A"}},
+				{"op": "create", "task": {"header": ""}}]}`,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"index":1,"op":"create","status":"failed","error":{"code":"VALIDATION_FAILED"`,
+			serviceMock: func() {
+				mockService.EXPECT().BatchTasks(gomock.Any(), gomock.Any(), false).Return([]*models.BatchResult{
+					{Index: 0, Op: models.BatchCreate, Status: models.BatchDone, ID: &taskID, Version: 1},
+					{Index: 1, Op: models.BatchCreate, Status: models.BatchFailed,
+						Err: &serviceTasks.ValidationError{Fields: []serviceTasks.FieldError{
+							{Field: "header", Rule: serviceTasks.RuleRequired, Message: "is required"},
+						}}},
+				}, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/todos/batch", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			if tt.serviceMock != nil {
+				tt.serviceMock()
+			}
+
+			handler.BatchTasks(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+
+			if tt.expectedErr != "" {
+				var errorResp responses.ErrorResponse
+				err := json.Unmarshal(w.Body.Bytes(), &errorResp)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedErr, errorResp.Error.Code)
+			} else {
+				var successResp responses.Success
+				err := json.Unmarshal(w.Body.Bytes(), &successResp)
+				assert.NoError(t, err)
+				assert.Len(t, successResp.Result, 2)
+			}
+		})
+	}
+}
+
+func TestExportTasks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := New(mockService)
+
+	dueAt := time.Date(2024, 5, 10, 9, 0, 0, 0, time.UTC)
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	parentID := uint(1)
+	exported := []*models.TaskDomain{
+		{ID: 1, Header: "Plain", Status: models.StatusTodo, Version: 1, CreatedAt: createdAt, UpdatedAt: createdAt},
+		{
+			ID:          2,
+			Header:      `Say "hi", then leave`,
+			Description: "first line\nsecond, line",
+			Status:      models.StatusInProgress,
+			DueAt:       &dueAt,
+			Tags:        []string{"home", "a,b"},
+			ParentID:    &parentID,
+			DependsOn:   []uint{1, 3},
+			Reminders:   []models.Reminder{{Before: models.Duration(time.Hour)}},
+			Version:     4,
+			CreatedAt:   createdAt,
+			UpdatedAt:   createdAt,
+		},
+	}
+	exportAll := func(ctx context.Context, query *models.TaskQuery,
+		write func(task *models.TaskDomain) error) error {
+		for _, task := range exported {
+			if err := write(task); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name         string
+		method       string
+		url          string
+		expectedCode int
+		expectedErr  string
+		expectedType string
+		expectedBody string
+		serviceMock  func()
+	}{
+		{
+			name:         "MethodNotAllowed",
+			method:       http.MethodPost,
+			url:          "/todos/export?format=csv",
+			expectedCode: http.StatusMethodNotAllowed,
+			expectedErr:  responses.ErrMethodNotAllowed,
+		},
+		{
+			name:         "MissingFormat",
+			method:       http.MethodGet,
+			url:          "/todos/export",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidParam,
+		},
+		{
+			name:         "InvalidFilter",
+			method:       http.MethodGet,
+			url:          "/todos/export?format=csv&finished=maybe",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidFilter,
+		},
+		{
+			name:         "ServiceError",
+			method:       http.MethodGet,
+			url:          "/todos/export?format=jsonl",
+			expectedCode: http.StatusInternalServerError,
+			expectedErr:  responses.ErrInternalServer,
+			serviceMock: func() {
+				mockService.EXPECT().ExportTasks(gomock.Any(), gomock.Any(), gomock.Any()).Return(assert.AnError)
+			},
+		},
+		{
+			name:         "CSV",
+			method:       http.MethodGet,
+			url:          "/todos/export?format=csv&status=todo&status=in_progress",
+			expectedCode: http.StatusOK,
+			expectedType: "text/csv; charset=utf-8",
+			expectedBody: "id,header,description,status,finished,due_at,tags,parent_id,depends_on,recurrence," +
+				"reminders,version,created_at,updated_at,completed_at\n" +
+				"1,Plain,,todo,false,,,,,,,1,2024-05-01T12:00:00Z,2024-05-01T12:00:00Z,\n" +
+				"2,\"Say \"\"hi\"\", then leave\",\"first line\nsecond, line\",in_progress,false,2024-05-10T09:00:00Z," +
+				"\"home\na,b\",1,\"1,3\",,1h0m0s,4,2024-05-01T12:00:00Z,2024-05-01T12:00:00Z,\n",
+			serviceMock: func() {
+				mockService.EXPECT().ExportTasks(gomock.Any(), &models.TaskQuery{
+					Statuses: []string{models.StatusTodo, models.StatusInProgress},
+					TagMode:  models.TagModeAny,
+				}, gomock.Any()).DoAndReturn(exportAll)
+			},
+		},
+		{
+			name:         "EmptyCSV",
+			method:       http.MethodGet,
+			url:          "/todos/export?format=csv",
+			expectedCode: http.StatusOK,
+			expectedType: "text/csv; charset=utf-8",
+			expectedBody: "id,header,description,status,finished,due_at,tags,parent_id,depends_on,recurrence," +
+				"reminders,version,created_at,updated_at,completed_at\n",
+			serviceMock: func() {
+				mockService.EXPECT().ExportTasks(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:         "JSONL",
+			method:       http.MethodGet,
+			url:          "/todos/export?format=jsonl",
+			expectedCode: http.StatusOK,
+			expectedType: "application/x-ndjson",
+			serviceMock: func() {
+				mockService.EXPECT().ExportTasks(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(exportAll)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, nil)
+			w := httptest.NewRecorder()
+
+			if tt.serviceMock != nil {
+				tt.serviceMock()
+			}
+
+			handler.ExportTasks(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			if tt.expectedErr != "" {
+				var errorResp responses.ErrorResponse
+				err := json.Unmarshal(w.Body.Bytes(), &errorResp)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedErr, errorResp.Error.Code)
+				assert.Empty(t, w.Header().Get("Content-Disposition"))
+				return
+			}
+
+			assert.Equal(t, tt.expectedType, w.Header().Get("Content-Type"))
+			assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, w.Body.String())
+				return
+			}
+
+			lines := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n")
+			assert.Len(t, lines, len(exported))
+			for i, line := range lines {
+				var task models.TaskDomain
+				assert.NoError(t, json.Unmarshal([]byte(line), &task))
+				assert.Equal(t, exported[i].ID, task.ID)
+				assert.Equal(t, exported[i].Description, task.Description)
+			}
+		})
+	}
+}
+
+func TestImportTasks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := New(mockService)
+
+	dueAt := time.Date(2024, 5, 10, 9, 0, 0, 0, time.UTC)
+	parentID := uint(1)
+	// drain reads every row the way the service does and reports them back.
+	var read []*models.ImportRow
+	drain := func(ctx context.Context, next func() (*models.ImportRow, error), dryRun,
+		abort bool) (*models.ImportReport, error) {
+		read = nil
+		report := &models.ImportReport{DryRun: dryRun}
+		for {
+			row, err := next()
+			if err == io.EOF {
+				return report, nil
+			}
+			if err != nil {
+				return report, fmt.Errorf("service/import.go - %w", err)
+			}
+			read = append(read, row)
+			report.Rows++
+			if row.Err != nil {
+				report.Failed++
+				report.Errors = append(report.Errors, models.ImportError{Row: row.Row, Err: row.Err})
+			}
+		}
+	}
+	exportedCSV := "id,header,description,status,finished,due_at,tags,parent_id,depends_on,recurrence," +
+		"reminders,version,created_at,updated_at,completed_at\n" +
+		"2,\"Say \"\"hi\"\", then leave\",\"first line\nsecond, line\",in_progress,false,2024-05-10T09:00:00Z," +
+		"\"home\na,b\",1,\"1,3\",,1h0m0s,4,2024-05-01T12:00:00Z,2024-05-01T12:00:00Z,\n"
+
+	tests := []struct {
+		name         string
+		url          string
+		contentType  string
+		body         string
+		expectedCode int
+		expectedErr  string
+		expectedBody string
+		expectedRows []*models.ImportRow
+		serviceMock  func()
+	}{
+		{
+			name:         "InvalidOnError",
+			url:          "/todos/import?format=csv&on_error=retry",
+			body:         "header\nTask\n",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidParam,
+		},
+		{
+			name:         "UnknownFormat",
+			url:          "/todos/import",
+			contentType:  "application/json",
+			body:         `{"header": "Task"}`,
+			expectedCode: http.StatusUnsupportedMediaType,
+			expectedErr:  responses.ErrUnsupportedMedia,
+		},
+		{
+			name:         "UnknownColumn",
+			url:          "/todos/import",
+			contentType:  "text/csv",
+			body:         "header,priority\nTask,high\n",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidImport,
+		},
+		{
+			name:         "MissingHeaderColumn",
+			url:          "/todos/import?format=csv",
+			body:         "description\nNo header\n",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidImport,
+		},
+		{
+			name:         "ExportedCSV",
+			url:          "/todos/import?dry_run=true&on_error=abort",
+			contentType:  "text/csv; charset=utf-8",
+			body:         exportedCSV,
+			expectedCode: http.StatusOK,
+			expectedBody: `"dry_run":true`,
+			expectedRows: []*models.ImportRow{{Row: 2, Task: &models.TaskDTO{
+				Header:      `Say "hi", then leave`,
+				Description: "first line\nsecond, line",
+				Status:      models.StatusInProgress,
+				DueAt:       &dueAt,
+				Tags:        []string{"home", "a,b"},
+				ParentID:    &parentID,
+				Reminders:   []models.Duration{models.Duration(time.Hour)},
+			}}},
+			serviceMock: func() {
+				mockService.EXPECT().ImportTasks(gomock.Any(), gomock.Any(), true, true).DoAndReturn(drain)
+			},
+		},
+		{
+			name:         "InvalidCSVRows",
+			url:          "/todos/import?format=csv",
+			body:         "header,due_at\nFirst,tomorrow\nSecond\nThird,\n",
+			expectedCode: http.StatusOK,
+			expectedBody: `{"row":2,"code":"INVALID_ROW","message":"due_at must be an RFC 3339 timestamp"}`,
+			expectedRows: []*models.ImportRow{
+				{Row: 2, Err: &rowError{message: "due_at must be an RFC 3339 timestamp"}},
+				{Row: 3, Err: &rowError{message: "must have 2 fields, has 1"}},
+				{Row: 4, Task: &models.TaskDTO{Header: "Third"}},
+			},
+			serviceMock: func() {
+				mockService.EXPECT().ImportTasks(gomock.Any(), gomock.Any(), false, false).DoAndReturn(drain)
+			},
+		},
+		{
+			name:         "BrokenCSV",
+			url:          "/todos/import?format=csv",
+			body:         "header\n\"unterminated\n",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidImport,
+			serviceMock: func() {
+				mockService.EXPECT().ImportTasks(gomock.Any(), gomock.Any(), false, false).DoAndReturn(drain)
+			},
+		},
+		{
+			name:        "JSONL",
+			url:         "/todos/import",
+			contentType: "application/x-ndjson",
+			body: "{\"header\": \"First\", \"reminders\": [\"30m\"]}\n\n{not json}\n" +
+				"{\"id\": 7, \"header\": \"Exported\", \"version\": 2, \"reminders\": [{\"before\": \"1h0m0s\"}]}\n",
+			expectedCode: http.StatusOK,
+			expectedRows: []*models.ImportRow{
+				{Row: 1, Task: &models.TaskDTO{Header: "First",
+					Reminders: []models.Duration{models.Duration(30 * time.Minute)}}},
+				{Row: 3, Err: &rowError{
+					message: "invalid JSON: invalid character 'n' looking for beginning of object key string"}},
+				{Row: 4, Task: &models.TaskDTO{Header: "Exported",
+					Reminders: []models.Duration{models.Duration(time.Hour)}}},
+			},
+			serviceMock: func() {
+				mockService.EXPECT().ImportTasks(gomock.Any(), gomock.Any(), false, false).DoAndReturn(drain)
+			},
+		},
+		{
+			name:        "MultipartUpload",
+			url:         "/todos/import",
+			contentType: "multipart/form-data; boundary=xyz",
+			body: "--xyz\r\nContent-Disposition: form-data; name=\"note\"\r\n\r\nignored\r\n" +
+				"--xyz\r\nContent-Disposition: form-data; name=\"file\"; filename=\"tasks.csv\"\r\n" +
+				"Content-Type: application/octet-stream\r\n\r\nheader\nUploaded\n\r\n--xyz--\r\n",
+			expectedCode: http.StatusOK,
+			expectedRows: []*models.ImportRow{{Row: 2, Task: &models.TaskDTO{Header: "Uploaded"}}},
+			serviceMock: func() {
+				mockService.EXPECT().ImportTasks(gomock.Any(), gomock.Any(), false, false).DoAndReturn(drain)
+			},
+		},
+		{
+			name:         "ServiceError",
+			url:          "/todos/import?format=jsonl",
+			body:         "{\"header\": \"First\"}\n",
+			expectedCode: http.StatusInternalServerError,
+			expectedErr:  responses.ErrInternalServer,
+			serviceMock: func() {
+				mockService.EXPECT().ImportTasks(gomock.Any(), gomock.Any(), false, false).
+					Return(&models.ImportReport{Rows: 1}, assert.AnError)
+			},
+		},
+		{
+			name:         "ValidationErrorsInReport",
+			url:          "/todos/import?format=jsonl",
+			body:         "{\"header\": \"\"}\n",
+			expectedCode: http.StatusOK,
+			expectedBody: `"errors":[{"row":1,"code":"VALIDATION_FAILED"`,
+			serviceMock: func() {
+				mockService.EXPECT().ImportTasks(gomock.Any(), gomock.Any(), false, false).
+					Return(&models.ImportReport{Rows: 1, Failed: 1, Errors: []models.ImportError{{
+						Row: 1,
+						Err: &serviceTasks.ValidationError{Fields: []serviceTasks.FieldError{
+							{Field: "header", Rule: serviceTasks.RuleRequired, Message: "is required"},
+						}},
+					}}}, nil)
+			},
+		},
+	}
+
+	t.Run("MethodNotAllowed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/todos/import", nil)
+		w := httptest.NewRecorder()
+
+		handler.ImportTasks(w, req)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.url, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+
+			read = nil
+			if tt.serviceMock != nil {
+				tt.serviceMock()
+			}
+
+			handler.ImportTasks(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.expectedBody)
+
+			if tt.expectedErr != "" {
+				var errorResp responses.ErrorResponse
+				err := json.Unmarshal(w.Body.Bytes(), &errorResp)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedErr, errorResp.Error.Code)
+			}
+			if tt.expectedRows != nil {
+				assert.Equal(t, tt.expectedRows, read)
+			}
+		})
+	}
+}
+
+func TestExportICal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := New(mockService)
+
+	dueAt := time.Date(2024, 5, 10, 9, 0, 0, 0, time.UTC)
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	parentID := uint(1)
+	exported := []*models.TaskDomain{
+		{
+			ID:          2,
+			Header:      "Plan; review, ship",
+			Description: strings.Repeat("Описание задачи ", 8) + "\nback\\slash",
+			Status:      models.StatusInReview,
+			DueAt:       &dueAt,
+			Tags:        []string{"work", "a,b"},
+			ParentID:    &parentID,
+			Reminders:   []models.Reminder{{Before: models.Duration(90 * time.Minute)}},
+			Version:     3,
+			CreatedAt:   createdAt,
+			UpdatedAt:   createdAt,
+		},
+		{ID: 3, Header: "Done", Status: models.StatusDone, Finished: true, Version: 1,
+			CreatedAt: createdAt, UpdatedAt: createdAt, CompletedAt: &createdAt},
+	}
+
+	t.Run("MethodNotAllowed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/todos.ics", nil)
+		w := httptest.NewRecorder()
+
+		handler.ExportICal(w, req)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+
+	t.Run("InvalidFilter", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/todos.ics?tag_mode=some", nil)
+		w := httptest.NewRecorder()
+
+		handler.ExportICal(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Feed", func(t *testing.T) {
+		mockService.EXPECT().ExportTasks(gomock.Any(), &models.TaskQuery{
+			Tags:    []string{"work"},
+			TagMode: models.TagModeAny,
+		}, gomock.Any()).DoAndReturn(func(ctx context.Context, query *models.TaskQuery,
+			write func(task *models.TaskDomain) error) error {
+			for _, task := range exported {
+				if err := write(task); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/todos.ics?tag=work", nil)
+		w := httptest.NewRecorder()
+
+		handler.ExportICal(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/calendar; charset=utf-8", w.Header().Get("Content-Type"))
+
+		body := w.Body.String()
+		require.True(t, strings.HasSuffix(body, "END:VCALENDAR\r\n"))
+		for _, line := range strings.Split(strings.TrimSuffix(body, "\r\n"), "\r\n") {
+			assert.LessOrEqual(t, len(line), 75, line)
+			assert.True(t, utf8.ValidString(line), line)
+		}
+
+		unfolded := strings.ReplaceAll(body, "\r\n ", "")
+		for _, line := range []string{
+			"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n",
+			"BEGIN:VTODO\r\nUID:task-2@tasks-service\r\nDTSTAMP:20240501T120000Z\r\n",
+			"SEQUENCE:2\r\n",
+			`SUMMARY:Plan\; review\, ship` + "\r\n",
+			"DESCRIPTION:" + strings.Repeat("Описание задачи ", 8) + `\nback\\slash` + "\r\n",
+			"STATUS:IN-PROCESS\r\n",
+			"DUE:20240510T090000Z\r\n",
+			`CATEGORIES:work,a\,b` + "\r\n",
+			"RELATED-TO;RELTYPE=PARENT:task-1@tasks-service\r\n",
+			"TRIGGER;RELATED=END:-PT1H30M\r\n",
+			"UID:task-3@tasks-service\r\n",
+			"STATUS:COMPLETED\r\nCOMPLETED:20240501T120000Z\r\n",
+		} {
+			assert.Contains(t, unfolded, line)
+		}
+	})
+}
+
+func TestImportICal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	handler := New(mockService)
+
+	dueAt := time.Date(2024, 5, 10, 9, 0, 0, 0, time.UTC)
+	parentID := uint(1)
+	var read []*models.ImportRow
+	drain := func(ctx context.Context, next func() (*models.ImportRow, error), dryRun,
+		abort bool) (*models.ImportReport, error) {
+		report := &models.ImportReport{DryRun: dryRun}
+		for {
+			row, err := next()
+			if err == io.EOF {
+				return report, nil
+			}
+			if err != nil {
+				return report, fmt.Errorf("service/import.go - %w", err)
+			}
+			read = append(read, row)
+			report.Rows++
+		}
+	}
+
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode int
+		expectedErr  string
+		expectedRows []*models.ImportRow
+	}{
+		{
+			name: "VTODOs",
+			body: "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//test//EN\r\n" +
+				"BEGIN:VEVENT\r\nSUMMARY:Not a task\r\nEND:VEVENT\r\n" +
+				"BEGIN:VTODO\r\nUID:abc@example.com\r\nSUMMARY:Plan\\; review\\, \r\n ship\r\n" +
+				"DESCRIPTION:line one\\nline two\r\nSTATUS:IN-PROCESS\r\nDUE:20240510T090000Z\r\n" +
+				"CATEGORIES:work,a\\,b\r\nCATEGORIES:home\r\nRELATED-TO:task-1@tasks-service\r\n" +
+				"BEGIN:VALARM\r\nACTION:DISPLAY\r\nTRIGGER;RELATED=END:-PT1H30M\r\nEND:VALARM\r\n" +
+				"BEGIN:VALARM\r\nACTION:DISPLAY\r\nTRIGGER:-PT15M\r\nEND:VALARM\r\nEND:VTODO\r\n" +
+				"BEGIN:VTODO\r\nSUMMARY:Date only\r\nDUE;VALUE=DATE:20240510\r\nSTATUS:COMPLETED\r\n" +
+				"RRULE:FREQ=WEEKLY\r\nEND:VTODO\r\n" +
+				"BEGIN:VTODO\r\nSUMMARY:Broken\r\nSTATUS:WAITING\r\nEND:VTODO\r\n" +
+				"BEGIN:VTODO\r\nSUMMARY:Date without VALUE\r\nDUE:20240510\r\nEND:VTODO\r\nEND:VCALENDAR\r\n",
+			expectedCode: http.StatusOK,
+			expectedRows: []*models.ImportRow{
+				{Row: 7, Task: &models.TaskDTO{
+					Header:      "Plan; review, ship",
+					Description: "line one\nline two",
+					Status:      models.StatusInProgress,
+					DueAt:       &dueAt,
+					Tags:        []string{"work", "a,b", "home"},
+					ParentID:    &parentID,
+					Reminders:   []models.Duration{models.Duration(90 * time.Minute)},
+				}, UID: "abc@example.com", ParentUID: "task-1@tasks-service"},
+				{Row: 26, Task: &models.TaskDTO{
+					Header:     "Date only",
+					Status:     models.StatusDone,
+					DueAt:      func() *time.Time { d := time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC); return &d }(),
+					Recurrence: "FREQ=WEEKLY",
+				}},
+				{Row: 32, Err: &rowError{
+					message: "STATUS must be NEEDS-ACTION, IN-PROCESS, COMPLETED or CANCELLED",
+				}},
+				{Row: 36, Err: &rowError{message: "DUE must be a date or a date-time"}},
+			},
+		},
+		{
+			name:         "NotACalendar",
+			body:         "header\nTask\n",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidImport,
+		},
+		{
+			name:         "UnterminatedVTODO",
+			body:         "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nSUMMARY:Task\r\n",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidImport,
+		},
+	}
+
+	t.Run("MethodNotAllowed", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/todos/import.ics", nil)
+		w := httptest.NewRecorder()
+
+		handler.ImportICal(w, req)
+
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/todos/import.ics?on_error=skip",
+				strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "text/calendar")
+			w := httptest.NewRecorder()
+
+			read = nil
+			mockService.EXPECT().ImportTasks(gomock.Any(), gomock.Any(), false, false).DoAndReturn(drain)
+
+			handler.ImportICal(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedErr != "" {
+				var errorResp responses.ErrorResponse
+				err := json.Unmarshal(w.Body.Bytes(), &errorResp)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedErr, errorResp.Error.Code)
+			}
+			if tt.expectedRows != nil {
+				assert.Equal(t, tt.expectedRows, read)
+			}
+		})
+	}
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		var feed bytes.Buffer
+		encoder := newICalEncoder(&feed)
+		require.NoError(t, encoder.encode(&models.TaskDomain{
+			ID:          5,
+			Header:      strings.Repeat("Длинный заголовок; ", 6),
+			Description: "a,b\\c\nd",
+			Status:      models.StatusTodo,
+			DueAt:       &dueAt,
+			Tags:        []string{"x;y"},
+			Reminders:   []models.Reminder{{Before: models.Duration(26 * time.Hour)}},
+		}))
+		require.NoError(t, encoder.encode(&models.TaskDomain{
+			ID:         6,
+			Header:     "Standup",
+			Status:     models.StatusTodo,
+			DueAt:      &dueAt,
+			ParentID:   func() *uint { id := uint(5); return &id }(),
+			Recurrence: "FREQ=WEEKLY;BYDAY=MO,WE",
+			TimeZone:   "Europe/Berlin",
+		}))
+		require.NoError(t, encoder.flush())
+		assert.Contains(t, feed.String(), "DUE;TZID=Europe/Berlin:20240510T110000\r\n")
+
+		rows := newICalRows(&feed)
+		row, err := rows.next()
+		require.NoError(t, err)
+		assert.Equal(t, &models.TaskDTO{
+			Header:      strings.Repeat("Длинный заголовок; ", 6),
+			Description: "a,b\\c\nd",
+			Status:      models.StatusTodo,
+			DueAt:       &dueAt,
+			Tags:        []string{"x;y"},
+			Reminders:   []models.Duration{models.Duration(26 * time.Hour)},
+		}, row.Task)
+		assert.Equal(t, "task-5@tasks-service", row.UID)
+		row, err = rows.next()
+		require.NoError(t, err)
+		assert.Equal(t, &models.TaskDTO{
+			Header:     "Standup",
+			Status:     models.StatusTodo,
+			DueAt:      &dueAt,
+			ParentID:   func() *uint { id := uint(5); return &id }(),
+			Recurrence: "FREQ=WEEKLY;BYDAY=MO,WE",
+			TimeZone:   "Europe/Berlin",
+		}, row.Task)
+		assert.Equal(t, "task-5@tasks-service", row.ParentUID)
+		_, err = rows.next()
+		assert.ErrorIs(t, err, io.EOF)
+	})
+}
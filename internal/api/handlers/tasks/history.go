@@ -0,0 +1,155 @@
+package tasks
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/avraam311/tasks-service/internal/api/responses"
+	"github.com/avraam311/tasks-service/internal/repository/tasks"
+	serviceTasks "github.com/avraam311/tasks-service/internal/service/tasks"
+)
+
+func (h *Handler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		slog.Error("not allowed method", slog.String("method", r.Method))
+		err := responses.ResponseError(w, responses.ErrMethodNotAllowed, "only GET allowed", http.StatusMethodNotAllowed)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	taskIDStr := r.PathValue("id")
+	taskIDUint, err := strconv.ParseUint(taskIDStr, 10, strconv.IntSize)
+	if err != nil {
+		slog.Error("failed to convert task id into int", slog.String("tasks id str", taskIDStr))
+		err := responses.ResponseError(w, responses.ErrInvalidID, "invalid task id", http.StatusBadRequest)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+	taskID := uint(taskIDUint)
+
+	history, err := h.service.GetHistory(r.Context(), taskID)
+	if err != nil {
+		if errors.Is(err, tasks.ErrTaskNotFound) {
+			slog.Error("task not found", slog.Any("task_id", taskID))
+			err := responses.ResponseError(w, responses.ErrTaskNotFound, "task not found", http.StatusNotFound)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+
+		slog.Error("failed to get task history", slog.Any("task id", taskID), slog.Any("error", err))
+		err := responses.ResponseError(w, responses.ErrInternalServer, "internal server error", http.StatusInternalServerError)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	err = responses.ResponseOK(w, history)
+	if err != nil {
+		slog.Error("failed to send json response", slog.Any("err", err))
+	}
+}
+
+func (h *Handler) RevertTask(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		slog.Error("not allowed method", slog.String("method", r.Method))
+		err := responses.ResponseError(w, responses.ErrMethodNotAllowed, "only POST allowed", http.StatusMethodNotAllowed)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	taskIDStr := r.PathValue("id")
+	taskIDUint, err := strconv.ParseUint(taskIDStr, 10, strconv.IntSize)
+	if err != nil {
+		slog.Error("failed to convert task id into int", slog.String("tasks id str", taskIDStr))
+		err := responses.ResponseError(w, responses.ErrInvalidID, "invalid task id", http.StatusBadRequest)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+	taskID := uint(taskIDUint)
+
+	revisionStr := r.PathValue("revision")
+	revision, err := strconv.Atoi(revisionStr)
+	if err != nil || revision < 1 {
+		slog.Error("invalid revision", slog.String("revision", revisionStr))
+		err := responses.ResponseError(w, responses.ErrInvalidParam, "revision must be a positive integer",
+			http.StatusBadRequest)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	task, err := h.service.RevertTask(r.Context(), taskID, revision)
+	if err != nil {
+		var validationErr *serviceTasks.ValidationError
+		if errors.As(err, &validationErr) {
+			slog.Error("task validation failed", slog.Any("task_id", taskID), slog.Any("error", err))
+			err := responses.ResponseErrorDetails(w, responses.ErrValidation, "task validation failed",
+				validationErr.Fields, http.StatusUnprocessableEntity)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+		if errors.Is(err, tasks.ErrTaskNotFound) {
+			slog.Error("task not found", slog.Any("task_id", taskID))
+			err := responses.ResponseError(w, responses.ErrTaskNotFound, "task not found", http.StatusNotFound)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+		if errors.Is(err, tasks.ErrTaskTrashed) {
+			slog.Error("task is in the trash", slog.Any("task_id", taskID))
+			err := responses.ResponseError(w, responses.ErrTaskTrashed,
+				"task is in the trash, restore it first", http.StatusConflict)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+		if errors.Is(err, serviceTasks.ErrRevisionNotFound) {
+			slog.Error("revision not found", slog.Any("task_id", taskID), slog.Int("revision", revision))
+			err := responses.ResponseError(w, responses.ErrRevisionNotFound, "revision not found", http.StatusNotFound)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+		if errors.Is(err, serviceTasks.ErrNotRevertible) {
+			slog.Error("revision is not revertible", slog.Any("task_id", taskID), slog.Int("revision", revision))
+			err := responses.ResponseError(w, responses.ErrNotRevertible, "revision deleted the task", http.StatusConflict)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+
+		slog.Error("failed to revert task", slog.Any("task id", taskID), slog.Int("revision", revision),
+			slog.Any("error", err))
+		err := responses.ResponseError(w, responses.ErrInternalServer, "internal server error", http.StatusInternalServerError)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(task.Version))
+	err = responses.ResponseOK(w, task)
+	if err != nil {
+		slog.Error("failed to send json response", slog.Any("err", err))
+	}
+}
@@ -0,0 +1,520 @@
+package tasks
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+const (
+	formatICal = "ics"
+
+	icalProdID    = "-//tasks-service//tasks//EN"
+	icalUIDSuffix = "@tasks-service"
+	icalTime      = "20060102T150405Z"
+	// Not counting the line break.
+	icalLineOctets = 75
+)
+
+// in_review has no VTODO status of its own and is exported as IN-PROCESS.
+var (
+	icalStatuses = map[string]string{
+		models.StatusTodo:       "NEEDS-ACTION",
+		models.StatusInProgress: "IN-PROCESS",
+		models.StatusInReview:   "IN-PROCESS",
+		models.StatusDone:       "COMPLETED",
+		models.StatusCancelled:  "CANCELLED",
+	}
+	icalTaskStatuses = map[string]string{
+		"NEEDS-ACTION": models.StatusTodo,
+		"IN-PROCESS":   models.StatusInProgress,
+		"COMPLETED":    models.StatusDone,
+		"CANCELLED":    models.StatusCancelled,
+	}
+)
+
+var (
+	icalEscaper    = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+	icalDurationRe = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+)
+
+type icalEncoder struct {
+	buf   *bufio.Writer
+	begun bool
+}
+
+func newICalEncoder(w io.Writer) *icalEncoder {
+	return &icalEncoder{buf: bufio.NewWriter(w)}
+}
+
+func (e *icalEncoder) encode(task *models.TaskDomain) error {
+	var b strings.Builder
+	e.begin(&b)
+
+	status := icalStatuses[task.Status]
+	if task.Finished {
+		status = "COMPLETED"
+	}
+
+	writeICalLine(&b, "BEGIN:VTODO")
+	writeICalLine(&b, "UID:"+icalUID(task.ID))
+	writeICalLine(&b, "DTSTAMP:"+task.UpdatedAt.UTC().Format(icalTime))
+	writeICalLine(&b, "CREATED:"+task.CreatedAt.UTC().Format(icalTime))
+	writeICalLine(&b, "LAST-MODIFIED:"+task.UpdatedAt.UTC().Format(icalTime))
+	if task.Version > 0 {
+		writeICalLine(&b, "SEQUENCE:"+strconv.FormatUint(task.Version-1, 10))
+	}
+	writeICalLine(&b, "SUMMARY:"+icalEscaper.Replace(task.Header))
+	if task.Description != "" {
+		writeICalLine(&b, "DESCRIPTION:"+icalEscaper.Replace(task.Description))
+	}
+	if status != "" {
+		writeICalLine(&b, "STATUS:"+status)
+	}
+	if task.DueAt != nil {
+		writeICalLine(&b, icalDue(task))
+	}
+	if task.Recurrence != "" {
+		writeICalLine(&b, "RRULE:"+task.Recurrence)
+	}
+	if task.CompletedAt != nil {
+		writeICalLine(&b, "COMPLETED:"+task.CompletedAt.UTC().Format(icalTime))
+	}
+	if len(task.Tags) > 0 {
+		categories := make([]string, 0, len(task.Tags))
+		for _, tag := range task.Tags {
+			categories = append(categories, icalEscaper.Replace(tag))
+		}
+		writeICalLine(&b, "CATEGORIES:"+strings.Join(categories, ","))
+	}
+	if task.ParentID != nil {
+		writeICalLine(&b, "RELATED-TO;RELTYPE=PARENT:"+icalUID(*task.ParentID))
+	}
+	if task.DueAt != nil {
+		for _, reminder := range task.Reminders {
+			writeICalLine(&b, "BEGIN:VALARM")
+			writeICalLine(&b, "ACTION:DISPLAY")
+			writeICalLine(&b, "DESCRIPTION:"+icalEscaper.Replace(task.Header))
+			writeICalLine(&b, "TRIGGER;RELATED=END:"+formatICalDuration(-time.Duration(reminder.Before)))
+			writeICalLine(&b, "END:VALARM")
+		}
+	}
+	writeICalLine(&b, "END:VTODO")
+
+	_, err := e.buf.WriteString(b.String())
+	return err
+}
+
+func (e *icalEncoder) flush() error {
+	var b strings.Builder
+	e.begin(&b)
+	writeICalLine(&b, "END:VCALENDAR")
+	if _, err := e.buf.WriteString(b.String()); err != nil {
+		return err
+	}
+
+	return e.buf.Flush()
+}
+
+func (e *icalEncoder) begin(b *strings.Builder) {
+	if e.begun {
+		return
+	}
+	e.begun = true
+
+	writeICalLine(b, "BEGIN:VCALENDAR")
+	writeICalLine(b, "VERSION:2.0")
+	writeICalLine(b, "PRODID:"+icalProdID)
+	writeICalLine(b, "CALSCALE:GREGORIAN")
+	writeICalLine(b, "METHOD:PUBLISH")
+}
+
+// Lines are folded without splitting a UTF-8 sequence.
+func writeICalLine(b *strings.Builder, line string) {
+	limit := icalLineOctets
+	for len(line) > limit {
+		cut := limit
+		for !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// The leading space of a continuation line counts towards it.
+		limit = icalLineOctets - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+func icalUID(taskID uint) string {
+	return "task-" + strconv.FormatUint(uint64(taskID), 10) + icalUIDSuffix
+}
+
+func icalTaskID(uid string) (uint, bool) {
+	rest, ok := strings.CutPrefix(uid, "task-")
+	if !ok {
+		return 0, false
+	}
+	rest, ok = strings.CutSuffix(rest, icalUIDSuffix)
+	if !ok {
+		return 0, false
+	}
+	taskID, err := strconv.ParseUint(rest, 10, strconv.IntSize)
+	if err != nil {
+		return 0, false
+	}
+
+	return uint(taskID), true
+}
+
+// A recurring task's due date is written in its time zone, which its RRULE
+// is followed in.
+func icalDue(task *models.TaskDomain) string {
+	if task.Recurrence != "" && task.TimeZone != "" {
+		if loc, err := time.LoadLocation(task.TimeZone); err == nil {
+			return "DUE;TZID=" + task.TimeZone + ":" + task.DueAt.In(loc).Format(strings.TrimSuffix(icalTime, "Z"))
+		}
+	}
+
+	return "DUE:" + task.DueAt.UTC().Format(icalTime)
+}
+
+func formatICalDuration(d time.Duration) string {
+	if d == 0 {
+		return "PT0S"
+	}
+
+	var b strings.Builder
+	if d < 0 {
+		b.WriteByte('-')
+		d = -d
+	}
+	b.WriteByte('P')
+	if days := d / (24 * time.Hour); days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+		d -= days * 24 * time.Hour
+	}
+	if d > 0 {
+		b.WriteByte('T')
+		for _, unit := range []struct {
+			size time.Duration
+			name byte
+		}{{time.Hour, 'H'}, {time.Minute, 'M'}, {time.Second, 'S'}} {
+			if n := d / unit.size; n > 0 {
+				fmt.Fprintf(&b, "%d%c", n, unit.name)
+				d -= n * unit.size
+			}
+		}
+	}
+
+	return b.String()
+}
+
+func parseICalDuration(value string) (time.Duration, bool) {
+	match := icalDurationRe.FindStringSubmatch(value)
+	if match == nil || value == "P" || strings.HasSuffix(value, "T") {
+		return 0, false
+	}
+
+	var d time.Duration
+	for i, unit := range []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		if match[i+2] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(match[i+2])
+		if err != nil {
+			return 0, false
+		}
+		d += time.Duration(n) * unit
+	}
+	if match[1] == "-" {
+		d = -d
+	}
+
+	return d, true
+}
+
+// Rows are numbered by the line of BEGIN:VTODO.
+type icalRows struct {
+	scanner *bufio.Scanner
+	line    int
+	// pending is read ahead to see whether it continues the line before it.
+	pending    string
+	hasPending bool
+	calendar   bool
+}
+
+func newICalRows(r io.Reader) *icalRows {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLine)
+
+	return &icalRows{scanner: scanner}
+}
+
+func (c *icalRows) next() (*models.ImportRow, error) {
+	for {
+		line, lineNo, err := c.readLine()
+		if errors.Is(err, io.EOF) && !c.calendar {
+			return nil, &importFileError{message: "not an iCalendar file: BEGIN:VCALENDAR is missing"}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name, _, value, _ := parseICalLine(line)
+		if !c.calendar {
+			if name != "BEGIN" || !strings.EqualFold(value, "VCALENDAR") {
+				return nil, &importFileError{message: "not an iCalendar file: BEGIN:VCALENDAR is missing"}
+			}
+			c.calendar = true
+			continue
+		}
+		if name == "BEGIN" && strings.EqualFold(value, "VTODO") {
+			return c.readTodo(lineNo)
+		}
+	}
+}
+
+// Only VALARMs triggered relative to the due date become reminders.
+func (c *icalRows) readTodo(row int) (*models.ImportRow, error) {
+	task := &models.TaskDTO{}
+	var uid, parentUID string
+	var rowErr error
+	var nested []string
+	for {
+		line, _, err := c.readLine()
+		if errors.Is(err, io.EOF) {
+			return nil, &importFileError{message: fmt.Sprintf("VTODO at line %d has no END:VTODO", row)}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name, params, value, ok := parseICalLine(line)
+		switch {
+		case !ok:
+			err = &rowError{message: fmt.Sprintf("invalid content line %q", line)}
+		case name == "BEGIN":
+			nested = append(nested, strings.ToUpper(value))
+		case name == "END" && len(nested) == 0:
+			if rowErr != nil {
+				return &models.ImportRow{Row: row, Err: rowErr}, nil
+			}
+			// A parent exported by this service is linked to the task itself
+			// when the file has no row for it.
+			if parentID, ok := icalTaskID(parentUID); ok {
+				task.ParentID = &parentID
+			}
+			return &models.ImportRow{Row: row, Task: task, UID: uid, ParentUID: parentUID}, nil
+		case name == "END":
+			nested = nested[:len(nested)-1]
+		case len(nested) == 1 && nested[0] == "VALARM" && name == "TRIGGER":
+			if strings.EqualFold(params["RELATED"], "END") {
+				d, ok := parseICalDuration(value)
+				if ok {
+					task.Reminders = append(task.Reminders, models.Duration(-d))
+				} else {
+					err = &rowError{message: "TRIGGER must be a duration such as -PT1H"}
+				}
+			}
+		case len(nested) == 0 && name == "UID":
+			uid = value
+		case len(nested) == 0 && name == "RELATED-TO":
+			if relType := strings.ToUpper(params["RELTYPE"]); relType == "" || relType == "PARENT" {
+				parentUID = value
+			}
+		case len(nested) == 0:
+			err = setICalProperty(task, name, params, value)
+		}
+		if err != nil && rowErr == nil {
+			rowErr = err
+		}
+	}
+}
+
+func setICalProperty(task *models.TaskDTO, name string, params map[string]string, value string) error {
+	switch name {
+	case "SUMMARY":
+		task.Header = unescapeICal(value)
+	case "DESCRIPTION":
+		task.Description = unescapeICal(value)
+	case "STATUS":
+		status, ok := icalTaskStatuses[strings.ToUpper(value)]
+		if !ok {
+			return &rowError{message: "STATUS must be NEEDS-ACTION, IN-PROCESS, COMPLETED or CANCELLED"}
+		}
+		task.Status = status
+	case "DUE":
+		dueAt, err := parseICalTime(value, params)
+		if err != nil {
+			return err
+		}
+		task.DueAt = &dueAt
+		task.TimeZone = params["TZID"]
+	case "CATEGORIES":
+		for _, tag := range splitICalList(value) {
+			task.Tags = append(task.Tags, unescapeICal(tag))
+		}
+	case "RRULE":
+		task.Recurrence = value
+	}
+
+	return nil
+}
+
+// Floating times are taken in the TZID of the property, or UTC without one.
+func parseICalTime(value string, params map[string]string) (time.Time, error) {
+	if strings.EqualFold(params["VALUE"], "DATE") {
+		t, err := time.ParseInLocation("20060102", value, time.UTC)
+		if err != nil {
+			return time.Time{}, &rowError{message: "DUE must be a date or a date-time"}
+		}
+		return t, nil
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(icalTime, value)
+		if err != nil {
+			return time.Time{}, &rowError{message: "DUE must be a date or a date-time"}
+		}
+		return t, nil
+	}
+
+	loc := time.UTC
+	if tzid := params["TZID"]; tzid != "" {
+		var err error
+		loc, err = time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, &rowError{message: fmt.Sprintf("DUE has unknown TZID %q", tzid)}
+		}
+	}
+	t, err := time.ParseInLocation(strings.TrimSuffix(icalTime, "Z"), value, loc)
+	if err != nil {
+		return time.Time{}, &rowError{message: "DUE must be a date or a date-time"}
+	}
+
+	return t.UTC(), nil
+}
+
+func (c *icalRows) readLine() (string, int, error) {
+	line, ok := c.pending, c.hasPending
+	if !ok {
+		var err error
+		line, err = c.scan()
+		if err != nil {
+			return "", 0, err
+		}
+	}
+	c.hasPending = false
+	lineNo := c.line
+
+	for {
+		physical, err := c.scan()
+		if errors.Is(err, io.EOF) {
+			return line, lineNo, nil
+		}
+		if err != nil {
+			return "", 0, err
+		}
+		if !strings.HasPrefix(physical, " ") && !strings.HasPrefix(physical, "\t") {
+			c.pending, c.hasPending = physical, true
+			return line, lineNo, nil
+		}
+		line += physical[1:]
+		if len(line) > maxImportLine {
+			return "", 0, &importFileError{
+				message: fmt.Sprintf("content line at line %d is longer than %d bytes", lineNo, maxImportLine),
+			}
+		}
+	}
+}
+
+func (c *icalRows) scan() (string, error) {
+	if c.scanner.Scan() {
+		c.line++
+		return c.scanner.Text(), nil
+	}
+	if errors.Is(c.scanner.Err(), bufio.ErrTooLong) {
+		return "", &importFileError{message: fmt.Sprintf("line %d is longer than %d bytes", c.line+1, maxImportLine)}
+	}
+	if err := c.scanner.Err(); err != nil {
+		return "", err
+	}
+
+	return "", io.EOF
+}
+
+func parseICalLine(line string) (string, map[string]string, string, bool) {
+	quoted := false
+	colon := -1
+	for i := 0; i < len(line) && colon < 0; i++ {
+		switch line[i] {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				colon = i
+			}
+		}
+	}
+	if colon <= 0 {
+		return "", nil, "", false
+	}
+
+	head, value := line[:colon], line[colon+1:]
+	parts := strings.Split(head, ";")
+	params := make(map[string]string, len(parts)-1)
+	for _, param := range parts[1:] {
+		key, val, _ := strings.Cut(param, "=")
+		params[strings.ToUpper(key)] = strings.Trim(val, `"`)
+	}
+
+	return strings.ToUpper(parts[0]), params, value, true
+}
+
+func splitICalList(value string) []string {
+	var items []string
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ',':
+			items = append(items, value[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(items, value[start:])
+}
+
+func unescapeICal(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i == len(value)-1 {
+			b.WriteByte(value[i])
+			continue
+		}
+		i++
+		switch value[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(value[i])
+		}
+	}
+
+	return b.String()
+}
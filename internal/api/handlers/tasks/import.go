@@ -0,0 +1,441 @@
+package tasks
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avraam311/tasks-service/internal/api/responses"
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+// Even the largest valid task is far smaller than a line.
+const maxImportLine = 1 << 20
+
+var importFormats = map[string]string{
+	"text/csv":             formatCSV,
+	"application/x-ndjson": formatJSONL,
+	"application/jsonl":    formatJSONL,
+	".csv":                 formatCSV,
+	".jsonl":               formatJSONL,
+	".ndjson":              formatJSONL,
+}
+
+// The other columns of an export are ignored, so an export can be imported
+// again.
+var csvImportColumns = []string{
+	"header", "description", "status", "due_at", "tags", "parent_id", "recurrence", "reminders",
+}
+
+type importFileError struct {
+	message string
+}
+
+func (e *importFileError) Error() string {
+	return e.message
+}
+
+type rowError struct {
+	message string
+}
+
+func (e *rowError) Error() string {
+	return e.message
+}
+
+type importError struct {
+	Row int `json:"row"`
+	*taskError
+}
+
+type importReport struct {
+	*models.ImportReport
+	Errors []importError `json:"errors"`
+}
+
+func (h *Handler) ImportTasks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		slog.Error("not allowed method", slog.String("method", r.Method))
+		err := responses.ResponseError(w, responses.ErrMethodNotAllowed, "only POST allowed", http.StatusMethodNotAllowed)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	h.importTasks(w, r, "")
+}
+
+// UIDs only link the rows of the file, every VTODO becomes a new task.
+func (h *Handler) ImportICal(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		slog.Error("not allowed method", slog.String("method", r.Method))
+		err := responses.ResponseError(w, responses.ErrMethodNotAllowed, "only POST allowed", http.StatusMethodNotAllowed)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	h.importTasks(w, r, formatICal)
+}
+
+func (h *Handler) importTasks(w http.ResponseWriter, r *http.Request, format string) {
+	params := r.URL.Query()
+	var dryRun bool
+	if dryRunStr := params.Get("dry_run"); dryRunStr != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(dryRunStr)
+		if err != nil {
+			slog.Error("invalid dry_run parameter", slog.String("dry_run", dryRunStr))
+			err := responses.ResponseError(w, responses.ErrInvalidParam, "dry_run must be true or false",
+				http.StatusBadRequest)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+	}
+
+	onError := params.Get("on_error")
+	if onError != "" && onError != models.ImportSkip && onError != models.ImportAbort {
+		slog.Error("invalid on_error parameter", slog.String("on_error", onError))
+		err := responses.ResponseError(w, responses.ErrInvalidParam, "on_error must be skip or abort",
+			http.StatusBadRequest)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	if format == "" {
+		format = params.Get("format")
+		if format != "" && format != formatCSV && format != formatJSONL {
+			slog.Error("invalid import format", slog.String("format", format))
+			err := responses.ResponseError(w, responses.ErrInvalidParam, "format must be csv or jsonl",
+				http.StatusBadRequest)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+	}
+
+	body, mediaType, fileName, err := importBody(r)
+	if err != nil {
+		slog.Error("failed to read import upload", slog.Any("error", err))
+		err := responses.ResponseError(w, responses.ErrInvalidImport, err.Error(), http.StatusBadRequest)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+	if format == "" {
+		format = importFormats[mediaType]
+	}
+	if format == "" {
+		format = importFormats[strings.ToLower(path.Ext(fileName))]
+	}
+	if format == "" {
+		slog.Error("unsupported import content type", slog.String("content_type", mediaType),
+			slog.String("file_name", fileName))
+		err := responses.ResponseError(w, responses.ErrUnsupportedMedia,
+			"content type must be text/csv or application/x-ndjson, or set format=csv|jsonl",
+			http.StatusUnsupportedMediaType)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	var next func() (*models.ImportRow, error)
+	switch format {
+	case formatCSV:
+		rows, err := newCSVRows(body)
+		if err != nil {
+			slog.Error("invalid csv header", slog.Any("error", err))
+			err := responses.ResponseError(w, responses.ErrInvalidImport, err.Error(), http.StatusBadRequest)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+		next = rows.next
+	case formatJSONL:
+		next = newJSONLRows(body).next
+	case formatICal:
+		next = newICalRows(body).next
+	}
+
+	report, err := h.service.ImportTasks(r.Context(), next, dryRun, onError == models.ImportAbort)
+	if err != nil {
+		var fileErr *importFileError
+		if errors.As(err, &fileErr) {
+			slog.Error("invalid import file", slog.Any("error", err))
+			err := responses.ResponseErrorDetails(w, responses.ErrInvalidImport, fileErr.message,
+				importReportOf(report), http.StatusBadRequest)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+
+		slog.Error("failed to import tasks", slog.Any("error", err))
+		err := responses.ResponseErrorDetails(w, responses.ErrInternalServer, "internal server error",
+			importReportOf(report), http.StatusInternalServerError)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	err = responses.ResponseOK(w, importReportOf(report))
+	if err != nil {
+		slog.Error("failed to send json response", slog.Any("err", err))
+	}
+}
+
+// The multipart form is streamed, not parsed into memory or temporary files.
+func importBody(r *http.Request) (io.Reader, string, string, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return r.Body, mediaType, "", nil
+	}
+
+	form, err := r.MultipartReader()
+	if err != nil {
+		return nil, "", "", fmt.Errorf("invalid multipart upload: %w", err)
+	}
+	for {
+		part, err := form.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, "", "", errors.New("multipart upload has no file part")
+		}
+		if err != nil {
+			return nil, "", "", fmt.Errorf("invalid multipart upload: %w", err)
+		}
+		if part.FormName() == "file" {
+			mediaType, _, _ = mime.ParseMediaType(part.Header.Get("Content-Type"))
+			return part, mediaType, part.FileName(), nil
+		}
+	}
+}
+
+func importReportOf(report *models.ImportReport) *importReport {
+	if report == nil {
+		return nil
+	}
+
+	response := &importReport{ImportReport: report, Errors: make([]importError, 0, len(report.Errors))}
+	for _, failed := range report.Errors {
+		response.Errors = append(response.Errors, importError{Row: failed.Row, taskError: importFailure(failed.Err)})
+	}
+
+	return response
+}
+
+func importFailure(err error) *taskError {
+	var rowErr *rowError
+	if errors.As(err, &rowErr) {
+		return &taskError{Code: responses.ErrInvalidRow, Message: rowErr.message}
+	}
+	_, failure := taskFailure(err)
+
+	return failure
+}
+
+// Rows are numbered by record, the header row being row 1.
+type csvRows struct {
+	r       *csv.Reader
+	columns []string
+	row     int
+}
+
+func newCSVRows(r io.Reader) (*csvRows, error) {
+	rows := &csvRows{r: csv.NewReader(r), row: 1}
+	rows.r.ReuseRecord = true
+	header, err := rows.r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, &importFileError{message: "csv has no header row"}
+	}
+	if err != nil {
+		return nil, &importFileError{message: fmt.Sprintf("invalid csv header row: %v", err)}
+	}
+
+	rows.columns = make([]string, len(header))
+	seen := make(map[string]bool, len(header))
+	for i, column := range header {
+		column = strings.TrimSpace(column)
+		if i == 0 {
+			column = strings.TrimPrefix(column, "\ufeff")
+		}
+		switch {
+		case seen[column]:
+			return nil, &importFileError{message: fmt.Sprintf("duplicate column %q", column)}
+		case slices.Contains(csvImportColumns, column):
+			rows.columns[i] = column
+		case !slices.Contains(csvColumns, column):
+			return nil, &importFileError{message: fmt.Sprintf("unknown column %q", column)}
+		}
+		seen[column] = true
+	}
+	if !slices.Contains(rows.columns, "header") {
+		return nil, &importFileError{message: `missing column "header"`}
+	}
+
+	return rows, nil
+}
+
+func (c *csvRows) next() (*models.ImportRow, error) {
+	record, err := c.r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, io.EOF
+	}
+	c.row++
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		if !errors.Is(err, csv.ErrFieldCount) {
+			return nil, &importFileError{message: fmt.Sprintf("invalid csv: %v", err)}
+		}
+		return &models.ImportRow{Row: c.row, Err: &rowError{
+			message: fmt.Sprintf("must have %d fields, has %d", len(c.columns), len(record)),
+		}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	task := &models.TaskDTO{}
+	for i, value := range record {
+		if err := setCSVField(task, c.columns[i], value); err != nil {
+			return &models.ImportRow{Row: c.row, Err: err}, nil
+		}
+	}
+
+	return &models.ImportRow{Row: c.row, Task: task}, nil
+}
+
+func setCSVField(task *models.TaskDTO, column, value string) error {
+	switch column {
+	case "header":
+		task.Header = value
+	case "description":
+		task.Description = value
+	case "status":
+		task.Status = value
+	case "recurrence":
+		task.Recurrence = value
+	case "tags":
+		if value == "" {
+			return nil
+		}
+		for _, tag := range strings.Split(value, "\n") {
+			task.Tags = append(task.Tags, strings.TrimSuffix(tag, "\r"))
+		}
+	case "due_at":
+		if value == "" {
+			return nil
+		}
+		dueAt, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return &rowError{message: "due_at must be an RFC 3339 timestamp"}
+		}
+		task.DueAt = &dueAt
+	case "parent_id":
+		if value == "" {
+			return nil
+		}
+		parentID, err := strconv.ParseUint(value, 10, 0)
+		if err != nil {
+			return &rowError{message: "parent_id must be a task id"}
+		}
+		id := uint(parentID)
+		task.ParentID = &id
+	case "reminders":
+		if value == "" {
+			return nil
+		}
+		for _, before := range strings.Split(value, ",") {
+			d, err := time.ParseDuration(strings.TrimSpace(before))
+			if err != nil {
+				return &rowError{message: fmt.Sprintf("reminders must be durations such as 1h30m, got %q", before)}
+			}
+			task.Reminders = append(task.Reminders, models.Duration(d))
+		}
+	}
+
+	return nil
+}
+
+// Rows are numbered by line, blank lines included.
+type jsonlRows struct {
+	scanner *bufio.Scanner
+	row     int
+}
+
+// jsonlTask also accepts reminders as exported, {"before": "1h0m0s"}.
+type jsonlTask struct {
+	models.TaskDTO
+	Reminders []jsonlReminder `json:"reminders,omitempty"`
+}
+
+type jsonlReminder models.Duration
+
+func (d *jsonlReminder) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		var reminder models.Reminder
+		if err := json.Unmarshal(data, &reminder); err != nil {
+			return err
+		}
+		*d = jsonlReminder(reminder.Before)
+		return nil
+	}
+
+	return (*models.Duration)(d).UnmarshalJSON(data)
+}
+
+func newJSONLRows(r io.Reader) *jsonlRows {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLine)
+
+	return &jsonlRows{scanner: scanner}
+}
+
+func (j *jsonlRows) next() (*models.ImportRow, error) {
+	for j.scanner.Scan() {
+		j.row++
+		line := bytes.TrimSpace(j.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var task jsonlTask
+		if err := json.Unmarshal(line, &task); err != nil {
+			return &models.ImportRow{Row: j.row, Err: &rowError{message: fmt.Sprintf("invalid JSON: %v", err)}}, nil
+		}
+		for _, reminder := range task.Reminders {
+			task.TaskDTO.Reminders = append(task.TaskDTO.Reminders, models.Duration(reminder))
+		}
+		return &models.ImportRow{Row: j.row, Task: &task.TaskDTO}, nil
+	}
+	if errors.Is(j.scanner.Err(), bufio.ErrTooLong) {
+		return nil, &importFileError{message: fmt.Sprintf("line %d is longer than %d bytes", j.row+1, maxImportLine)}
+	}
+	if err := j.scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, io.EOF
+}
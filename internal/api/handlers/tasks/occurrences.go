@@ -0,0 +1,84 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/avraam311/tasks-service/internal/api/responses"
+	"github.com/avraam311/tasks-service/internal/repository/tasks"
+	serviceTasks "github.com/avraam311/tasks-service/internal/service/tasks"
+)
+
+func (h *Handler) GetOccurrences(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		slog.Error("not allowed method", slog.String("method", r.Method))
+		err := responses.ResponseError(w, responses.ErrMethodNotAllowed, "only GET allowed", http.StatusMethodNotAllowed)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	taskIDStr := r.PathValue("id")
+	taskIDUint, err := strconv.ParseUint(taskIDStr, 10, strconv.IntSize)
+	if err != nil {
+		slog.Error("failed to convert task id into int", slog.String("tasks id str", taskIDStr))
+		err := responses.ResponseError(w, responses.ErrInvalidID, "invalid task id", http.StatusBadRequest)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+	taskID := uint(taskIDUint)
+
+	var limit int
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > serviceTasks.MaxOccurrencePreview {
+			slog.Error("invalid occurrences limit", slog.String("limit", limitStr))
+			err := responses.ResponseError(w, responses.ErrInvalidLimit,
+				fmt.Sprintf("limit must be an integer between 1 and %d", serviceTasks.MaxOccurrencePreview),
+				http.StatusBadRequest)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+	}
+
+	dates, err := h.service.PreviewOccurrences(r.Context(), taskID, limit)
+	if err != nil {
+		if errors.Is(err, tasks.ErrTaskNotFound) {
+			slog.Error("task not found", slog.Any("task_id", taskID))
+			err := responses.ResponseError(w, responses.ErrTaskNotFound, "task not found", http.StatusNotFound)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+		if errors.Is(err, serviceTasks.ErrNotRecurring) {
+			slog.Error("task is not recurring", slog.Any("task_id", taskID))
+			err := responses.ResponseError(w, responses.ErrNotRecurring, "task has no recurrence rule",
+				http.StatusConflict)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+
+		slog.Error("failed to preview occurrences", slog.Any("task id", taskID), slog.Any("error", err))
+		err := responses.ResponseError(w, responses.ErrInternalServer, "internal server error", http.StatusInternalServerError)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	err = responses.ResponseOK(w, dates)
+	if err != nil {
+		slog.Error("failed to send json response", slog.Any("err", err))
+	}
+}
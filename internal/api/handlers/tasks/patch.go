@@ -0,0 +1,116 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/avraam311/tasks-service/internal/api/responses"
+	"github.com/avraam311/tasks-service/internal/models"
+	serviceTasks "github.com/avraam311/tasks-service/internal/service/tasks"
+)
+
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+	maxPatchBytes         = 1 << 20
+)
+
+func (h *Handler) PatchTask(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPatch {
+		slog.Error("not allowed method", slog.String("method", r.Method))
+		err := responses.ResponseError(w, responses.ErrMethodNotAllowed, "only PATCH allowed", http.StatusMethodNotAllowed)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	taskIDStr := strings.TrimPrefix(r.URL.Path, "/todos/")
+	taskIDUint, err := strconv.ParseUint(taskIDStr, 10, strconv.IntSize)
+	if err != nil {
+		slog.Error("failed to convert task id into int", slog.String("tasks id str", taskIDStr))
+		err := responses.ResponseError(w, responses.ErrInvalidID, "invalid task id", http.StatusBadRequest)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+	taskID := uint(taskIDUint)
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (mediaType != mergePatchContentType && mediaType != jsonPatchContentType) {
+		slog.Error("unsupported patch content type", slog.String("content_type", r.Header.Get("Content-Type")))
+		err := responses.ResponseError(w, responses.ErrUnsupportedMedia,
+			fmt.Sprintf("content type must be %s or %s", mergePatchContentType, jsonPatchContentType),
+			http.StatusUnsupportedMediaType)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	var force bool
+	if forceStr := r.URL.Query().Get("force"); forceStr != "" {
+		force, err = strconv.ParseBool(forceStr)
+		if err != nil {
+			slog.Error("invalid force parameter", slog.String("force", forceStr))
+			err := responses.ResponseError(w, responses.ErrInvalidParam, "force must be true or false", http.StatusBadRequest)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+	}
+
+	patch, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchBytes))
+	if err != nil {
+		slog.Error("failed to read patch", slog.Any("error", err))
+		err := responses.ResponseError(w, responses.ErrInvalidPatch, fmt.Sprintf("invalid request body: %s", err.Error()),
+			http.StatusBadRequest)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	var task *models.TaskDomain
+	if mediaType == jsonPatchContentType {
+		task, err = h.service.JSONPatchTask(r.Context(), taskID, patch, force)
+	} else {
+		task, err = h.service.PatchTask(r.Context(), taskID, patch, force)
+	}
+	if err != nil {
+		var testErr *serviceTasks.PatchTestError
+		if errors.As(err, &testErr) {
+			slog.Error("patch test failed", slog.Any("task_id", taskID), slog.Any("error", err))
+			err := responses.ResponseErrorDetails(w, responses.ErrPatchTestFailed, "patch test operation failed", testErr,
+				http.StatusConflict)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+		if errors.Is(err, serviceTasks.ErrInvalidPatch) {
+			slog.Error("invalid patch", slog.Any("task_id", taskID), slog.Any("error", err))
+			err := responses.ResponseError(w, responses.ErrInvalidPatch, "invalid patch", http.StatusBadRequest)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+		responseTaskFailure(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(task.Version))
+	err = responses.ResponseOK(w, task)
+	if err != nil {
+		slog.Error("failed to send json response", slog.Any("err", err))
+	}
+}
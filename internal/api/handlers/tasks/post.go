@@ -33,11 +33,7 @@ func (h *Handler) CreateTask(w http.ResponseWriter, r *http.Request) {
 
 	taskID, err := h.service.CreateTask(r.Context(), &task)
 	if err != nil {
-		slog.Error("failed to create task", slog.Any("task", task), slog.Any("error", err))
-		err := responses.ResponseError(w, responses.ErrInternalServer, "internal server error", http.StatusInternalServerError)
-		if err != nil {
-			slog.Error("failed to send json response", slog.Any("err", err))
-		}
+		responseTaskFailure(w, err)
 		return
 	}
 
@@ -2,7 +2,6 @@ package tasks
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -11,7 +10,6 @@ import (
 
 	"github.com/avraam311/tasks-service/internal/api/responses"
 	"github.com/avraam311/tasks-service/internal/models"
-	"github.com/avraam311/tasks-service/internal/repository/tasks"
 )
 
 func (h *Handler) UpdateTask(w http.ResponseWriter, r *http.Request) {
@@ -25,7 +23,7 @@ func (h *Handler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	taskIDStr := strings.TrimPrefix(r.URL.Path, "/todos/")
-	taskIDInt, err := strconv.Atoi(taskIDStr)
+	taskIDUint, err := strconv.ParseUint(taskIDStr, 10, strconv.IntSize)
 	if err != nil {
 		slog.Error("failed to convert task id into int", slog.String("tasks id str", taskIDStr))
 		err := responses.ResponseError(w, responses.ErrInvalidID, "invalid task id", http.StatusBadRequest)
@@ -34,12 +32,12 @@ func (h *Handler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	taskID := uint(taskIDInt)
+	taskID := uint(taskIDUint)
 
-	var task models.TaskDTO
-	if err := json.NewDecoder(r.Body).Decode(&task); err != nil {
-		slog.Error("failed to decode JSON", slog.Any("error", err), slog.Any("task", r.Body))
-		err := responses.ResponseError(w, responses.ErrInvalidJSON, fmt.Sprintf("invalid request body: %s", err.Error()),
+	version, conditional, err := parseIfMatch(r)
+	if err != nil {
+		slog.Error("invalid If-Match header", slog.String("if_match", r.Header.Get("If-Match")))
+		err := responses.ResponseError(w, responses.ErrInvalidIfMatch, "If-Match must be a single strong ETag or *",
 			http.StatusBadRequest)
 		if err != nil {
 			slog.Error("failed to send json response", slog.Any("err", err))
@@ -47,26 +45,68 @@ func (h *Handler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.service.UpdateTask(r.Context(), taskID, &task)
-	if err != nil {
-		if errors.Is(err, tasks.ErrTaskNotFound) {
-			slog.Error("task not found", slog.Any("task_id", taskID), slog.Any("task", task))
-			err := responses.ResponseError(w, responses.ErrTaskNotFound, "task not found", http.StatusBadRequest)
+	var upsert bool
+	if upsertStr := r.URL.Query().Get("upsert"); upsertStr != "" {
+		upsert, err = strconv.ParseBool(upsertStr)
+		if err != nil {
+			slog.Error("invalid upsert parameter", slog.String("upsert", upsertStr))
+			err := responses.ResponseError(w, responses.ErrInvalidParam, "upsert must be true or false", http.StatusBadRequest)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+	}
+
+	var force bool
+	if forceStr := r.URL.Query().Get("force"); forceStr != "" {
+		force, err = strconv.ParseBool(forceStr)
+		if err != nil {
+			slog.Error("invalid force parameter", slog.String("force", forceStr))
+			err := responses.ResponseError(w, responses.ErrInvalidParam, "force must be true or false", http.StatusBadRequest)
 			if err != nil {
 				slog.Error("failed to send json response", slog.Any("err", err))
 			}
 			return
 		}
+	}
 
-		slog.Error("failed to update task", slog.Any("task id", taskID), slog.Any("task", task), slog.Any("error", err))
-		err := responses.ResponseError(w, responses.ErrInternalServer, "internal server error", http.StatusInternalServerError)
+	var task models.TaskDTO
+	if err := json.NewDecoder(r.Body).Decode(&task); err != nil {
+		slog.Error("failed to decode JSON", slog.Any("error", err), slog.Any("task", r.Body))
+		err := responses.ResponseError(w, responses.ErrInvalidJSON, fmt.Sprintf("invalid request body: %s", err.Error()),
+			http.StatusBadRequest)
 		if err != nil {
 			slog.Error("failed to send json response", slog.Any("err", err))
 		}
 		return
 	}
 
-	err = responses.ResponseCreated(w, responses.SuccessTaskUpdated)
+	var (
+		newVersion uint64
+		created    bool
+	)
+	switch {
+	case conditional:
+		newVersion, err = h.service.UpdateTaskIfMatch(r.Context(), taskID, version, &task, force)
+	case upsert:
+		created, err = h.service.UpsertTask(r.Context(), taskID, &task, force)
+	default:
+		err = h.service.UpdateTask(r.Context(), taskID, &task, force)
+	}
+	if err != nil {
+		responseTaskFailure(w, err)
+		return
+	}
+
+	if conditional {
+		w.Header().Set("ETag", formatETag(newVersion))
+	}
+	if created {
+		err = responses.ResponseCreated(w, responses.SuccessTaskCreated)
+	} else {
+		err = responses.ResponseCreated(w, responses.SuccessTaskUpdated)
+	}
 	if err != nil {
 		slog.Error("failed to send json response", slog.Any("err", err))
 	}
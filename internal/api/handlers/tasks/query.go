@@ -0,0 +1,123 @@
+package tasks
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avraam311/tasks-service/internal/api/responses"
+	"github.com/avraam311/tasks-service/internal/models"
+	serviceTasks "github.com/avraam311/tasks-service/internal/service/tasks"
+)
+
+var sortFields = map[string]bool{
+	models.SortByID:     true,
+	models.SortByHeader: true,
+	models.SortByDueAt:  true,
+}
+
+type queryError struct {
+	code    string
+	message string
+}
+
+func (e *queryError) Error() string {
+	return e.message
+}
+
+func parseTaskQuery(r *http.Request) (*models.TaskQuery, *queryError) {
+	params := r.URL.Query()
+	query := &models.TaskQuery{
+		HeaderPrefix: params.Get("header_prefix"),
+		Cursor:       params.Get("cursor"),
+	}
+
+	if finishedStr := params.Get("finished"); finishedStr != "" {
+		finished, err := strconv.ParseBool(finishedStr)
+		if err != nil {
+			return nil, &queryError{code: responses.ErrInvalidFilter, message: "finished must be true or false"}
+		}
+		query.Finished = &finished
+	}
+
+	query.Statuses = params["status"]
+	for _, status := range query.Statuses {
+		if !slices.Contains(models.Statuses, status) {
+			return nil, &queryError{code: responses.ErrInvalidFilter,
+				message: "status must be one of " + strings.Join(models.Statuses, ", ")}
+		}
+	}
+
+	query.Tags = params["tag"]
+	switch query.TagMode = params.Get("tag_mode"); query.TagMode {
+	case "":
+		query.TagMode = models.TagModeAny
+	case models.TagModeAny, models.TagModeAll:
+	default:
+		return nil, &queryError{code: responses.ErrInvalidFilter, message: "tag_mode must be any or all"}
+	}
+
+	if treeStr := params.Get("tree"); treeStr != "" {
+		tree, err := strconv.ParseBool(treeStr)
+		if err != nil {
+			return nil, &queryError{code: responses.ErrInvalidParam, message: "tree must be true or false"}
+		}
+		query.Tree = tree
+	}
+
+	if overdueStr := params.Get("overdue"); overdueStr != "" {
+		overdue, err := strconv.ParseBool(overdueStr)
+		if err != nil {
+			return nil, &queryError{code: responses.ErrInvalidFilter, message: "overdue must be true or false"}
+		}
+		query.Overdue = overdue
+	}
+
+	for name, target := range map[string]**time.Time{
+		"updated_since": &query.UpdatedSince,
+		"due_after":     &query.DueAfter,
+		"due_before":    &query.DueBefore,
+	} {
+		value := params.Get(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, &queryError{code: responses.ErrInvalidFilter, message: name + " must be an RFC 3339 timestamp"}
+		}
+		*target = &t
+	}
+
+	if limitStr := params.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > serviceTasks.MaxPageLimit {
+			return nil, &queryError{
+				code:    responses.ErrInvalidLimit,
+				message: fmt.Sprintf("limit must be an integer between 1 and %d", serviceTasks.MaxPageLimit),
+			}
+		}
+		query.Limit = limit
+	}
+
+	if sortStr := params.Get("sort"); sortStr != "" {
+		seen := map[string]bool{}
+		for _, field := range strings.Split(sortStr, ",") {
+			key := models.SortKey{Field: strings.TrimSpace(field)}
+			if strings.HasPrefix(key.Field, "-") {
+				key.Field = key.Field[1:]
+				key.Desc = true
+			}
+			if !sortFields[key.Field] || seen[key.Field] {
+				return nil, &queryError{code: responses.ErrInvalidSort, message: fmt.Sprintf("invalid sort field %q", field)}
+			}
+			seen[key.Field] = true
+			query.Sort = append(query.Sort, key)
+		}
+	}
+
+	return query, nil
+}
@@ -0,0 +1,63 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/avraam311/tasks-service/internal/api/responses"
+	serviceTasks "github.com/avraam311/tasks-service/internal/service/tasks"
+)
+
+func (h *Handler) SearchTasks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		slog.Error("not allowed method", slog.String("method", r.Method))
+		err := responses.ResponseError(w, responses.ErrMethodNotAllowed, "only GET allowed", http.StatusMethodNotAllowed)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	params := r.URL.Query()
+	var limit int
+	if limitStr := params.Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > serviceTasks.MaxSearchLimit {
+			slog.Error("invalid search limit", slog.String("limit", limitStr))
+			err := responses.ResponseError(w, responses.ErrInvalidLimit,
+				fmt.Sprintf("limit must be an integer between 1 and %d", serviceTasks.MaxSearchLimit), http.StatusBadRequest)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+	}
+
+	results, err := h.service.SearchTasks(r.Context(), params.Get("q"), limit)
+	if err != nil {
+		if errors.Is(err, serviceTasks.ErrEmptySearchQuery) {
+			slog.Error("empty search query")
+			err := responses.ResponseError(w, responses.ErrInvalidSearch, "q must contain a search term", http.StatusBadRequest)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+
+		slog.Error("failed to search tasks", slog.Any("error", err))
+		err := responses.ResponseError(w, responses.ErrInternalServer, "internal server error", http.StatusInternalServerError)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	err = responses.ResponseOK(w, results)
+	if err != nil {
+		slog.Error("failed to send json response", slog.Any("err", err))
+	}
+}
@@ -0,0 +1,85 @@
+package tasks
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/avraam311/tasks-service/internal/api/responses"
+	"github.com/avraam311/tasks-service/internal/repository/tasks"
+)
+
+func (h *Handler) GetChildren(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		slog.Error("not allowed method", slog.String("method", r.Method))
+		err := responses.ResponseError(w, responses.ErrMethodNotAllowed, "only GET allowed", http.StatusMethodNotAllowed)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	taskIDStr := r.PathValue("id")
+	taskIDUint, err := strconv.ParseUint(taskIDStr, 10, strconv.IntSize)
+	if err != nil {
+		slog.Error("failed to convert task id into int", slog.String("tasks id str", taskIDStr))
+		err := responses.ResponseError(w, responses.ErrInvalidID, "invalid task id", http.StatusBadRequest)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+	taskID := uint(taskIDUint)
+
+	children, err := h.service.GetChildren(r.Context(), taskID)
+	if err != nil {
+		if errors.Is(err, tasks.ErrTaskNotFound) {
+			slog.Error("task not found", slog.Any("task_id", taskID))
+			err := responses.ResponseError(w, responses.ErrTaskNotFound, "task not found", http.StatusNotFound)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+
+		slog.Error("failed to get children", slog.Any("task id", taskID), slog.Any("error", err))
+		err := responses.ResponseError(w, responses.ErrInternalServer, "internal server error", http.StatusInternalServerError)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	err = responses.ResponseOK(w, children)
+	if err != nil {
+		slog.Error("failed to send json response", slog.Any("err", err))
+	}
+}
+
+func (h *Handler) getTaskTree(w http.ResponseWriter, r *http.Request, taskID uint) {
+	tree, err := h.service.GetTaskTree(r.Context(), taskID)
+	if err != nil {
+		if errors.Is(err, tasks.ErrTaskNotFound) {
+			slog.Error("task not found", slog.Any("task_id", taskID))
+			err := responses.ResponseError(w, responses.ErrTaskNotFound, "task not found", http.StatusNotFound)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+
+		slog.Error("failed to get task tree", slog.Any("task id", taskID), slog.Any("error", err))
+		err := responses.ResponseError(w, responses.ErrInternalServer, "internal server error", http.StatusInternalServerError)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(tree.Version))
+	err = responses.ResponseOK(w, tree)
+	if err != nil {
+		slog.Error("failed to send json response", slog.Any("err", err))
+	}
+}
@@ -0,0 +1,103 @@
+package tasks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/avraam311/tasks-service/internal/api/responses"
+	"github.com/avraam311/tasks-service/internal/repository/tasks"
+	serviceTasks "github.com/avraam311/tasks-service/internal/service/tasks"
+)
+
+type renameTagRequest struct {
+	Name string `json:"name"`
+}
+
+func (h *Handler) GetTags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		slog.Error("not allowed method", slog.String("method", r.Method))
+		err := responses.ResponseError(w, responses.ErrMethodNotAllowed, "only GET allowed", http.StatusMethodNotAllowed)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	tags, err := h.service.GetTags(r.Context())
+	if err != nil {
+		slog.Error("failed to get tags", slog.Any("error", err))
+		err := responses.ResponseError(w, responses.ErrInternalServer, "internal server error", http.StatusInternalServerError)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	err = responses.ResponseOK(w, tags)
+	if err != nil {
+		slog.Error("failed to send json response", slog.Any("err", err))
+	}
+}
+
+func (h *Handler) RenameTag(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		slog.Error("not allowed method", slog.String("method", r.Method))
+		err := responses.ResponseError(w, responses.ErrMethodNotAllowed, "only PUT allowed", http.StatusMethodNotAllowed)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	oldName := strings.TrimPrefix(r.URL.Path, "/tags/")
+
+	var req renameTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		slog.Error("failed to decode JSON", slog.Any("error", err))
+		err := responses.ResponseError(w, responses.ErrInvalidJSON, fmt.Sprintf("invalid request body: %s", err.Error()),
+			http.StatusBadRequest)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	tag, err := h.service.RenameTag(r.Context(), oldName, req.Name)
+	if err != nil {
+		var validationErr *serviceTasks.ValidationError
+		if errors.As(err, &validationErr) {
+			slog.Error("tag validation failed", slog.String("name", req.Name), slog.Any("error", err))
+			err := responses.ResponseErrorDetails(w, responses.ErrValidation, "tag validation failed",
+				validationErr.Fields, http.StatusUnprocessableEntity)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+		if errors.Is(err, tasks.ErrTagNotFound) {
+			slog.Error("tag not found", slog.String("tag", oldName))
+			err := responses.ResponseError(w, responses.ErrTagNotFound, "tag not found", http.StatusNotFound)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+
+		slog.Error("failed to rename tag", slog.String("tag", oldName), slog.String("name", req.Name),
+			slog.Any("error", err))
+		err := responses.ResponseError(w, responses.ErrInternalServer, "internal server error", http.StatusInternalServerError)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	err = responses.ResponseOK(w, tag)
+	if err != nil {
+		slog.Error("failed to send json response", slog.Any("err", err))
+	}
+}
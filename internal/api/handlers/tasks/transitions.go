@@ -0,0 +1,58 @@
+package tasks
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/avraam311/tasks-service/internal/api/responses"
+)
+
+func (h *Handler) TransitionTask(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		slog.Error("not allowed method", slog.String("method", r.Method))
+		err := responses.ResponseError(w, responses.ErrMethodNotAllowed, "only POST allowed", http.StatusMethodNotAllowed)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	taskIDStr := r.PathValue("id")
+	taskIDUint, err := strconv.ParseUint(taskIDStr, 10, strconv.IntSize)
+	if err != nil {
+		slog.Error("failed to convert task id into int", slog.String("tasks id str", taskIDStr))
+		err := responses.ResponseError(w, responses.ErrInvalidID, "invalid task id", http.StatusBadRequest)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+	taskID := uint(taskIDUint)
+	name := r.PathValue("name")
+
+	var force bool
+	if forceStr := r.URL.Query().Get("force"); forceStr != "" {
+		force, err = strconv.ParseBool(forceStr)
+		if err != nil {
+			slog.Error("invalid force parameter", slog.String("force", forceStr))
+			err := responses.ResponseError(w, responses.ErrInvalidParam, "force must be true or false", http.StatusBadRequest)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+	}
+
+	task, err := h.service.TransitionTask(r.Context(), taskID, name, force)
+	if err != nil {
+		responseTaskFailure(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", formatETag(task.Version))
+	err = responses.ResponseOK(w, task)
+	if err != nil {
+		slog.Error("failed to send json response", slog.Any("err", err))
+	}
+}
@@ -0,0 +1,58 @@
+package webhooks
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/avraam311/tasks-service/internal/api/responses"
+	"github.com/avraam311/tasks-service/internal/repository/webhooks"
+)
+
+func (h *Handler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		slog.Error("not allowed method", slog.String("method", r.Method))
+		err := responses.ResponseError(w, responses.ErrMethodNotAllowed, "only GET allowed", http.StatusMethodNotAllowed)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	webhookIDStr := r.PathValue("id")
+	webhookIDInt, err := strconv.Atoi(webhookIDStr)
+	if err != nil {
+		slog.Error("failed to convert webhook id into int", slog.String("webhook id str", webhookIDStr))
+		err := responses.ResponseError(w, responses.ErrInvalidID, "invalid webhook id", http.StatusBadRequest)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+	webhookID := uint(webhookIDInt)
+
+	deliveries, err := h.service.GetDeliveries(r.Context(), webhookID)
+	if err != nil {
+		if errors.Is(err, webhooks.ErrWebhookNotFound) {
+			slog.Error("webhook not found", slog.Any("webhook_id", webhookID))
+			err := responses.ResponseError(w, responses.ErrWebhookNotFound, "webhook not found", http.StatusNotFound)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+
+		slog.Error("failed to get webhook deliveries", slog.Any("webhook id", webhookID), slog.Any("error", err))
+		err := responses.ResponseError(w, responses.ErrInternalServer, "internal server error", http.StatusInternalServerError)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	err = responses.ResponseOK(w, deliveries)
+	if err != nil {
+		slog.Error("failed to send json response", slog.Any("err", err))
+	}
+}
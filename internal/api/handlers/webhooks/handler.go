@@ -0,0 +1,26 @@
+package webhooks
+
+import (
+	"context"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+type Service interface {
+	CreateWebhook(ctx context.Context, dto *models.WebhookDTO) (*models.Webhook, error)
+	GetWebhooks(ctx context.Context) ([]*models.Webhook, error)
+	GetWebhook(ctx context.Context, webhookID uint) (*models.Webhook, error)
+	UpdateWebhook(ctx context.Context, webhookID uint, dto *models.WebhookDTO) (*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookID uint) error
+	GetDeliveries(ctx context.Context, webhookID uint) ([]*models.Delivery, error)
+}
+
+type Handler struct {
+	service Service
+}
+
+func New(service Service) Handler {
+	return Handler{
+		service: service,
+	}
+}
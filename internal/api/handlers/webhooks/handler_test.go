@@ -0,0 +1,337 @@
+package webhooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/avraam311/tasks-service/internal/api/responses"
+	"github.com/avraam311/tasks-service/internal/mocks"
+	"github.com/avraam311/tasks-service/internal/models"
+	"github.com/avraam311/tasks-service/internal/repository/webhooks"
+	serviceTasks "github.com/avraam311/tasks-service/internal/service/tasks"
+)
+
+func newRequest(method, target, id string, body interface{}) *http.Request {
+	var req *http.Request
+	if body != nil {
+		jsonBody, _ := json.Marshal(body)
+		req = httptest.NewRequest(method, target, bytes.NewBuffer(jsonBody))
+	} else {
+		req = httptest.NewRequest(method, target, nil)
+	}
+	if id != "" {
+		req.SetPathValue("id", id)
+	}
+
+	return req
+}
+
+func assertResponse(t *testing.T, w *httptest.ResponseRecorder, expectedCode int, expectedErr string) {
+	assert.Equal(t, expectedCode, w.Code)
+
+	if expectedErr != "" {
+		var errorResp responses.ErrorResponse
+		err := json.Unmarshal(w.Body.Bytes(), &errorResp)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedErr, errorResp.Error.Code)
+	} else {
+		var successResp responses.Success
+		err := json.Unmarshal(w.Body.Bytes(), &successResp)
+		assert.NoError(t, err)
+		assert.NotNil(t, successResp.Result)
+	}
+}
+
+func TestCreateWebhook(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockWebhookService(ctrl)
+	handler := New(mockService)
+
+	tests := []struct {
+		name         string
+		method       string
+		body         interface{}
+		expectedCode int
+		expectedErr  string
+		serviceMock  func()
+	}{
+		{
+			name:         "MethodNotAllowed",
+			method:       http.MethodGet,
+			expectedCode: http.StatusMethodNotAllowed,
+			expectedErr:  responses.ErrMethodNotAllowed,
+		},
+		{
+			name:         "InvalidJSON",
+			method:       http.MethodPost,
+			body:         "invalid json",
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidJSON,
+		},
+		{
+			name:         "ValidationFailed",
+			method:       http.MethodPost,
+			body:         models.WebhookDTO{URL: "not a url"},
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedErr:  responses.ErrValidation,
+			serviceMock: func() {
+				mockService.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).
+					Return(nil, &serviceTasks.ValidationError{Fields: []serviceTasks.FieldError{
+						{Field: "url", Rule: "url", Message: "must be an absolute http or https URL"},
+					}})
+			},
+		},
+		{
+			name:         "ServiceError",
+			method:       http.MethodPost,
+			body:         models.WebhookDTO{URL: "https://example.com"},
+			expectedCode: http.StatusInternalServerError,
+			expectedErr:  responses.ErrInternalServer,
+			serviceMock: func() {
+				mockService.EXPECT().CreateWebhook(gomock.Any(), gomock.Any()).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name:         "Success",
+			method:       http.MethodPost,
+			body:         models.WebhookDTO{URL: "https://example.com", Events: []string{models.EventTaskCreated}},
+			expectedCode: http.StatusCreated,
+			serviceMock: func() {
+				mockService.EXPECT().CreateWebhook(gomock.Any(), &models.WebhookDTO{
+					URL:    "https://example.com",
+					Events: []string{models.EventTaskCreated},
+				}).Return(&models.Webhook{ID: 1, URL: "https://example.com", Secret: "s3cret"}, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRequest(tt.method, "/webhooks", "", tt.body)
+			w := httptest.NewRecorder()
+
+			if tt.serviceMock != nil {
+				tt.serviceMock()
+			}
+
+			handler.CreateWebhook(w, req)
+
+			assertResponse(t, w, tt.expectedCode, tt.expectedErr)
+		})
+	}
+}
+
+func TestGetWebhooks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockWebhookService(ctrl)
+	handler := New(mockService)
+
+	t.Run("Success", func(t *testing.T) {
+		mockService.EXPECT().GetWebhooks(gomock.Any()).Return([]*models.Webhook{{ID: 1}}, nil)
+		w := httptest.NewRecorder()
+
+		handler.GetWebhooks(w, newRequest(http.MethodGet, "/webhooks", "", nil))
+
+		assertResponse(t, w, http.StatusOK, "")
+	})
+
+	t.Run("ServiceError", func(t *testing.T) {
+		mockService.EXPECT().GetWebhooks(gomock.Any()).Return(nil, assert.AnError)
+		w := httptest.NewRecorder()
+
+		handler.GetWebhooks(w, newRequest(http.MethodGet, "/webhooks", "", nil))
+
+		assertResponse(t, w, http.StatusInternalServerError, responses.ErrInternalServer)
+	})
+}
+
+func TestWebhookByID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockWebhookService(ctrl)
+	handler := New(mockService)
+	body := models.WebhookDTO{URL: "https://example.com"}
+
+	tests := []struct {
+		name         string
+		method       string
+		id           string
+		body         interface{}
+		handle       func(w http.ResponseWriter, r *http.Request)
+		expectedCode int
+		expectedErr  string
+		serviceMock  func()
+	}{
+		{
+			name:         "Get/MethodNotAllowed",
+			method:       http.MethodPost,
+			id:           "1",
+			handle:       handler.GetWebhook,
+			expectedCode: http.StatusMethodNotAllowed,
+			expectedErr:  responses.ErrMethodNotAllowed,
+		},
+		{
+			name:         "Get/InvalidID",
+			method:       http.MethodGet,
+			id:           "abc",
+			handle:       handler.GetWebhook,
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidID,
+		},
+		{
+			name:         "Get/NotFound",
+			method:       http.MethodGet,
+			id:           "9",
+			handle:       handler.GetWebhook,
+			expectedCode: http.StatusNotFound,
+			expectedErr:  responses.ErrWebhookNotFound,
+			serviceMock: func() {
+				mockService.EXPECT().GetWebhook(gomock.Any(), uint(9)).Return(nil, webhooks.ErrWebhookNotFound)
+			},
+		},
+		{
+			name:         "Get/Success",
+			method:       http.MethodGet,
+			id:           "1",
+			handle:       handler.GetWebhook,
+			expectedCode: http.StatusOK,
+			serviceMock: func() {
+				mockService.EXPECT().GetWebhook(gomock.Any(), uint(1)).Return(&models.Webhook{ID: 1}, nil)
+			},
+		},
+		{
+			name:         "Update/InvalidJSON",
+			method:       http.MethodPut,
+			id:           "1",
+			body:         "invalid json",
+			handle:       handler.UpdateWebhook,
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidJSON,
+		},
+		{
+			name:         "Update/ValidationFailed",
+			method:       http.MethodPut,
+			id:           "1",
+			body:         models.WebhookDTO{},
+			handle:       handler.UpdateWebhook,
+			expectedCode: http.StatusUnprocessableEntity,
+			expectedErr:  responses.ErrValidation,
+			serviceMock: func() {
+				mockService.EXPECT().UpdateWebhook(gomock.Any(), uint(1), gomock.Any()).
+					Return(nil, &serviceTasks.ValidationError{})
+			},
+		},
+		{
+			name:         "Update/NotFound",
+			method:       http.MethodPut,
+			id:           "9",
+			body:         body,
+			handle:       handler.UpdateWebhook,
+			expectedCode: http.StatusNotFound,
+			expectedErr:  responses.ErrWebhookNotFound,
+			serviceMock: func() {
+				mockService.EXPECT().UpdateWebhook(gomock.Any(), uint(9), &body).Return(nil, webhooks.ErrWebhookNotFound)
+			},
+		},
+		{
+			name:         "Update/Success",
+			method:       http.MethodPut,
+			id:           "1",
+			body:         body,
+			handle:       handler.UpdateWebhook,
+			expectedCode: http.StatusOK,
+			serviceMock: func() {
+				mockService.EXPECT().UpdateWebhook(gomock.Any(), uint(1), &body).
+					Return(&models.Webhook{ID: 1, URL: body.URL, Active: true}, nil)
+			},
+		},
+		{
+			name:         "Delete/InvalidID",
+			method:       http.MethodDelete,
+			id:           "-",
+			handle:       handler.DeleteWebhook,
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  responses.ErrInvalidID,
+		},
+		{
+			name:         "Delete/NotFound",
+			method:       http.MethodDelete,
+			id:           "9",
+			handle:       handler.DeleteWebhook,
+			expectedCode: http.StatusNotFound,
+			expectedErr:  responses.ErrWebhookNotFound,
+			serviceMock: func() {
+				mockService.EXPECT().DeleteWebhook(gomock.Any(), uint(9)).Return(webhooks.ErrWebhookNotFound)
+			},
+		},
+		{
+			name:         "Delete/Success",
+			method:       http.MethodDelete,
+			id:           "1",
+			handle:       handler.DeleteWebhook,
+			expectedCode: http.StatusOK,
+			serviceMock: func() {
+				mockService.EXPECT().DeleteWebhook(gomock.Any(), uint(1)).Return(nil)
+			},
+		},
+		{
+			name:         "Deliveries/NotFound",
+			method:       http.MethodGet,
+			id:           "9",
+			handle:       handler.GetDeliveries,
+			expectedCode: http.StatusNotFound,
+			expectedErr:  responses.ErrWebhookNotFound,
+			serviceMock: func() {
+				mockService.EXPECT().GetDeliveries(gomock.Any(), uint(9)).Return(nil, webhooks.ErrWebhookNotFound)
+			},
+		},
+		{
+			name:         "Deliveries/ServiceError",
+			method:       http.MethodGet,
+			id:           "1",
+			handle:       handler.GetDeliveries,
+			expectedCode: http.StatusInternalServerError,
+			expectedErr:  responses.ErrInternalServer,
+			serviceMock: func() {
+				mockService.EXPECT().GetDeliveries(gomock.Any(), uint(1)).Return(nil, assert.AnError)
+			},
+		},
+		{
+			name:         "Deliveries/Success",
+			method:       http.MethodGet,
+			id:           "1",
+			handle:       handler.GetDeliveries,
+			expectedCode: http.StatusOK,
+			serviceMock: func() {
+				mockService.EXPECT().GetDeliveries(gomock.Any(), uint(1)).
+					Return([]*models.Delivery{{ID: 3, WebhookID: 1, Attempt: 1, Success: true}}, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newRequest(tt.method, "/webhooks/"+tt.id, tt.id, tt.body)
+			w := httptest.NewRecorder()
+
+			if tt.serviceMock != nil {
+				tt.serviceMock()
+			}
+
+			tt.handle(w, req)
+
+			assertResponse(t, w, tt.expectedCode, tt.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/avraam311/tasks-service/internal/api/responses"
+	"github.com/avraam311/tasks-service/internal/models"
+	"github.com/avraam311/tasks-service/internal/repository/webhooks"
+	serviceTasks "github.com/avraam311/tasks-service/internal/service/tasks"
+)
+
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		slog.Error("not allowed method", slog.String("method", r.Method))
+		err := responses.ResponseError(w, responses.ErrMethodNotAllowed, "only POST allowed", http.StatusMethodNotAllowed)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	var dto models.WebhookDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		slog.Error("failed to decode JSON", slog.Any("error", err))
+		err := responses.ResponseError(w, responses.ErrInvalidJSON, fmt.Sprintf("invalid request body: %s", err.Error()),
+			http.StatusBadRequest)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	webhook, err := h.service.CreateWebhook(r.Context(), &dto)
+	if err != nil {
+		var validationErr *serviceTasks.ValidationError
+		if errors.As(err, &validationErr) {
+			slog.Error("webhook validation failed", slog.String("url", dto.URL), slog.Any("error", err))
+			err := responses.ResponseErrorDetails(w, responses.ErrValidation, "webhook validation failed",
+				validationErr.Fields, http.StatusUnprocessableEntity)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+
+		slog.Error("failed to create webhook", slog.String("url", dto.URL), slog.Any("error", err))
+		err := responses.ResponseError(w, responses.ErrInternalServer, "internal server error", http.StatusInternalServerError)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	err = responses.ResponseCreated(w, webhook)
+	if err != nil {
+		slog.Error("failed to send json response", slog.Any("err", err))
+	}
+}
+
+func (h *Handler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		slog.Error("not allowed method", slog.String("method", r.Method))
+		err := responses.ResponseError(w, responses.ErrMethodNotAllowed, "only GET allowed", http.StatusMethodNotAllowed)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	webhooks, err := h.service.GetWebhooks(r.Context())
+	if err != nil {
+		slog.Error("failed to get webhooks", slog.Any("error", err))
+		err := responses.ResponseError(w, responses.ErrInternalServer, "internal server error", http.StatusInternalServerError)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	err = responses.ResponseOK(w, webhooks)
+	if err != nil {
+		slog.Error("failed to send json response", slog.Any("err", err))
+	}
+}
+
+func (h *Handler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		slog.Error("not allowed method", slog.String("method", r.Method))
+		err := responses.ResponseError(w, responses.ErrMethodNotAllowed, "only GET allowed", http.StatusMethodNotAllowed)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	webhookIDStr := r.PathValue("id")
+	webhookIDInt, err := strconv.Atoi(webhookIDStr)
+	if err != nil {
+		slog.Error("failed to convert webhook id into int", slog.String("webhook id str", webhookIDStr))
+		err := responses.ResponseError(w, responses.ErrInvalidID, "invalid webhook id", http.StatusBadRequest)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+	webhookID := uint(webhookIDInt)
+
+	webhook, err := h.service.GetWebhook(r.Context(), webhookID)
+	if err != nil {
+		if errors.Is(err, webhooks.ErrWebhookNotFound) {
+			slog.Error("webhook not found", slog.Any("webhook_id", webhookID))
+			err := responses.ResponseError(w, responses.ErrWebhookNotFound, "webhook not found", http.StatusNotFound)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+
+		slog.Error("failed to get webhook", slog.Any("webhook id", webhookID), slog.Any("error", err))
+		err := responses.ResponseError(w, responses.ErrInternalServer, "internal server error", http.StatusInternalServerError)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	err = responses.ResponseOK(w, webhook)
+	if err != nil {
+		slog.Error("failed to send json response", slog.Any("err", err))
+	}
+}
+
+func (h *Handler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		slog.Error("not allowed method", slog.String("method", r.Method))
+		err := responses.ResponseError(w, responses.ErrMethodNotAllowed, "only PUT allowed", http.StatusMethodNotAllowed)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	webhookIDStr := r.PathValue("id")
+	webhookIDInt, err := strconv.Atoi(webhookIDStr)
+	if err != nil {
+		slog.Error("failed to convert webhook id into int", slog.String("webhook id str", webhookIDStr))
+		err := responses.ResponseError(w, responses.ErrInvalidID, "invalid webhook id", http.StatusBadRequest)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+	webhookID := uint(webhookIDInt)
+
+	var dto models.WebhookDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		slog.Error("failed to decode JSON", slog.Any("error", err))
+		err := responses.ResponseError(w, responses.ErrInvalidJSON, fmt.Sprintf("invalid request body: %s", err.Error()),
+			http.StatusBadRequest)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	webhook, err := h.service.UpdateWebhook(r.Context(), webhookID, &dto)
+	if err != nil {
+		var validationErr *serviceTasks.ValidationError
+		if errors.As(err, &validationErr) {
+			slog.Error("webhook validation failed", slog.String("url", dto.URL), slog.Any("error", err))
+			err := responses.ResponseErrorDetails(w, responses.ErrValidation, "webhook validation failed",
+				validationErr.Fields, http.StatusUnprocessableEntity)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+		if errors.Is(err, webhooks.ErrWebhookNotFound) {
+			slog.Error("webhook not found", slog.Any("webhook_id", webhookID))
+			err := responses.ResponseError(w, responses.ErrWebhookNotFound, "webhook not found", http.StatusNotFound)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+
+		slog.Error("failed to update webhook", slog.Any("webhook id", webhookID), slog.Any("error", err))
+		err := responses.ResponseError(w, responses.ErrInternalServer, "internal server error", http.StatusInternalServerError)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	err = responses.ResponseOK(w, webhook)
+	if err != nil {
+		slog.Error("failed to send json response", slog.Any("err", err))
+	}
+}
+
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		slog.Error("not allowed method", slog.String("method", r.Method))
+		err := responses.ResponseError(w, responses.ErrMethodNotAllowed, "only DELETE allowed", http.StatusMethodNotAllowed)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	webhookIDStr := r.PathValue("id")
+	webhookIDInt, err := strconv.Atoi(webhookIDStr)
+	if err != nil {
+		slog.Error("failed to convert webhook id into int", slog.String("webhook id str", webhookIDStr))
+		err := responses.ResponseError(w, responses.ErrInvalidID, "invalid webhook id", http.StatusBadRequest)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+	webhookID := uint(webhookIDInt)
+
+	err = h.service.DeleteWebhook(r.Context(), webhookID)
+	if err != nil {
+		if errors.Is(err, webhooks.ErrWebhookNotFound) {
+			slog.Error("webhook not found", slog.Any("webhook_id", webhookID))
+			err := responses.ResponseError(w, responses.ErrWebhookNotFound, "webhook not found", http.StatusNotFound)
+			if err != nil {
+				slog.Error("failed to send json response", slog.Any("err", err))
+			}
+			return
+		}
+
+		slog.Error("failed to delete webhook", slog.Any("webhook id", webhookID), slog.Any("error", err))
+		err := responses.ResponseError(w, responses.ErrInternalServer, "internal server error", http.StatusInternalServerError)
+		if err != nil {
+			slog.Error("failed to send json response", slog.Any("err", err))
+		}
+		return
+	}
+
+	err = responses.ResponseOK(w, responses.SuccessWebhookDeleted)
+	if err != nil {
+		slog.Error("failed to send json response", slog.Any("err", err))
+	}
+}
@@ -0,0 +1,20 @@
+package middlewares
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/avraam311/tasks-service/internal/actor"
+)
+
+const actorHeader = "X-Actor"
+
+func ActorMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if name := strings.TrimSpace(r.Header.Get(actorHeader)); name != "" {
+			r = r.WithContext(actor.With(r.Context(), name))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
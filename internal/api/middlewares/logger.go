@@ -47,3 +47,14 @@ func (rw *responseWriterWrapper) Write(data []byte) (int, error) {
 	rw.size += int64(len(data))
 	return rw.ResponseWriter.Write(data)
 }
+
+func (rw *responseWriterWrapper) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the wrapped writer to http.ResponseController.
+func (rw *responseWriterWrapper) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -7,24 +7,57 @@ import (
 )
 
 const (
-	ErrInternalServer   = "INTERNAL_ERROR"
-	ErrInvalidJSON      = "INVALID_JSON"
-	ErrInvalidID        = "INVALID_ID"
-	ErrMethodNotAllowed = "METHOD_NOT_ALLOWED"
-	ErrTaskNotFound     = "TASK_NOT_FOUND"
+	ErrInternalServer     = "INTERNAL_ERROR"
+	ErrInvalidJSON        = "INVALID_JSON"
+	ErrInvalidID          = "INVALID_ID"
+	ErrMethodNotAllowed   = "METHOD_NOT_ALLOWED"
+	ErrTaskNotFound       = "TASK_NOT_FOUND"
+	ErrInvalidIfMatch     = "INVALID_IF_MATCH"
+	ErrVersionMismatch    = "VERSION_MISMATCH"
+	ErrInvalidPatch       = "INVALID_PATCH"
+	ErrUnsupportedMedia   = "UNSUPPORTED_MEDIA_TYPE"
+	ErrPatchTestFailed    = "PATCH_TEST_FAILED"
+	ErrInvalidFilter      = "INVALID_FILTER"
+	ErrInvalidSort        = "INVALID_SORT"
+	ErrInvalidLimit       = "INVALID_LIMIT"
+	ErrInvalidCursor      = "INVALID_CURSOR"
+	ErrInvalidSearch      = "INVALID_SEARCH_QUERY"
+	ErrValidation         = "VALIDATION_FAILED"
+	ErrInvalidParam       = "INVALID_PARAMETER"
+	ErrTagNotFound        = "TAG_NOT_FOUND"
+	ErrTaskHasChildren    = "TASK_HAS_CHILDREN"
+	ErrDependencyNotFound = "DEPENDENCY_NOT_FOUND"
+	ErrDependencyCycle    = "DEPENDENCY_CYCLE"
+	ErrTaskBlocked        = "TASK_BLOCKED"
+	ErrTransitionNotFound = "TRANSITION_NOT_FOUND"
+	ErrIllegalTransition  = "ILLEGAL_TRANSITION"
+	ErrNotRecurring       = "TASK_NOT_RECURRING"
+	ErrWebhookNotFound    = "WEBHOOK_NOT_FOUND"
+	ErrRevisionNotFound   = "REVISION_NOT_FOUND"
+	ErrNotRevertible      = "REVISION_NOT_REVERTIBLE"
+	ErrTaskTrashed        = "TASK_TRASHED"
+	ErrBatchFailed        = "BATCH_FAILED"
+	ErrInvalidImport      = "INVALID_IMPORT"
+	ErrInvalidRow         = "INVALID_ROW"
 
+	SuccessTaskCreated = "TASK_CREATED"
 	SuccessTaskUpdated = "TASK_UPDATED"
 	SuccessTaskDeleted = "TASK_DELETED"
+	SuccessTaskPurged  = "TASK_PURGED"
+
+	SuccessWebhookDeleted = "WEBHOOK_DELETED"
 )
 
 type Success struct {
-	Result interface{} `json:"result"`
+	Result     interface{} `json:"result"`
+	NextCursor string      `json:"next_cursor,omitempty"`
 }
 
 type ErrorResponse struct {
 	Error struct {
-		Code    string `json:"code"`
-		Message string `json:"message"`
+		Code    string      `json:"code"`
+		Message string      `json:"message"`
+		Details interface{} `json:"details,omitempty"`
 	} `json:"error"`
 }
 
@@ -46,6 +79,14 @@ func ResponseOK(w http.ResponseWriter, result interface{}) error {
 	return nil
 }
 
+func ResponsePage(w http.ResponseWriter, result interface{}, nextCursor string) error {
+	err := WriteJSON(w, http.StatusOK, Success{Result: result, NextCursor: nextCursor})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 func ResponseCreated(w http.ResponseWriter, result interface{}) error {
 	err := WriteJSON(w, http.StatusCreated, Success{Result: result})
 	if err != nil {
@@ -55,9 +96,14 @@ func ResponseCreated(w http.ResponseWriter, result interface{}) error {
 }
 
 func ResponseError(w http.ResponseWriter, code string, message string, statusCode int) error {
+	return ResponseErrorDetails(w, code, message, nil, statusCode)
+}
+
+func ResponseErrorDetails(w http.ResponseWriter, code string, message string, details interface{}, statusCode int) error {
 	resp := ErrorResponse{}
 	resp.Error.Code = code
 	resp.Error.Message = message
+	resp.Error.Details = details
 
 	err := WriteJSON(w, statusCode, resp)
 	if err != nil {
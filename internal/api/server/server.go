@@ -3,20 +3,49 @@ package server
 import (
 	"net/http"
 
+	"github.com/avraam311/tasks-service/internal/api/handlers/events"
 	"github.com/avraam311/tasks-service/internal/api/handlers/tasks"
+	"github.com/avraam311/tasks-service/internal/api/handlers/webhooks"
 	"github.com/avraam311/tasks-service/internal/api/middlewares"
 )
 
-func NewRouter(tasksHand tasks.Handler) http.Handler {
+func NewRouter(tasksHand tasks.Handler, webhooksHand webhooks.Handler, eventsHand events.Handler) http.Handler {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("POST /todos", tasksHand.CreateTask)
 	mux.HandleFunc("GET /todos", tasksHand.GetAllTasks)
 	mux.HandleFunc("GET /todos/", tasksHand.GetTask)
+	mux.HandleFunc("GET /todos/search", tasksHand.SearchTasks)
+	mux.HandleFunc("GET /todos/export", tasksHand.ExportTasks)
+	mux.HandleFunc("POST /todos/import", tasksHand.ImportTasks)
+	mux.HandleFunc("GET /todos.ics", tasksHand.ExportICal)
+	mux.HandleFunc("POST /todos/import.ics", tasksHand.ImportICal)
+	mux.HandleFunc("POST /todos/batch", tasksHand.BatchTasks)
+	mux.HandleFunc("GET /todos/events", eventsHand.StreamEvents)
+	mux.HandleFunc("GET /todos/{id}/children", tasksHand.GetChildren)
+	mux.HandleFunc("POST /todos/{id}/transitions/{name}", tasksHand.TransitionTask)
+	mux.HandleFunc("GET /todos/{id}/occurrences", tasksHand.GetOccurrences)
+	mux.HandleFunc("GET /todos/{id}/history", tasksHand.GetHistory)
+	mux.HandleFunc("POST /todos/{id}/revert/{revision}", tasksHand.RevertTask)
+	mux.HandleFunc("POST /todos/{id}/dependencies/{depId}", tasksHand.AddDependency)
+	mux.HandleFunc("DELETE /todos/{id}/dependencies/{depId}", tasksHand.RemoveDependency)
 	mux.HandleFunc("PUT /todos/", tasksHand.UpdateTask)
+	mux.HandleFunc("PATCH /todos/", tasksHand.PatchTask)
 	mux.HandleFunc("DELETE /todos/", tasksHand.DeleteTask)
+	mux.HandleFunc("GET /trash", tasksHand.GetTrash)
+	mux.HandleFunc("POST /trash/{id}/restore", tasksHand.RestoreTask)
+	mux.HandleFunc("DELETE /trash/{id}", tasksHand.PurgeTask)
+	mux.HandleFunc("GET /tags", tasksHand.GetTags)
+	mux.HandleFunc("PUT /tags/", tasksHand.RenameTag)
+	mux.HandleFunc("POST /webhooks", webhooksHand.CreateWebhook)
+	mux.HandleFunc("GET /webhooks", webhooksHand.GetWebhooks)
+	mux.HandleFunc("GET /webhooks/{id}", webhooksHand.GetWebhook)
+	mux.HandleFunc("PUT /webhooks/{id}", webhooksHand.UpdateWebhook)
+	mux.HandleFunc("DELETE /webhooks/{id}", webhooksHand.DeleteWebhook)
+	mux.HandleFunc("GET /webhooks/{id}/deliveries", webhooksHand.GetDeliveries)
 
-	router := middlewares.RecoveryMiddleware(mux)
+	router := middlewares.ActorMiddleware(mux)
+	router = middlewares.RecoveryMiddleware(router)
 	router = middlewares.LoggingMiddleware(router)
 
 	return router
@@ -4,16 +4,66 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+const (
+	StorageMemory = "memory"
+	StorageFile   = "file"
 )
 
 type Config struct {
-	Server *Server
+	Server    *Server
+	Storage   *Storage
+	Workflow  *Workflow
+	Reminders *Reminders
+	Webhooks  *Webhooks
+	Events    *Events
+	Trash     *Trash
 }
 
 type Server struct {
 	Port string `json:"port" validate:"required"`
 }
 
+type Storage struct {
+	Backend             string `json:"backend" validate:"oneof=memory file"`
+	Dir                 string `json:"dir"`
+	SnapshotIntervalSec int    `json:"snapshot_interval_sec"`
+}
+
+// An empty Transitions keeps the default workflow.
+type Workflow struct {
+	Transitions []models.Transition `json:"transitions"`
+}
+
+// IntervalSec 0 picks the default.
+type Reminders struct {
+	IntervalSec int `json:"interval_sec"`
+}
+
+// Zero fields pick the defaults.
+type Webhooks struct {
+	MaxAttempts   int `json:"max_attempts"`
+	BackoffMs     int `json:"backoff_ms"`
+	MaxBackoffSec int `json:"max_backoff_sec"`
+	TimeoutSec    int `json:"timeout_sec"`
+	DisableAfter  int `json:"disable_after"`
+	Workers       int `json:"workers"`
+	QueueSize     int `json:"queue_size"`
+}
+
+type Events struct {
+	BufferSize   int `json:"buffer_size"`
+	HeartbeatSec int `json:"heartbeat_sec"`
+}
+
+type Trash struct {
+	RetentionHours   int `json:"retention_hours"`
+	PurgeIntervalSec int `json:"purge_interval_sec"`
+}
+
 func New() (*Config, error) {
 	return &Config{}, nil
 }
@@ -28,5 +78,21 @@ func (c *Config) LoadJSON(fileName string) error {
 		return fmt.Errorf("config/config.go - failed to unmarshal data - %w", err)
 	}
 
+	if c.Storage == nil {
+		c.Storage = &Storage{Backend: StorageMemory}
+	}
+	if c.Reminders == nil {
+		c.Reminders = &Reminders{}
+	}
+	if c.Webhooks == nil {
+		c.Webhooks = &Webhooks{}
+	}
+	if c.Events == nil {
+		c.Events = &Events{}
+	}
+	if c.Trash == nil {
+		c.Trash = &Trash{}
+	}
+
 	return nil
 }
@@ -0,0 +1,22 @@
+package notifier
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+type Log struct{}
+
+func (Log) Notify(ctx context.Context, reminder *models.DueReminder) error {
+	slog.InfoContext(ctx, "task reminder",
+		slog.Any("task_id", reminder.TaskID),
+		slog.String("header", reminder.Header),
+		slog.Time("due_at", reminder.DueAt),
+		slog.String("before", time.Duration(reminder.Before).String()),
+	)
+
+	return nil
+}
@@ -7,8 +7,10 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "github.com/avraam311/tasks-service/internal/models"
+	tasks "github.com/avraam311/tasks-service/internal/repository/tasks"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -35,33 +37,108 @@ func (m *MockRepo) EXPECT() *MockRepoMockRecorder {
 	return m.recorder
 }
 
-// DeleteTask mocks base method.
-func (m *MockRepo) DeleteTask(ctx context.Context, taskID uint) error {
+// AddDependency mocks base method.
+func (m *MockRepo) AddDependency(ctx context.Context, taskID uint, dependencyID uint, update func(task *models.TaskDomain) error) (*models.TaskDomain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddDependency", ctx, taskID, dependencyID, update)
+	ret0, _ := ret[0].(*models.TaskDomain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddDependency indicates an expected call of AddDependency.
+func (mr *MockRepoMockRecorder) AddDependency(ctx, taskID, dependencyID, update interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddDependency", reflect.TypeOf((*MockRepo)(nil).AddDependency), ctx, taskID, dependencyID, update)
+}
+
+// Atomically mocks base method.
+func (m *MockRepo) Atomically(ctx context.Context, fn func(tx *tasks.Tx) error) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteTask", ctx, taskID)
+	ret := m.ctrl.Call(m, "Atomically", ctx, fn)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// Atomically indicates an expected call of Atomically.
+func (mr *MockRepoMockRecorder) Atomically(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Atomically", reflect.TypeOf((*MockRepo)(nil).Atomically), ctx, fn)
+}
+
+// CompareAndDeleteTask mocks base method.
+func (m *MockRepo) CompareAndDeleteTask(ctx context.Context, taskID uint, version uint64, mode string, deletedAt time.Time, detach func(task *models.TaskDomain) error) ([]uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompareAndDeleteTask", ctx, taskID, version, mode, deletedAt, detach)
+	ret0, _ := ret[0].([]uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompareAndDeleteTask indicates an expected call of CompareAndDeleteTask.
+func (mr *MockRepoMockRecorder) CompareAndDeleteTask(ctx, taskID, version, mode, deletedAt, detach interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompareAndDeleteTask", reflect.TypeOf((*MockRepo)(nil).CompareAndDeleteTask), ctx, taskID, version, mode, deletedAt, detach)
+}
+
+// CompareAndSwapTask mocks base method.
+func (m *MockRepo) CompareAndSwapTask(ctx context.Context, taskID uint, version uint64, update func(task *models.TaskDomain) error) (*models.TaskDomain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompareAndSwapTask", ctx, taskID, version, update)
+	ret0, _ := ret[0].(*models.TaskDomain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompareAndSwapTask indicates an expected call of CompareAndSwapTask.
+func (mr *MockRepoMockRecorder) CompareAndSwapTask(ctx, taskID, version, update interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompareAndSwapTask", reflect.TypeOf((*MockRepo)(nil).CompareAndSwapTask), ctx, taskID, version, update)
+}
+
+// DeleteTask mocks base method.
+func (m *MockRepo) DeleteTask(ctx context.Context, taskID uint, mode string, deletedAt time.Time, detach func(task *models.TaskDomain) error) ([]uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTask", ctx, taskID, mode, deletedAt, detach)
+	ret0, _ := ret[0].([]uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // DeleteTask indicates an expected call of DeleteTask.
-func (mr *MockRepoMockRecorder) DeleteTask(ctx, taskID interface{}) *gomock.Call {
+func (mr *MockRepoMockRecorder) DeleteTask(ctx, taskID, mode, deletedAt, detach interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTask", reflect.TypeOf((*MockRepo)(nil).DeleteTask), ctx, taskID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTask", reflect.TypeOf((*MockRepo)(nil).DeleteTask), ctx, taskID, mode, deletedAt, detach)
 }
 
-// LoadAllTasks mocks base method.
-func (m *MockRepo) LoadAllTasks(ctx context.Context) ([]*models.TaskDomain, error) {
+// LoadChildren mocks base method.
+func (m *MockRepo) LoadChildren(ctx context.Context, taskID uint) ([]*models.TaskDomain, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "LoadAllTasks", ctx)
+	ret := m.ctrl.Call(m, "LoadChildren", ctx, taskID)
 	ret0, _ := ret[0].([]*models.TaskDomain)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// LoadAllTasks indicates an expected call of LoadAllTasks.
-func (mr *MockRepoMockRecorder) LoadAllTasks(ctx interface{}) *gomock.Call {
+// LoadChildren indicates an expected call of LoadChildren.
+func (mr *MockRepoMockRecorder) LoadChildren(ctx, taskID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadAllTasks", reflect.TypeOf((*MockRepo)(nil).LoadAllTasks), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadChildren", reflect.TypeOf((*MockRepo)(nil).LoadChildren), ctx, taskID)
+}
+
+// LoadTags mocks base method.
+func (m *MockRepo) LoadTags(ctx context.Context) ([]*models.TagCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadTags", ctx)
+	ret0, _ := ret[0].([]*models.TagCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadTags indicates an expected call of LoadTags.
+func (mr *MockRepoMockRecorder) LoadTags(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadTags", reflect.TypeOf((*MockRepo)(nil).LoadTags), ctx)
 }
 
 // LoadTask mocks base method.
@@ -79,8 +156,143 @@ func (mr *MockRepoMockRecorder) LoadTask(ctx, taskID interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadTask", reflect.TypeOf((*MockRepo)(nil).LoadTask), ctx, taskID)
 }
 
+// LoadTaskTrees mocks base method.
+func (m *MockRepo) LoadTaskTrees(ctx context.Context, taskIDs []uint) ([]*models.TaskTree, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadTaskTrees", ctx, taskIDs)
+	ret0, _ := ret[0].([]*models.TaskTree)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadTaskTrees indicates an expected call of LoadTaskTrees.
+func (mr *MockRepoMockRecorder) LoadTaskTrees(ctx, taskIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadTaskTrees", reflect.TypeOf((*MockRepo)(nil).LoadTaskTrees), ctx, taskIDs)
+}
+
+// LoadTrash mocks base method.
+func (m *MockRepo) LoadTrash(ctx context.Context) ([]*models.TaskDomain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadTrash", ctx)
+	ret0, _ := ret[0].([]*models.TaskDomain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadTrash indicates an expected call of LoadTrash.
+func (mr *MockRepoMockRecorder) LoadTrash(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadTrash", reflect.TypeOf((*MockRepo)(nil).LoadTrash), ctx)
+}
+
+// QueryTasks mocks base method.
+func (m *MockRepo) QueryTasks(ctx context.Context, query *models.TaskQuery) ([]*models.TaskDomain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryTasks", ctx, query)
+	ret0, _ := ret[0].([]*models.TaskDomain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryTasks indicates an expected call of QueryTasks.
+func (mr *MockRepoMockRecorder) QueryTasks(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryTasks", reflect.TypeOf((*MockRepo)(nil).QueryTasks), ctx, query)
+}
+
+// PurgeTask mocks base method.
+func (m *MockRepo) PurgeTask(ctx context.Context, taskID uint) ([]uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeTask", ctx, taskID)
+	ret0, _ := ret[0].([]uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeTask indicates an expected call of PurgeTask.
+func (mr *MockRepoMockRecorder) PurgeTask(ctx, taskID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeTask", reflect.TypeOf((*MockRepo)(nil).PurgeTask), ctx, taskID)
+}
+
+// PurgeTrash mocks base method.
+func (m *MockRepo) PurgeTrash(ctx context.Context, before time.Time) ([]uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeTrash", ctx, before)
+	ret0, _ := ret[0].([]uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeTrash indicates an expected call of PurgeTrash.
+func (mr *MockRepoMockRecorder) PurgeTrash(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeTrash", reflect.TypeOf((*MockRepo)(nil).PurgeTrash), ctx, before)
+}
+
+// RemoveDependency mocks base method.
+func (m *MockRepo) RemoveDependency(ctx context.Context, taskID uint, dependencyID uint, update func(task *models.TaskDomain) error) (*models.TaskDomain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveDependency", ctx, taskID, dependencyID, update)
+	ret0, _ := ret[0].(*models.TaskDomain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveDependency indicates an expected call of RemoveDependency.
+func (mr *MockRepoMockRecorder) RemoveDependency(ctx, taskID, dependencyID, update interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveDependency", reflect.TypeOf((*MockRepo)(nil).RemoveDependency), ctx, taskID, dependencyID, update)
+}
+
+// RestoreTask mocks base method.
+func (m *MockRepo) RestoreTask(ctx context.Context, taskID uint, update func(task *models.TaskDomain) error) ([]uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreTask", ctx, taskID, update)
+	ret0, _ := ret[0].([]uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreTask indicates an expected call of RestoreTask.
+func (mr *MockRepoMockRecorder) RestoreTask(ctx, taskID, update interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreTask", reflect.TypeOf((*MockRepo)(nil).RestoreTask), ctx, taskID, update)
+}
+
+// SearchTasks mocks base method.
+func (m *MockRepo) SearchTasks(ctx context.Context, query string, limit int) ([]*models.SearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchTasks", ctx, query, limit)
+	ret0, _ := ret[0].([]*models.SearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchTasks indicates an expected call of SearchTasks.
+func (mr *MockRepoMockRecorder) SearchTasks(ctx, query, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchTasks", reflect.TypeOf((*MockRepo)(nil).SearchTasks), ctx, query, limit)
+}
+
+// StoreNextOccurrence mocks base method.
+func (m *MockRepo) StoreNextOccurrence(ctx context.Context, taskID uint, next *models.TaskDomain, update func(task *models.TaskDomain) error) (*models.TaskDomain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreNextOccurrence", ctx, taskID, next, update)
+	ret0, _ := ret[0].(*models.TaskDomain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StoreNextOccurrence indicates an expected call of StoreNextOccurrence.
+func (mr *MockRepoMockRecorder) StoreNextOccurrence(ctx, taskID, next, update interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreNextOccurrence", reflect.TypeOf((*MockRepo)(nil).StoreNextOccurrence), ctx, taskID, next, update)
+}
+
 // StoreTask mocks base method.
-func (m *MockRepo) StoreTask(ctx context.Context, task *models.TaskDTO) (uint, error) {
+func (m *MockRepo) StoreTask(ctx context.Context, task *models.TaskDomain) (uint, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "StoreTask", ctx, task)
 	ret0, _ := ret[0].(uint)
@@ -94,16 +306,62 @@ func (mr *MockRepoMockRecorder) StoreTask(ctx, task interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreTask", reflect.TypeOf((*MockRepo)(nil).StoreTask), ctx, task)
 }
 
-// SwapTask mocks base method.
-func (m *MockRepo) SwapTask(ctx context.Context, taskID uint, task *models.TaskDTO) error {
+// SwapReminders mocks base method.
+func (m *MockRepo) SwapReminders(ctx context.Context, taskID uint, update func(task *models.TaskDomain) error) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SwapTask", ctx, taskID, task)
+	ret := m.ctrl.Call(m, "SwapReminders", ctx, taskID, update)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// SwapReminders indicates an expected call of SwapReminders.
+func (mr *MockRepoMockRecorder) SwapReminders(ctx, taskID, update interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SwapReminders", reflect.TypeOf((*MockRepo)(nil).SwapReminders), ctx, taskID, update)
+}
+
+// SwapTaggedTasks mocks base method.
+func (m *MockRepo) SwapTaggedTasks(ctx context.Context, tag string, update func(task *models.TaskDomain) error) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SwapTaggedTasks", ctx, tag, update)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SwapTaggedTasks indicates an expected call of SwapTaggedTasks.
+func (mr *MockRepoMockRecorder) SwapTaggedTasks(ctx, tag, update interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SwapTaggedTasks", reflect.TypeOf((*MockRepo)(nil).SwapTaggedTasks), ctx, tag, update)
+}
+
+// SwapTask mocks base method.
+func (m *MockRepo) SwapTask(ctx context.Context, taskID uint, update func(task *models.TaskDomain) error) (*models.TaskDomain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SwapTask", ctx, taskID, update)
+	ret0, _ := ret[0].(*models.TaskDomain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // SwapTask indicates an expected call of SwapTask.
-func (mr *MockRepoMockRecorder) SwapTask(ctx, taskID, task interface{}) *gomock.Call {
+func (mr *MockRepoMockRecorder) SwapTask(ctx, taskID, update interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SwapTask", reflect.TypeOf((*MockRepo)(nil).SwapTask), ctx, taskID, update)
+}
+
+// UpsertTask mocks base method.
+func (m *MockRepo) UpsertTask(ctx context.Context, taskID uint, update func(task *models.TaskDomain) error) (*models.TaskDomain, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertTask", ctx, taskID, update)
+	ret0, _ := ret[0].(*models.TaskDomain)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// UpsertTask indicates an expected call of UpsertTask.
+func (mr *MockRepoMockRecorder) UpsertTask(ctx, taskID, update interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SwapTask", reflect.TypeOf((*MockRepo)(nil).SwapTask), ctx, taskID, task)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertTask", reflect.TypeOf((*MockRepo)(nil).UpsertTask), ctx, taskID, update)
 }
@@ -7,6 +7,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "github.com/avraam311/tasks-service/internal/models"
 	gomock "github.com/golang/mock/gomock"
@@ -35,6 +36,36 @@ func (m *MockService) EXPECT() *MockServiceMockRecorder {
 	return m.recorder
 }
 
+// AddDependency mocks base method.
+func (m *MockService) AddDependency(ctx context.Context, taskID uint, dependencyID uint) (*models.TaskDomain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddDependency", ctx, taskID, dependencyID)
+	ret0, _ := ret[0].(*models.TaskDomain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddDependency indicates an expected call of AddDependency.
+func (mr *MockServiceMockRecorder) AddDependency(ctx, taskID, dependencyID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddDependency", reflect.TypeOf((*MockService)(nil).AddDependency), ctx, taskID, dependencyID)
+}
+
+// BatchTasks mocks base method.
+func (m *MockService) BatchTasks(ctx context.Context, ops []models.BatchOperation, atomic bool) ([]*models.BatchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchTasks", ctx, ops, atomic)
+	ret0, _ := ret[0].([]*models.BatchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchTasks indicates an expected call of BatchTasks.
+func (mr *MockServiceMockRecorder) BatchTasks(ctx, ops, atomic interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchTasks", reflect.TypeOf((*MockService)(nil).BatchTasks), ctx, ops, atomic)
+}
+
 // CreateTask mocks base method.
 func (m *MockService) CreateTask(ctx context.Context, task *models.TaskDTO) (uint, error) {
 	m.ctrl.T.Helper()
@@ -51,32 +82,105 @@ func (mr *MockServiceMockRecorder) CreateTask(ctx, task interface{}) *gomock.Cal
 }
 
 // DeleteTask mocks base method.
-func (m *MockService) DeleteTask(ctx context.Context, taskID uint) error {
+func (m *MockService) DeleteTask(ctx context.Context, taskID uint, mode string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteTask", ctx, taskID)
+	ret := m.ctrl.Call(m, "DeleteTask", ctx, taskID, mode)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteTask indicates an expected call of DeleteTask.
-func (mr *MockServiceMockRecorder) DeleteTask(ctx, taskID interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) DeleteTask(ctx, taskID, mode interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTask", reflect.TypeOf((*MockService)(nil).DeleteTask), ctx, taskID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTask", reflect.TypeOf((*MockService)(nil).DeleteTask), ctx, taskID, mode)
+}
+
+// DeleteTaskIfMatch mocks base method.
+func (m *MockService) DeleteTaskIfMatch(ctx context.Context, taskID uint, version uint64, mode string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTaskIfMatch", ctx, taskID, version, mode)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTaskIfMatch indicates an expected call of DeleteTaskIfMatch.
+func (mr *MockServiceMockRecorder) DeleteTaskIfMatch(ctx, taskID, version, mode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTaskIfMatch", reflect.TypeOf((*MockService)(nil).DeleteTaskIfMatch), ctx, taskID, version, mode)
+}
+
+// ExportTasks mocks base method.
+func (m *MockService) ExportTasks(ctx context.Context, query *models.TaskQuery, write func(*models.TaskDomain) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportTasks", ctx, query, write)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportTasks indicates an expected call of ExportTasks.
+func (mr *MockServiceMockRecorder) ExportTasks(ctx, query, write interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportTasks", reflect.TypeOf((*MockService)(nil).ExportTasks), ctx, query, write)
 }
 
 // GetAllTasks mocks base method.
-func (m *MockService) GetAllTasks(ctx context.Context) ([]*models.TaskDomain, error) {
+func (m *MockService) GetAllTasks(ctx context.Context, query *models.TaskQuery) (*models.TaskPage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllTasks", ctx)
-	ret0, _ := ret[0].([]*models.TaskDomain)
+	ret := m.ctrl.Call(m, "GetAllTasks", ctx, query)
+	ret0, _ := ret[0].(*models.TaskPage)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAllTasks indicates an expected call of GetAllTasks.
-func (mr *MockServiceMockRecorder) GetAllTasks(ctx interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) GetAllTasks(ctx, query interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllTasks", reflect.TypeOf((*MockService)(nil).GetAllTasks), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllTasks", reflect.TypeOf((*MockService)(nil).GetAllTasks), ctx, query)
+}
+
+// GetChildren mocks base method.
+func (m *MockService) GetChildren(ctx context.Context, taskID uint) ([]*models.TaskDomain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChildren", ctx, taskID)
+	ret0, _ := ret[0].([]*models.TaskDomain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetChildren indicates an expected call of GetChildren.
+func (mr *MockServiceMockRecorder) GetChildren(ctx, taskID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChildren", reflect.TypeOf((*MockService)(nil).GetChildren), ctx, taskID)
+}
+
+// GetHistory mocks base method.
+func (m *MockService) GetHistory(ctx context.Context, taskID uint) ([]*models.RevisionDiff, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistory", ctx, taskID)
+	ret0, _ := ret[0].([]*models.RevisionDiff)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHistory indicates an expected call of GetHistory.
+func (mr *MockServiceMockRecorder) GetHistory(ctx, taskID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockService)(nil).GetHistory), ctx, taskID)
+}
+
+// GetTags mocks base method.
+func (m *MockService) GetTags(ctx context.Context) ([]*models.TagCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTags", ctx)
+	ret0, _ := ret[0].([]*models.TagCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTags indicates an expected call of GetTags.
+func (mr *MockServiceMockRecorder) GetTags(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTags", reflect.TypeOf((*MockService)(nil).GetTags), ctx)
 }
 
 // GetTask mocks base method.
@@ -94,16 +198,240 @@ func (mr *MockServiceMockRecorder) GetTask(ctx, taskID interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTask", reflect.TypeOf((*MockService)(nil).GetTask), ctx, taskID)
 }
 
+// GetTaskTree mocks base method.
+func (m *MockService) GetTaskTree(ctx context.Context, taskID uint) (*models.TaskTree, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTaskTree", ctx, taskID)
+	ret0, _ := ret[0].(*models.TaskTree)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTaskTree indicates an expected call of GetTaskTree.
+func (mr *MockServiceMockRecorder) GetTaskTree(ctx, taskID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTaskTree", reflect.TypeOf((*MockService)(nil).GetTaskTree), ctx, taskID)
+}
+
+// GetTrash mocks base method.
+func (m *MockService) GetTrash(ctx context.Context) ([]*models.TaskDomain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTrash", ctx)
+	ret0, _ := ret[0].([]*models.TaskDomain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTrash indicates an expected call of GetTrash.
+func (mr *MockServiceMockRecorder) GetTrash(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTrash", reflect.TypeOf((*MockService)(nil).GetTrash), ctx)
+}
+
+// ImportTasks mocks base method.
+func (m *MockService) ImportTasks(ctx context.Context, next func() (*models.ImportRow, error), dryRun bool, abort bool) (*models.ImportReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportTasks", ctx, next, dryRun, abort)
+	ret0, _ := ret[0].(*models.ImportReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportTasks indicates an expected call of ImportTasks.
+func (mr *MockServiceMockRecorder) ImportTasks(ctx, next, dryRun, abort interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportTasks", reflect.TypeOf((*MockService)(nil).ImportTasks), ctx, next, dryRun, abort)
+}
+
+// JSONPatchTask mocks base method.
+func (m *MockService) JSONPatchTask(ctx context.Context, taskID uint, patch []byte, force bool) (*models.TaskDomain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JSONPatchTask", ctx, taskID, patch, force)
+	ret0, _ := ret[0].(*models.TaskDomain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// JSONPatchTask indicates an expected call of JSONPatchTask.
+func (mr *MockServiceMockRecorder) JSONPatchTask(ctx, taskID, patch, force interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JSONPatchTask", reflect.TypeOf((*MockService)(nil).JSONPatchTask), ctx, taskID, patch, force)
+}
+
+// PatchTask mocks base method.
+func (m *MockService) PatchTask(ctx context.Context, taskID uint, patch []byte, force bool) (*models.TaskDomain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchTask", ctx, taskID, patch, force)
+	ret0, _ := ret[0].(*models.TaskDomain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PatchTask indicates an expected call of PatchTask.
+func (mr *MockServiceMockRecorder) PatchTask(ctx, taskID, patch, force interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchTask", reflect.TypeOf((*MockService)(nil).PatchTask), ctx, taskID, patch, force)
+}
+
+// PreviewOccurrences mocks base method.
+func (m *MockService) PreviewOccurrences(ctx context.Context, taskID uint, n int) ([]time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PreviewOccurrences", ctx, taskID, n)
+	ret0, _ := ret[0].([]time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PreviewOccurrences indicates an expected call of PreviewOccurrences.
+func (mr *MockServiceMockRecorder) PreviewOccurrences(ctx, taskID, n interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreviewOccurrences", reflect.TypeOf((*MockService)(nil).PreviewOccurrences), ctx, taskID, n)
+}
+
+// PurgeTask mocks base method.
+func (m *MockService) PurgeTask(ctx context.Context, taskID uint) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeTask", ctx, taskID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurgeTask indicates an expected call of PurgeTask.
+func (mr *MockServiceMockRecorder) PurgeTask(ctx, taskID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeTask", reflect.TypeOf((*MockService)(nil).PurgeTask), ctx, taskID)
+}
+
+// RemoveDependency mocks base method.
+func (m *MockService) RemoveDependency(ctx context.Context, taskID uint, dependencyID uint) (*models.TaskDomain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveDependency", ctx, taskID, dependencyID)
+	ret0, _ := ret[0].(*models.TaskDomain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveDependency indicates an expected call of RemoveDependency.
+func (mr *MockServiceMockRecorder) RemoveDependency(ctx, taskID, dependencyID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveDependency", reflect.TypeOf((*MockService)(nil).RemoveDependency), ctx, taskID, dependencyID)
+}
+
+// RenameTag mocks base method.
+func (m *MockService) RenameTag(ctx context.Context, oldName string, newName string) (*models.TagCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenameTag", ctx, oldName, newName)
+	ret0, _ := ret[0].(*models.TagCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RenameTag indicates an expected call of RenameTag.
+func (mr *MockServiceMockRecorder) RenameTag(ctx, oldName, newName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenameTag", reflect.TypeOf((*MockService)(nil).RenameTag), ctx, oldName, newName)
+}
+
+// RestoreTask mocks base method.
+func (m *MockService) RestoreTask(ctx context.Context, taskID uint) (*models.TaskDomain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreTask", ctx, taskID)
+	ret0, _ := ret[0].(*models.TaskDomain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreTask indicates an expected call of RestoreTask.
+func (mr *MockServiceMockRecorder) RestoreTask(ctx, taskID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreTask", reflect.TypeOf((*MockService)(nil).RestoreTask), ctx, taskID)
+}
+
+// RevertTask mocks base method.
+func (m *MockService) RevertTask(ctx context.Context, taskID uint, number int) (*models.TaskDomain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevertTask", ctx, taskID, number)
+	ret0, _ := ret[0].(*models.TaskDomain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevertTask indicates an expected call of RevertTask.
+func (mr *MockServiceMockRecorder) RevertTask(ctx, taskID, number interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevertTask", reflect.TypeOf((*MockService)(nil).RevertTask), ctx, taskID, number)
+}
+
+// SearchTasks mocks base method.
+func (m *MockService) SearchTasks(ctx context.Context, query string, limit int) ([]*models.SearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchTasks", ctx, query, limit)
+	ret0, _ := ret[0].([]*models.SearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchTasks indicates an expected call of SearchTasks.
+func (mr *MockServiceMockRecorder) SearchTasks(ctx, query, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchTasks", reflect.TypeOf((*MockService)(nil).SearchTasks), ctx, query, limit)
+}
+
+// TransitionTask mocks base method.
+func (m *MockService) TransitionTask(ctx context.Context, taskID uint, name string, force bool) (*models.TaskDomain, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransitionTask", ctx, taskID, name, force)
+	ret0, _ := ret[0].(*models.TaskDomain)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TransitionTask indicates an expected call of TransitionTask.
+func (mr *MockServiceMockRecorder) TransitionTask(ctx, taskID, name, force interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransitionTask", reflect.TypeOf((*MockService)(nil).TransitionTask), ctx, taskID, name, force)
+}
+
 // UpdateTask mocks base method.
-func (m *MockService) UpdateTask(ctx context.Context, taskID uint, task *models.TaskDTO) error {
+func (m *MockService) UpdateTask(ctx context.Context, taskID uint, task *models.TaskDTO, force bool) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateTask", ctx, taskID, task)
+	ret := m.ctrl.Call(m, "UpdateTask", ctx, taskID, task, force)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateTask indicates an expected call of UpdateTask.
-func (mr *MockServiceMockRecorder) UpdateTask(ctx, taskID, task interface{}) *gomock.Call {
+func (mr *MockServiceMockRecorder) UpdateTask(ctx, taskID, task, force interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTask", reflect.TypeOf((*MockService)(nil).UpdateTask), ctx, taskID, task, force)
+}
+
+// UpdateTaskIfMatch mocks base method.
+func (m *MockService) UpdateTaskIfMatch(ctx context.Context, taskID uint, version uint64, task *models.TaskDTO, force bool) (uint64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTaskIfMatch", ctx, taskID, version, task, force)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateTaskIfMatch indicates an expected call of UpdateTaskIfMatch.
+func (mr *MockServiceMockRecorder) UpdateTaskIfMatch(ctx, taskID, version, task, force interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTaskIfMatch", reflect.TypeOf((*MockService)(nil).UpdateTaskIfMatch), ctx, taskID, version, task, force)
+}
+
+// UpsertTask mocks base method.
+func (m *MockService) UpsertTask(ctx context.Context, taskID uint, task *models.TaskDTO, force bool) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertTask", ctx, taskID, task, force)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpsertTask indicates an expected call of UpsertTask.
+func (mr *MockServiceMockRecorder) UpsertTask(ctx, taskID, task, force interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTask", reflect.TypeOf((*MockService)(nil).UpdateTask), ctx, taskID, task)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertTask", reflect.TypeOf((*MockService)(nil).UpsertTask), ctx, taskID, task, force)
 }
@@ -0,0 +1,40 @@
+package models
+
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+
+	BatchAtomic     = "atomic"
+	BatchBestEffort = "best_effort"
+
+	BatchDone       = "done"
+	BatchFailed     = "failed"
+	BatchRolledBack = "rolled_back"
+	BatchSkipped    = "skipped"
+)
+
+// Version makes an update or delete conditional like an If-Match header.
+type BatchOperation struct {
+	Op       string   `json:"op"`
+	ID       *uint    `json:"id,omitempty"`
+	Version  *uint64  `json:"version,omitempty"`
+	Task     *TaskDTO `json:"task,omitempty"`
+	Children string   `json:"children,omitempty"`
+	Force    bool     `json:"force,omitempty"`
+}
+
+type BatchRequest struct {
+	Mode       string           `json:"mode,omitempty"`
+	Operations []BatchOperation `json:"operations"`
+}
+
+type BatchResult struct {
+	Index   int    `json:"index"`
+	Op      string `json:"op"`
+	Status  string `json:"status"`
+	ID      *uint  `json:"id,omitempty"`
+	Version uint64 `json:"version,omitempty"`
+	Deleted []uint `json:"deleted,omitempty"`
+	Err     error  `json:"-"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+const (
+	EventTaskCreated  = "task.created"
+	EventTaskUpdated  = "task.updated"
+	EventTaskFinished = "task.finished"
+	EventTaskDeleted  = "task.deleted"
+)
+
+var EventTypes = []string{EventTaskCreated, EventTaskUpdated, EventTaskFinished, EventTaskDeleted}
+
+// Task is empty for task.deleted.
+type TaskEvent struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	TaskID     uint        `json:"task_id"`
+	Task       *TaskDomain `json:"task,omitempty"`
+	OccurredAt time.Time   `json:"occurred_at"`
+}
@@ -0,0 +1,31 @@
+package models
+
+const (
+	ImportSkip  = "skip"
+	ImportAbort = "abort"
+)
+
+// A row with a ParentUID waits for the row of the file with that UID. When
+// there is none, the ParentID of its task is used.
+type ImportRow struct {
+	Row       int
+	Task      *TaskDTO
+	Err       error
+	UID       string
+	ParentUID string
+}
+
+// Errors holds the first failed rows, Failed counts all of them.
+type ImportReport struct {
+	DryRun   bool          `json:"dry_run"`
+	Rows     int           `json:"rows"`
+	Imported int           `json:"imported"`
+	Failed   int           `json:"failed"`
+	Aborted  bool          `json:"aborted"`
+	Errors   []ImportError `json:"-"`
+}
+
+type ImportError struct {
+	Row int
+	Err error
+}
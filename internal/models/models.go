@@ -1,14 +1,49 @@
 package models
 
+import "time"
+
 type TaskDTO struct {
-	Header      string `json:"header" validate:"required"`
-	Description string `json:"description"`
-	Finished    bool   `json:"finished"`
+	Header      string     `json:"header" validate:"required,notblank,singleline,nocontrol,max=200"`
+	Description string     `json:"description" validate:"nocontrol,max=10000"`
+	Status      string     `json:"status,omitempty" validate:"status"`
+	Finished    *bool      `json:"finished,omitempty"` // true means done, false reopens a done task
+	DueAt       *time.Time `json:"due_at,omitempty" validate:"timerange"`
+	Tags        []string   `json:"tags,omitempty" validate:"tags"`
+	ParentID    *uint      `json:"parent_id,omitempty"`
+	Recurrence  string     `json:"recurrence,omitempty" validate:"recurrence"`
+	TimeZone    string     `json:"time_zone,omitempty" validate:"timezone"`
+	Reminders   []Duration `json:"reminders,omitempty" validate:"reminders"`
 }
 
 type TaskDomain struct {
-	ID          uint   `json:"id" validate:"required"`
-	Header      string `json:"header" validate:"required"`
-	Description string `json:"description" validate:"required"`
-	Finished    bool   `json:"finished" validate:"required"`
+	ID          uint       `json:"id"`
+	Header      string     `json:"header"`
+	Description string     `json:"description"`
+	Status      string     `json:"status"`
+	Finished    bool       `json:"finished"` // Status == StatusDone, kept for older clients
+	DueAt       *time.Time `json:"due_at,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	Reminders   []Reminder `json:"reminders,omitempty"`
+	ParentID    *uint      `json:"parent_id,omitempty"`
+	DependsOn   []uint     `json:"depends_on,omitempty"`
+	Version     uint64     `json:"version"`
+
+	// Recurrence is followed in TimeZone (UTC when empty), so occurrences keep
+	// their local time across DST changes.
+	Recurrence           string `json:"recurrence,omitempty"`
+	TimeZone             string `json:"time_zone,omitempty"`
+	Occurrence           int    `json:"occurrence,omitempty"`
+	PreviousOccurrenceID *uint  `json:"previous_occurrence_id,omitempty"`
+	NextOccurrenceID     *uint  `json:"next_occurrence_id,omitempty"`
+
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	// TrashedWith is the task whose cascading delete trashed this one.
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	TrashedWith *uint      `json:"trashed_with,omitempty"`
+
+	// Derived on read, never stored.
+	Children *ChildCount `json:"children,omitempty"`
+	Blocked  bool        `json:"blocked"`
 }
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+const (
+	SortByID     = "id"
+	SortByHeader = "header"
+	SortByDueAt  = "due_at"
+
+	TagModeAny = "any"
+	TagModeAll = "all"
+)
+
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+type TaskQuery struct {
+	Finished     *bool
+	Statuses     []string
+	HeaderPrefix string
+	UpdatedSince *time.Time
+	// DueAfter is inclusive, DueBefore exclusive.
+	DueAfter  *time.Time
+	DueBefore *time.Time
+	Overdue   bool
+	Tags      []string
+	TagMode   string
+	// With Tree the filters and the page apply to the roots only.
+	Tree bool
+
+	Sort   []SortKey
+	Limit  int
+	Cursor string
+	After  *TaskDomain
+}
+
+type TaskPage struct {
+	Tasks      []*TaskDomain
+	Trees      []*TaskTree
+	NextCursor string
+}
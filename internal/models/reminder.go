@@ -0,0 +1,42 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Duration is written to JSON as a Go duration string such as "1h30m".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return fmt.Errorf("duration must be a string such as \"1h30m\": %w", err)
+	}
+	parsed, err := time.ParseDuration(str)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+
+	return nil
+}
+
+// FiredAt is cleared when the due date moves.
+type Reminder struct {
+	Before  Duration   `json:"before"`
+	FiredAt *time.Time `json:"fired_at,omitempty"`
+}
+
+type DueReminder struct {
+	TaskID uint      `json:"task_id"`
+	Header string    `json:"header"`
+	DueAt  time.Time `json:"due_at"`
+	Before Duration  `json:"before"`
+	FireAt time.Time `json:"fire_at"`
+}
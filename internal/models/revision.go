@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+const (
+	RevisionCreated  = "created"
+	RevisionUpdated  = "updated"
+	RevisionDeleted  = "deleted"
+	RevisionRestored = "restored"
+)
+
+// Task is empty for a deletion.
+type Revision struct {
+	Number     int         `json:"revision"`
+	TaskID     uint        `json:"task_id"`
+	Op         string      `json:"op"`
+	Task       *TaskDomain `json:"task,omitempty"`
+	Actor      string      `json:"actor,omitempty"`
+	RecordedAt time.Time   `json:"recorded_at"`
+}
+
+type RevisionDiff struct {
+	Number     int           `json:"revision"`
+	Op         string        `json:"op"`
+	Version    uint64        `json:"version,omitempty"`
+	Actor      string        `json:"actor,omitempty"`
+	RecordedAt time.Time     `json:"recorded_at"`
+	Changes    []FieldChange `json:"changes"`
+}
+
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from,omitempty"`
+	To    interface{} `json:"to,omitempty"`
+}
@@ -0,0 +1,14 @@
+package models
+
+type SearchResult struct {
+	Task       *TaskDomain `json:"task"`
+	Score      float64     `json:"score"`
+	Highlights []Highlight `json:"highlights"`
+}
+
+// Start and End are rune offsets, End is exclusive.
+type Highlight struct {
+	Field string `json:"field"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
@@ -0,0 +1,17 @@
+package models
+
+const (
+	StatusTodo       = "todo"
+	StatusInProgress = "in_progress"
+	StatusInReview   = "in_review"
+	StatusDone       = "done"
+	StatusCancelled  = "cancelled"
+)
+
+var Statuses = []string{StatusTodo, StatusInProgress, StatusInReview, StatusDone, StatusCancelled}
+
+type Transition struct {
+	Name string   `json:"name"`
+	From []string `json:"from"`
+	To   string   `json:"to"`
+}
@@ -0,0 +1,17 @@
+package models
+
+const (
+	ChildrenReject  = "reject"
+	ChildrenCascade = "cascade"
+	ChildrenOrphan  = "orphan"
+)
+
+type ChildCount struct {
+	Total    int `json:"total"`
+	Finished int `json:"finished"`
+}
+
+type TaskTree struct {
+	*TaskDomain
+	Subtasks []*TaskTree `json:"subtasks"`
+}
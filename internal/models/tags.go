@@ -0,0 +1,6 @@
+package models
+
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
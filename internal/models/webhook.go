@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+type WebhookDTO struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events,omitempty"`
+	Secret string   `json:"secret,omitempty"`
+	Active *bool    `json:"active,omitempty"`
+}
+
+// An empty Events list subscribes to every event type. Failures counts the
+// deliveries that failed in a row.
+type Webhook struct {
+	ID         uint       `json:"id"`
+	URL        string     `json:"url"`
+	Events     []string   `json:"events"`
+	Secret     string     `json:"secret,omitempty"`
+	Active     bool       `json:"active"`
+	Failures   int        `json:"failures"`
+	DisabledAt *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// StatusCode is 0 when no response arrived.
+type Delivery struct {
+	ID         uint      `json:"id"`
+	WebhookID  uint      `json:"webhook_id"`
+	EventID    string    `json:"event_id"`
+	EventType  string    `json:"event_type"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Success    bool      `json:"success"`
+	DurationMS int64     `json:"duration_ms"`
+	SentAt     time.Time `json:"sent_at"`
+}
@@ -1,11 +1,122 @@
 package tasks
 
-import "context"
+import (
+	"context"
+	"maps"
+	"slices"
+	"time"
 
-func (r *Repo) DeleteTask(ctx context.Context, taskID uint) error {
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+// detach runs against a copy of every task that loses its parent or a
+// dependency. The IDs come back descendants first and taskID last.
+func (r *Repo) DeleteTask(ctx context.Context, taskID uint, mode string, deletedAt time.Time,
+	detach func(task *models.TaskDomain) error) ([]uint, error) {
 	r.mu.Lock()
-	delete(r.storage, taskID)
-	r.mu.Unlock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.storage[taskID]; !ok {
+		return nil, ErrTaskNotFound
+	}
+
+	return r.deleteLocked(taskID, mode, deletedAt, detach)
+}
+
+func (r *Repo) CompareAndDeleteTask(ctx context.Context, taskID uint, version uint64, mode string,
+	deletedAt time.Time, detach func(task *models.TaskDomain) error) ([]uint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.storage[taskID]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+	if stored.Version != version {
+		return nil, ErrVersionMismatch
+	}
+
+	return r.deleteLocked(taskID, mode, deletedAt, detach)
+}
+
+func (r *Repo) deleteLocked(taskID uint, mode string, deletedAt time.Time,
+	detach func(task *models.TaskDomain) error) ([]uint, error) {
+	deleted := []uint{taskID}
+	detached := make(map[uint]*models.TaskDomain)
+	if childIDs := r.children[taskID]; len(childIDs) > 0 {
+		switch mode {
+		case models.ChildrenCascade:
+			deleted = append(r.descendants(taskID), taskID)
+		case models.ChildrenOrphan:
+			for _, childID := range childIDs {
+				child := cloneTask(r.storage[childID])
+				child.ParentID = nil
+				detached[childID] = child
+			}
+		default:
+			return nil, ErrTaskHasChildren
+		}
+	}
+
+	gone := make(map[uint]bool, len(deleted))
+	for _, deletedID := range deleted {
+		gone[deletedID] = true
+	}
+	for _, deletedID := range deleted {
+		for _, dependentID := range r.dependents[deletedID] {
+			if gone[dependentID] {
+				continue
+			}
+			dependent, ok := detached[dependentID]
+			if !ok {
+				dependent = cloneTask(r.storage[dependentID])
+				detached[dependentID] = dependent
+			}
+			dependent.DependsOn = slices.DeleteFunc(dependent.DependsOn, func(dependencyID uint) bool {
+				return gone[dependencyID]
+			})
+			if len(dependent.DependsOn) == 0 {
+				dependent.DependsOn = nil
+			}
+		}
+	}
+
+	if len(deleted) == 1 && len(detached) == 0 {
+		if err := r.apply(r.trashChange(taskID, taskID, deletedAt)); err != nil {
+			return nil, err
+		}
+		return deleted, nil
+	}
+
+	batch := change{Op: opBatch, NextID: r.taskID}
+	for _, detachedID := range slices.Sorted(maps.Keys(detached)) {
+		task := detached[detachedID]
+		if detach != nil {
+			if err := detach(task); err != nil {
+				return nil, err
+			}
+		}
+		task.ID = detachedID
+		task.Version = r.storage[detachedID].Version + 1
+		batch.Changes = append(batch.Changes, change{Op: opPut, ID: detachedID, Task: task, NextID: r.taskID})
+	}
+	for _, deletedID := range deleted {
+		batch.Changes = append(batch.Changes, r.trashChange(deletedID, taskID, deletedAt))
+	}
+
+	if err := r.apply(batch); err != nil {
+		return nil, err
+	}
+
+	return deleted, nil
+}
+
+func (r *Repo) trashChange(taskID, rootID uint, deletedAt time.Time) change {
+	task := cloneTask(r.storage[taskID])
+	task.DeletedAt = &deletedAt
+	if taskID != rootID {
+		task.TrashedWith = &rootID
+	}
 
-	return nil
+	return change{Op: opTrash, ID: taskID, Task: task, NextID: r.taskID}
 }
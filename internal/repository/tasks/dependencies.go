@@ -0,0 +1,115 @@
+package tasks
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+// Path starts and ends with the task that was to get the dependency.
+type DependencyCycleError struct {
+	Path []uint `json:"cycle"`
+}
+
+func (e *DependencyCycleError) Error() string {
+	ids := make([]string, len(e.Path))
+	for i, taskID := range e.Path {
+		ids[i] = strconv.FormatUint(uint64(taskID), 10)
+	}
+
+	return "dependency cycle: " + strings.Join(ids, " -> ")
+}
+
+func (r *Repo) AddDependency(ctx context.Context, taskID, dependencyID uint,
+	update func(task *models.TaskDomain) error) (*models.TaskDomain, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.storage[taskID]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+	if _, ok := r.storage[dependencyID]; !ok {
+		return nil, ErrDependencyNotFound
+	}
+	if _, found := slices.BinarySearch(stored.DependsOn, dependencyID); found {
+		return r.view(stored), nil
+	}
+	if path := r.dependencyPath(dependencyID, taskID); path != nil {
+		return nil, &DependencyCycleError{Path: append([]uint{taskID}, path...)}
+	}
+
+	return r.swapLocked(stored, func(task *models.TaskDomain) error {
+		task.DependsOn = insertID(task.DependsOn, dependencyID)
+		return update(task)
+	})
+}
+
+func (r *Repo) RemoveDependency(ctx context.Context, taskID, dependencyID uint,
+	update func(task *models.TaskDomain) error) (*models.TaskDomain, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.storage[taskID]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+	if _, found := slices.BinarySearch(stored.DependsOn, dependencyID); !found {
+		return nil, ErrDependencyNotFound
+	}
+
+	return r.swapLocked(stored, func(task *models.TaskDomain) error {
+		task.DependsOn = removeID(task.DependsOn, dependencyID)
+		if len(task.DependsOn) == 0 {
+			task.DependsOn = nil
+		}
+		return update(task)
+	})
+}
+
+// The caller must hold r.mu.
+func (r *Repo) dependencyPath(from, to uint) []uint {
+	prev := map[uint]uint{from: from}
+	queue := []uint{from}
+	for len(queue) > 0 {
+		taskID := queue[0]
+		queue = queue[1:]
+		if taskID == to {
+			path := []uint{to}
+			for taskID != from {
+				taskID = prev[taskID]
+				path = append(path, taskID)
+			}
+			slices.Reverse(path)
+			return path
+		}
+		for _, dependencyID := range r.storage[taskID].DependsOn {
+			if _, seen := prev[dependencyID]; !seen {
+				prev[dependencyID] = taskID
+				queue = append(queue, dependencyID)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *Repo) depend(task *models.TaskDomain) {
+	for _, dependencyID := range task.DependsOn {
+		r.dependents[dependencyID] = insertID(r.dependents[dependencyID], task.ID)
+	}
+}
+
+func (r *Repo) undepend(task *models.TaskDomain) {
+	for _, dependencyID := range task.DependsOn {
+		dependents := removeID(r.dependents[dependencyID], task.ID)
+		if len(dependents) == 0 {
+			delete(r.dependents, dependencyID)
+		} else {
+			r.dependents[dependencyID] = dependents
+		}
+	}
+}
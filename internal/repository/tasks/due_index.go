@@ -0,0 +1,70 @@
+package tasks
+
+import (
+	"slices"
+	"time"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+type dueEntry struct {
+	at time.Time
+	id uint
+}
+
+// dueIndex is ordered by (due_at, id).
+type dueIndex struct {
+	entries []dueEntry
+}
+
+func (x *dueIndex) add(task *models.TaskDomain) {
+	if task.DueAt == nil {
+		return
+	}
+	entry := dueEntry{at: *task.DueAt, id: task.ID}
+	pos, _ := slices.BinarySearchFunc(x.entries, entry, compareDueEntries)
+	x.entries = slices.Insert(x.entries, pos, entry)
+}
+
+func (x *dueIndex) remove(task *models.TaskDomain) {
+	if task.DueAt == nil {
+		return
+	}
+	entry := dueEntry{at: *task.DueAt, id: task.ID}
+	if pos, found := slices.BinarySearchFunc(x.entries, entry, compareDueEntries); found {
+		x.entries = slices.Delete(x.entries, pos, pos+1)
+	}
+}
+
+// A nil bound leaves that side open.
+func (x *dueIndex) between(after, before *time.Time) []uint {
+	start, end := 0, len(x.entries)
+	if after != nil {
+		start, _ = slices.BinarySearchFunc(x.entries, *after, func(e dueEntry, t time.Time) int {
+			return e.at.Compare(t)
+		})
+	}
+	if before != nil {
+		end, _ = slices.BinarySearchFunc(x.entries, *before, func(e dueEntry, t time.Time) int {
+			return e.at.Compare(t)
+		})
+	}
+	if start >= end {
+		return nil
+	}
+
+	ids := make([]uint, 0, end-start)
+	for _, entry := range x.entries[start:end] {
+		ids = append(ids, entry.id)
+	}
+
+	return ids
+}
+
+func compareDueEntries(a, b dueEntry) int {
+	if c := a.at.Compare(b.at); c != 0 {
+		return c
+	}
+
+	return compareUint(a.id, b.id)
+}
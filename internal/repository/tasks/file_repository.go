@@ -0,0 +1,270 @@
+package tasks
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+const (
+	walFileName      = "tasks.wal"
+	snapshotFileName = "tasks.snapshot"
+)
+
+// FileRepo appends every mutation to an fsync'd log before applying it.
+type FileRepo struct {
+	*Repo
+	dir  string
+	wal  *os.File
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+type snapshot struct {
+	NextID uint                        `json:"next_id"`
+	Tasks  map[uint]*models.TaskDomain `json:"tasks"`
+	Trash  map[uint]*models.TaskDomain `json:"trash,omitempty"`
+
+	Revisions map[uint][]*models.Revision `json:"revisions,omitempty"`
+}
+
+func NewFile(dir string, snapshotInterval time.Duration) (*FileRepo, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("repository/file_repository.go - failed to create data dir - %w", err)
+	}
+
+	r := &FileRepo{
+		Repo: New(),
+		dir:  dir,
+		stop: make(chan struct{}),
+	}
+	if err := r.loadSnapshot(); err != nil {
+		return nil, err
+	}
+
+	wal, err := os.OpenFile(filepath.Join(dir, walFileName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("repository/file_repository.go - failed to open wal - %w", err)
+	}
+	r.wal = wal
+	if err := r.replayWAL(); err != nil {
+		_ = wal.Close()
+		return nil, err
+	}
+	r.journal = r
+
+	if snapshotInterval > 0 {
+		r.wg.Add(1)
+		go r.compactLoop(snapshotInterval)
+	}
+
+	return r, nil
+}
+
+func (r *FileRepo) loadSnapshot() error {
+	data, err := os.ReadFile(filepath.Join(r.dir, snapshotFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("repository/file_repository.go - failed to read snapshot - %w", err)
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("repository/file_repository.go - failed to decode snapshot - %w", err)
+	}
+	if snap.Tasks != nil {
+		for _, task := range snap.Tasks {
+			upgradeTask(task)
+		}
+		r.storage = snap.Tasks
+		r.reindex()
+	}
+	for taskID, task := range snap.Trash {
+		upgradeTask(task)
+		task.ID = taskID
+		r.trash[taskID] = task
+	}
+	if snap.Revisions != nil {
+		r.revisions = snap.Revisions
+	}
+	r.taskID = snap.NextID
+
+	return nil
+}
+
+func (r *FileRepo) replayWAL() error {
+	reader := bufio.NewReader(r.wal)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				slog.Warn("truncating torn wal record", slog.Int64("offset", offset))
+				if err := r.wal.Truncate(offset); err != nil {
+					return fmt.Errorf("repository/file_repository.go - failed to truncate wal - %w", err)
+				}
+			}
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("repository/file_repository.go - failed to read wal - %w", err)
+		}
+
+		var c change
+		if err := json.Unmarshal(bytes.TrimSpace(line), &c); err != nil {
+			return fmt.Errorf("repository/file_repository.go - corrupt wal record at offset %d - %w", offset, err)
+		}
+		upgradeChange(&c)
+		r.replay(c)
+		offset += int64(len(line))
+	}
+
+	if _, err := r.wal.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("repository/file_repository.go - failed to seek wal - %w", err)
+	}
+
+	return nil
+}
+
+func (r *FileRepo) append(c change) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("repository/file_repository.go - failed to encode wal record - %w", err)
+	}
+	data = append(data, '\n')
+
+	if _, err := r.wal.Write(data); err != nil {
+		return fmt.Errorf("repository/file_repository.go - failed to write wal - %w", err)
+	}
+	if err := r.wal.Sync(); err != nil {
+		return fmt.Errorf("repository/file_repository.go - failed to sync wal - %w", err)
+	}
+
+	return nil
+}
+
+// Replaying a record that is already in the snapshot is harmless, so a crash
+// between writing the snapshot and truncating the log loses nothing.
+func (r *FileRepo) Snapshot() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := json.Marshal(snapshot{NextID: r.taskID, Tasks: r.storage, Trash: r.trash, Revisions: r.revisions})
+	if err != nil {
+		return fmt.Errorf("repository/file_repository.go - failed to encode snapshot - %w", err)
+	}
+
+	tmpName := filepath.Join(r.dir, snapshotFileName+".tmp")
+	if err := writeFileSync(tmpName, data); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, filepath.Join(r.dir, snapshotFileName)); err != nil {
+		return fmt.Errorf("repository/file_repository.go - failed to rename snapshot - %w", err)
+	}
+	if err := syncDir(r.dir); err != nil {
+		return err
+	}
+
+	if err := r.wal.Truncate(0); err != nil {
+		return fmt.Errorf("repository/file_repository.go - failed to truncate wal - %w", err)
+	}
+	if _, err := r.wal.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("repository/file_repository.go - failed to seek wal - %w", err)
+	}
+	if err := r.wal.Sync(); err != nil {
+		return fmt.Errorf("repository/file_repository.go - failed to sync wal - %w", err)
+	}
+
+	return nil
+}
+
+func (r *FileRepo) compactLoop(interval time.Duration) {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			if err := r.Snapshot(); err != nil {
+				slog.Error("failed to compact wal", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+func (r *FileRepo) Close() error {
+	close(r.stop)
+	r.wg.Wait()
+
+	snapErr := r.Snapshot()
+	if err := r.wal.Close(); err != nil {
+		return fmt.Errorf("repository/file_repository.go - failed to close wal - %w", err)
+	}
+
+	return snapErr
+}
+
+// upgradeTask fills in the status of a task written before tasks had one.
+func upgradeTask(task *models.TaskDomain) {
+	if task.Status != "" {
+		return
+	}
+	task.Status = models.StatusTodo
+	if task.Finished {
+		task.Status = models.StatusDone
+	}
+}
+
+func upgradeChange(c *change) {
+	if c.Task != nil {
+		upgradeTask(c.Task)
+	}
+	for i := range c.Changes {
+		upgradeChange(&c.Changes[i])
+	}
+}
+
+func writeFileSync(name string, data []byte) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("repository/file_repository.go - failed to create %s - %w", name, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("repository/file_repository.go - failed to write %s - %w", name, err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("repository/file_repository.go - failed to sync %s - %w", name, err)
+	}
+
+	return f.Close()
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("repository/file_repository.go - failed to open data dir - %w", err)
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("repository/file_repository.go - failed to sync data dir - %w", err)
+	}
+
+	return nil
+}
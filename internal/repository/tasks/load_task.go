@@ -8,17 +8,12 @@ import (
 
 func (r *Repo) LoadTask(ctx context.Context, taskID uint) (*models.TaskDomain, error) {
 	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	task, ok := r.storage[taskID]
-	r.mu.RUnlock()
 	if !ok {
 		return nil, ErrTaskNotFound
 	}
-	taskDomain := models.TaskDomain{
-		ID:          taskID,
-		Header:      task.Header,
-		Description: task.Description,
-		Finished:    task.Finished,
-	}
 
-	return &taskDomain, nil
+	return r.view(task), nil
 }
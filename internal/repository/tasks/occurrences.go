@@ -0,0 +1,52 @@
+package tasks
+
+import (
+	"context"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+// A task that already has a next occurrence is returned as is, so a series
+// never forks.
+func (r *Repo) StoreNextOccurrence(ctx context.Context, taskID uint, next *models.TaskDomain,
+	update func(task *models.TaskDomain) error) (*models.TaskDomain, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.storage[taskID]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+	if stored.NextOccurrenceID != nil {
+		return r.view(stored), nil
+	}
+
+	nextID := r.freeID()
+	occurrence := cloneTask(next)
+	occurrence.ID = nextID
+	occurrence.Version = 1
+	occurrence.PreviousOccurrenceID = &taskID
+	occurrence.NextOccurrenceID = nil
+	if err := r.checkParent(occurrence); err != nil {
+		return nil, err
+	}
+
+	task := r.view(stored)
+	if err := update(task); err != nil {
+		return nil, err
+	}
+	clearDerived(task)
+	task.ID = taskID
+	task.Version = stored.Version + 1
+	task.NextOccurrenceID = &nextID
+
+	err := r.apply(change{Op: opBatch, NextID: nextID + 1, Changes: []change{
+		{Op: opPut, ID: nextID, Task: occurrence, NextID: nextID + 1},
+		{Op: opPut, ID: taskID, Task: task, NextID: nextID + 1},
+	}})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.view(task), nil
+}
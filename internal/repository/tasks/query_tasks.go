@@ -0,0 +1,196 @@
+package tasks
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+func (r *Repo) QueryTasks(ctx context.Context, query *models.TaskQuery) ([]*models.TaskDomain, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	dueRange := query.DueAfter != nil || query.DueBefore != nil
+	if desc, ok := idOrder(query.Sort); ok && !dueRange {
+		return r.queryByID(query, desc), nil
+	}
+
+	var scope []*models.TaskDomain
+	if dueRange {
+		for _, taskID := range r.due.between(query.DueAfter, query.DueBefore) {
+			scope = append(scope, r.storage[taskID])
+		}
+	} else {
+		scope = make([]*models.TaskDomain, 0, len(r.storage))
+		for _, task := range r.storage {
+			scope = append(scope, task)
+		}
+	}
+
+	candidates := make([]*models.TaskDomain, 0, len(scope))
+	for _, task := range scope {
+		if !matchesQuery(task, query) {
+			continue
+		}
+		if query.After != nil && compareTasks(task, query.After, query.Sort) <= 0 {
+			continue
+		}
+		candidates = append(candidates, task)
+	}
+	slices.SortFunc(candidates, func(a, b *models.TaskDomain) int {
+		return compareTasks(a, b, query.Sort)
+	})
+	if query.Limit > 0 && len(candidates) > query.Limit {
+		candidates = candidates[:query.Limit]
+	}
+
+	tasks := make([]*models.TaskDomain, 0, len(candidates))
+	for _, task := range candidates {
+		tasks = append(tasks, r.view(task))
+	}
+
+	return tasks, nil
+}
+
+func (r *Repo) queryByID(query *models.TaskQuery, desc bool) []*models.TaskDomain {
+	tasks := []*models.TaskDomain{}
+	add := func(taskID uint) bool {
+		task := r.storage[taskID]
+		if !matchesQuery(task, query) {
+			return true
+		}
+		tasks = append(tasks, r.view(task))
+		return query.Limit <= 0 || len(tasks) < query.Limit
+	}
+
+	if !desc {
+		start := 0
+		if query.After != nil {
+			start, _ = slices.BinarySearch(r.ids, query.After.ID+1)
+		}
+		for _, taskID := range r.ids[start:] {
+			if !add(taskID) {
+				break
+			}
+		}
+		return tasks
+	}
+
+	end := len(r.ids)
+	if query.After != nil {
+		end, _ = slices.BinarySearch(r.ids, query.After.ID)
+	}
+	for i := end - 1; i >= 0; i-- {
+		if !add(r.ids[i]) {
+			break
+		}
+	}
+
+	return tasks
+}
+
+func matchesQuery(task *models.TaskDomain, query *models.TaskQuery) bool {
+	if query.Finished != nil && task.Finished != *query.Finished {
+		return false
+	}
+	if len(query.Statuses) > 0 && !slices.Contains(query.Statuses, task.Status) {
+		return false
+	}
+	if query.HeaderPrefix != "" &&
+		!strings.HasPrefix(strings.ToLower(task.Header), strings.ToLower(query.HeaderPrefix)) {
+		return false
+	}
+	if query.UpdatedSince != nil && task.UpdatedAt.Before(*query.UpdatedSince) {
+		return false
+	}
+	if query.DueAfter != nil && (task.DueAt == nil || task.DueAt.Before(*query.DueAfter)) {
+		return false
+	}
+	if query.DueBefore != nil && (task.DueAt == nil || !task.DueAt.Before(*query.DueBefore)) {
+		return false
+	}
+	if len(query.Tags) > 0 && !matchesTags(task.Tags, query.Tags, query.TagMode) {
+		return false
+	}
+	if query.Tree && task.ParentID != nil {
+		return false
+	}
+
+	return true
+}
+
+func matchesTags(taskTags, tags []string, mode string) bool {
+	for _, tag := range tags {
+		has := slices.Contains(taskTags, tag)
+		if has && mode != models.TagModeAll {
+			return true
+		}
+		if !has && mode == models.TagModeAll {
+			return false
+		}
+	}
+
+	return mode == models.TagModeAll
+}
+
+// idOrder reports whether sort orders by ID alone, and in which direction.
+func idOrder(sort []models.SortKey) (desc bool, ok bool) {
+	if len(sort) == 0 {
+		return false, true
+	}
+	if sort[0].Field == models.SortByID {
+		return sort[0].Desc, true
+	}
+
+	return false, false
+}
+
+func compareTasks(a, b *models.TaskDomain, sort []models.SortKey) int {
+	for _, key := range sort {
+		var c int
+		switch key.Field {
+		case models.SortByID:
+			c = compareUint(a.ID, b.ID)
+		case models.SortByHeader:
+			c = strings.Compare(a.Header, b.Header)
+		case models.SortByDueAt:
+			c = compareDueAt(a.DueAt, b.DueAt)
+		}
+		if key.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+
+	return compareUint(a.ID, b.ID)
+}
+
+// compareDueAt orders tasks without a due date after those with one.
+func compareDueAt(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	default:
+		return a.Compare(*b)
+	}
+}
+
+func compareUint(a, b uint) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -2,23 +2,263 @@ package tasks
 
 import (
 	"errors"
+	"slices"
 	"sync"
 
 	"github.com/avraam311/tasks-service/internal/models"
 )
 
 var (
-	ErrTaskNotFound = errors.New("task not found")
+	ErrTaskNotFound       = errors.New("task not found")
+	ErrVersionMismatch    = errors.New("task version mismatch")
+	ErrTagNotFound        = errors.New("tag not found")
+	ErrParentNotFound     = errors.New("parent task not found")
+	ErrParentCycle        = errors.New("parent chain forms a cycle")
+	ErrTaskHasChildren    = errors.New("task has children")
+	ErrDependencyNotFound = errors.New("dependency not found")
+	ErrTaskTrashed        = errors.New("task is in the trash")
+	ErrTaskIDOutOfRange   = errors.New("task id out of range")
 )
 
+const (
+	opPut      = "put"
+	opRestore  = "restore"
+	opDelete   = "delete"
+	opTrash    = "trash"
+	opBatch    = "batch"
+	opRevision = "revision"
+)
+
+// Every write goes through apply, so a durable backend can journal it before
+// it becomes visible. A batch is journaled as one record and survives a crash
+// whole or not at all.
+type change struct {
+	Op       string             `json:"op"`
+	ID       uint               `json:"id"`
+	Task     *models.TaskDomain `json:"task,omitempty"`
+	Changes  []change           `json:"changes,omitempty"`
+	Revision *models.Revision   `json:"revision,omitempty"`
+	NextID   uint               `json:"next_id"`
+}
+
+type journal interface {
+	append(c change) error
+}
+
 type Repo struct {
-	storage map[uint]*models.TaskDTO
-	taskID  uint
-	mu      sync.RWMutex
+	storage map[uint]*models.TaskDomain
+	// The indexes cover storage only, so reads never see the trash.
+	trash    map[uint]*models.TaskDomain
+	ids      []uint
+	due      *dueIndex
+	tags     map[string]map[uint]struct{}
+	children map[uint][]uint
+	// dependents maps a task to the IDs of the tasks that depend on it.
+	dependents map[uint][]uint
+	index      *searchIndex
+	revisions  map[uint][]*models.Revision
+	taskID     uint
+	mu         sync.RWMutex
+	journal    journal
 }
 
 func New() *Repo {
 	return &Repo{
-		storage: make(map[uint]*models.TaskDTO),
+		storage:    make(map[uint]*models.TaskDomain),
+		trash:      make(map[uint]*models.TaskDomain),
+		due:        &dueIndex{},
+		tags:       make(map[string]map[uint]struct{}),
+		children:   make(map[uint][]uint),
+		dependents: make(map[uint][]uint),
+		index:      newSearchIndex(),
+		revisions:  make(map[uint][]*models.Revision),
+	}
+}
+
+// The caller must hold r.mu for writing.
+func (r *Repo) apply(c change) error {
+	if err := r.check(c); err != nil {
+		return err
+	}
+	if r.journal != nil {
+		if err := r.journal.append(c); err != nil {
+			return err
+		}
+	}
+	r.replay(c)
+
+	return nil
+}
+
+// A put never replaces a task in the trash, only a restore takes it out.
+// The caller must hold r.mu.
+func (r *Repo) check(c change) error {
+	switch c.Op {
+	case opPut:
+		if _, ok := r.trash[c.ID]; ok {
+			return ErrTaskTrashed
+		}
+	case opBatch:
+		for _, inner := range c.Changes {
+			if err := r.check(inner); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *Repo) replay(c change) {
+	switch c.Op {
+	case opPut, opRestore:
+		c.Task.ID = c.ID
+		if old, ok := r.storage[c.ID]; ok {
+			r.due.remove(old)
+			r.untag(old)
+			r.unlink(old)
+			r.undepend(old)
+		} else {
+			pos, _ := slices.BinarySearch(r.ids, c.ID)
+			r.ids = slices.Insert(r.ids, pos, c.ID)
+		}
+		delete(r.trash, c.ID)
+		r.storage[c.ID] = c.Task
+		r.due.add(c.Task)
+		r.tag(c.Task)
+		r.link(c.Task)
+		r.depend(c.Task)
+		r.index.add(c.Task)
+	case opDelete, opTrash:
+		if old, ok := r.storage[c.ID]; ok {
+			pos, _ := slices.BinarySearch(r.ids, c.ID)
+			r.ids = slices.Delete(r.ids, pos, pos+1)
+			r.due.remove(old)
+			r.untag(old)
+			r.unlink(old)
+			r.undepend(old)
+		}
+		delete(r.storage, c.ID)
+		r.index.remove(c.ID)
+		delete(r.trash, c.ID)
+		if c.Op == opTrash {
+			c.Task.ID = c.ID
+			r.trash[c.ID] = c.Task
+		}
+	case opBatch:
+		for _, inner := range c.Changes {
+			r.replay(inner)
+		}
+	case opRevision:
+		// The record may already be in the snapshot when a crash hit
+		// between writing it and truncating the log.
+		if revisions := r.revisions[c.ID]; c.Revision.Number > len(revisions) {
+			r.revisions[c.ID] = append(revisions, c.Revision)
+		}
+	}
+	r.taskID = max(r.taskID, c.NextID)
+}
+
+func (r *Repo) tag(task *models.TaskDomain) {
+	for _, name := range task.Tags {
+		if r.tags[name] == nil {
+			r.tags[name] = make(map[uint]struct{})
+		}
+		r.tags[name][task.ID] = struct{}{}
+	}
+}
+
+func (r *Repo) untag(task *models.TaskDomain) {
+	for _, name := range task.Tags {
+		delete(r.tags[name], task.ID)
+		if len(r.tags[name]) == 0 {
+			delete(r.tags, name)
+		}
 	}
 }
+
+func (r *Repo) reindex() {
+	r.ids = make([]uint, 0, len(r.storage))
+	r.due = &dueIndex{}
+	r.tags = make(map[string]map[uint]struct{})
+	r.children = make(map[uint][]uint)
+	r.dependents = make(map[uint][]uint)
+	r.index = newSearchIndex()
+	for taskID, task := range r.storage {
+		task.ID = taskID
+		r.ids = append(r.ids, taskID)
+		r.due.add(task)
+		r.tag(task)
+		r.link(task)
+		r.depend(task)
+		r.index.add(task)
+	}
+	slices.Sort(r.ids)
+}
+
+// cloneTask copies a task so that callers never share memory with storage.
+func cloneTask(task *models.TaskDomain) *models.TaskDomain {
+	taskCopy := *task
+	if task.CompletedAt != nil {
+		completedAt := *task.CompletedAt
+		taskCopy.CompletedAt = &completedAt
+	}
+	if task.DeletedAt != nil {
+		deletedAt := *task.DeletedAt
+		taskCopy.DeletedAt = &deletedAt
+	}
+	if task.TrashedWith != nil {
+		trashedWith := *task.TrashedWith
+		taskCopy.TrashedWith = &trashedWith
+	}
+	if task.DueAt != nil {
+		dueAt := *task.DueAt
+		taskCopy.DueAt = &dueAt
+	}
+	taskCopy.Tags = slices.Clone(task.Tags)
+	taskCopy.Reminders = slices.Clone(task.Reminders)
+	for i, reminder := range task.Reminders {
+		if reminder.FiredAt != nil {
+			firedAt := *reminder.FiredAt
+			taskCopy.Reminders[i].FiredAt = &firedAt
+		}
+	}
+	if task.ParentID != nil {
+		parentID := *task.ParentID
+		taskCopy.ParentID = &parentID
+	}
+	taskCopy.DependsOn = slices.Clone(task.DependsOn)
+	if task.PreviousOccurrenceID != nil {
+		previousID := *task.PreviousOccurrenceID
+		taskCopy.PreviousOccurrenceID = &previousID
+	}
+	if task.NextOccurrenceID != nil {
+		nextID := *task.NextOccurrenceID
+		taskCopy.NextOccurrenceID = &nextID
+	}
+	clearDerived(&taskCopy)
+	return &taskCopy
+}
+
+func clearDerived(task *models.TaskDomain) {
+	task.Children = nil
+	task.Blocked = false
+}
+
+func insertID(ids []uint, id uint) []uint {
+	pos, found := slices.BinarySearch(ids, id)
+	if found {
+		return ids
+	}
+
+	return slices.Insert(ids, pos, id)
+}
+
+func removeID(ids []uint, id uint) []uint {
+	pos, found := slices.BinarySearch(ids, id)
+	if !found {
+		return ids
+	}
+
+	return slices.Delete(ids, pos, pos+1)
+}
@@ -4,8 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -13,6 +18,20 @@ import (
 	"github.com/avraam311/tasks-service/internal/models"
 )
 
+// deletedAt is the moment tasks deleted in tests are trashed at.
+var deletedAt = time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC)
+
+// replaceWith returns an update that overwrites the client-writable fields
+// of the stored task with those of task.
+func replaceWith(task *models.TaskDomain) func(stored *models.TaskDomain) error {
+	return func(stored *models.TaskDomain) error {
+		stored.Header = task.Header
+		stored.Description = task.Description
+		stored.Finished = task.Finished
+		return nil
+	}
+}
+
 func TestRepo_New(t *testing.T) {
 	repo := New()
 
@@ -26,7 +45,7 @@ func TestRepo_StoreTask(t *testing.T) {
 
 	t.Run("First Task", func(t *testing.T) {
 		repo := New()
-		task := &models.TaskDTO{
+		task := &models.TaskDomain{
 			Header:      "Test Task 1",
 			Description: "Test Description 1",
 			Finished:    false,
@@ -39,12 +58,18 @@ func TestRepo_StoreTask(t *testing.T) {
 
 		storedTask, ok := repo.storage[taskID]
 		assert.True(t, ok)
-		assert.Equal(t, task, storedTask)
+		assert.Equal(t, &models.TaskDomain{
+			ID:          taskID,
+			Header:      task.Header,
+			Description: task.Description,
+			Finished:    task.Finished,
+			Version:     1,
+		}, storedTask)
 	})
 
 	t.Run("Second Task", func(t *testing.T) {
 		repo := New()
-		task := &models.TaskDTO{
+		task := &models.TaskDomain{
 			Header:      "Test Task 2",
 			Description: "Test Description 2",
 			Finished:    true,
@@ -57,15 +82,21 @@ func TestRepo_StoreTask(t *testing.T) {
 
 		storedTask, ok := repo.storage[taskID]
 		assert.True(t, ok)
-		assert.Equal(t, task, storedTask)
+		assert.Equal(t, &models.TaskDomain{
+			ID:          taskID,
+			Header:      task.Header,
+			Description: task.Description,
+			Finished:    task.Finished,
+			Version:     1,
+		}, storedTask)
 	})
 
 	t.Run("Auto-increment", func(t *testing.T) {
 		repo := New()
 
-		task1 := &models.TaskDTO{Header: "Task 1"}
-		task2 := &models.TaskDTO{Header: "Task 2"}
-		task3 := &models.TaskDTO{Header: "Task 3"}
+		task1 := &models.TaskDomain{Header: "Task 1"}
+		task2 := &models.TaskDomain{Header: "Task 2"}
+		task3 := &models.TaskDomain{Header: "Task 3"}
 
 		id1, _ := repo.StoreTask(ctx, task1)
 		id2, _ := repo.StoreTask(ctx, task2)
@@ -84,7 +115,7 @@ func TestRepo_LoadTask(t *testing.T) {
 	t.Run("Existing Task", func(t *testing.T) {
 		repo := New()
 
-		task := &models.TaskDTO{
+		task := &models.TaskDomain{
 			Header:      "Test Task",
 			Description: "Test Description",
 			Finished:    false,
@@ -123,13 +154,13 @@ func TestRepo_LoadTask(t *testing.T) {
 	})
 }
 
-func TestRepo_LoadAllTasks(t *testing.T) {
+func TestRepo_QueryTasks_All(t *testing.T) {
 	ctx := context.Background()
 
 	t.Run("Empty Repository", func(t *testing.T) {
 		repo := New()
 
-		tasks, err := repo.LoadAllTasks(ctx)
+		tasks, err := repo.QueryTasks(ctx, &models.TaskQuery{})
 
 		assert.NoError(t, err)
 		assert.NotNil(t, tasks)
@@ -139,14 +170,14 @@ func TestRepo_LoadAllTasks(t *testing.T) {
 	t.Run("Single Task", func(t *testing.T) {
 		repo := New()
 
-		task := &models.TaskDTO{
+		task := &models.TaskDomain{
 			Header:      "Single Task",
 			Description: "Single Description",
 			Finished:    true,
 		}
 		taskID, _ := repo.StoreTask(ctx, task)
 
-		tasks, err := repo.LoadAllTasks(ctx)
+		tasks, err := repo.QueryTasks(ctx, &models.TaskQuery{})
 
 		assert.NoError(t, err)
 		assert.Len(t, tasks, 1)
@@ -161,15 +192,15 @@ func TestRepo_LoadAllTasks(t *testing.T) {
 	t.Run("Multiple Tasks", func(t *testing.T) {
 		repo := New()
 
-		task1 := &models.TaskDTO{Header: "Task 1", Description: "Desc 1", Finished: false}
-		task2 := &models.TaskDTO{Header: "Task 2", Description: "Desc 2", Finished: true}
-		task3 := &models.TaskDTO{Header: "Task 3", Description: "Desc 3", Finished: false}
+		task1 := &models.TaskDomain{Header: "Task 1", Description: "Desc 1", Finished: false}
+		task2 := &models.TaskDomain{Header: "Task 2", Description: "Desc 2", Finished: true}
+		task3 := &models.TaskDomain{Header: "Task 3", Description: "Desc 3", Finished: false}
 
 		id1, _ := repo.StoreTask(ctx, task1)
 		id2, _ := repo.StoreTask(ctx, task2)
 		id3, _ := repo.StoreTask(ctx, task3)
 
-		tasks, err := repo.LoadAllTasks(ctx)
+		tasks, err := repo.QueryTasks(ctx, &models.TaskQuery{})
 
 		assert.NoError(t, err)
 		assert.Len(t, tasks, 3)
@@ -186,6 +217,577 @@ func TestRepo_LoadAllTasks(t *testing.T) {
 		assert.Equal(t, task1.Header, taskMap[id1].Header)
 		assert.Equal(t, task2.Header, taskMap[id2].Header)
 		assert.Equal(t, task3.Header, taskMap[id3].Header)
+		assert.Equal(t, []uint{id1, id2, id3}, []uint{tasks[0].ID, tasks[1].ID, tasks[2].ID})
+	})
+}
+
+func TestRepo_QueryTasks(t *testing.T) {
+	ctx := context.Background()
+	repo := New()
+
+	for _, header := range []string{"C", "A", "B", "A"} {
+		_, err := repo.StoreTask(ctx, &models.TaskDomain{Header: header})
+		require.NoError(t, err)
+	}
+	_, err := repo.DeleteTask(ctx, 2, models.ChildrenReject, deletedAt, nil)
+	require.NoError(t, err)
+
+	ids := func(tasks []*models.TaskDomain) []uint {
+		result := []uint{}
+		for _, task := range tasks {
+			result = append(result, task.ID)
+		}
+		return result
+	}
+
+	t.Run("ID Order", func(t *testing.T) {
+		tasks, err := repo.QueryTasks(ctx, &models.TaskQuery{Limit: 2})
+		require.NoError(t, err)
+		assert.Equal(t, []uint{0, 1}, ids(tasks))
+
+		tasks, err = repo.QueryTasks(ctx, &models.TaskQuery{Limit: 2, After: tasks[1]})
+		require.NoError(t, err)
+		assert.Equal(t, []uint{3}, ids(tasks))
+	})
+
+	t.Run("ID Descending", func(t *testing.T) {
+		sort := []models.SortKey{{Field: models.SortByID, Desc: true}}
+
+		tasks, err := repo.QueryTasks(ctx, &models.TaskQuery{Sort: sort})
+		require.NoError(t, err)
+		assert.Equal(t, []uint{3, 1, 0}, ids(tasks))
+
+		tasks, err = repo.QueryTasks(ctx, &models.TaskQuery{Sort: sort, After: &models.TaskDomain{ID: 3}})
+		require.NoError(t, err)
+		assert.Equal(t, []uint{1, 0}, ids(tasks))
+	})
+
+	t.Run("Header Order", func(t *testing.T) {
+		sort := []models.SortKey{{Field: models.SortByHeader}}
+
+		tasks, err := repo.QueryTasks(ctx, &models.TaskQuery{Sort: sort, Limit: 1})
+		require.NoError(t, err)
+		assert.Equal(t, []uint{1}, ids(tasks))
+
+		tasks, err = repo.QueryTasks(ctx, &models.TaskQuery{Sort: sort, After: tasks[0]})
+		require.NoError(t, err)
+		assert.Equal(t, []uint{3, 0}, ids(tasks))
+	})
+}
+
+func TestRepo_QueryTasks_Filters(t *testing.T) {
+	ctx := context.Background()
+	repo := New()
+
+	_, _ = repo.StoreTask(ctx, &models.TaskDomain{Header: "Deploy backend", Finished: true})
+	_, _ = repo.StoreTask(ctx, &models.TaskDomain{Header: "deploy frontend"})
+	_, _ = repo.StoreTask(ctx, &models.TaskDomain{Header: "Write docs", Finished: true})
+
+	finished := true
+	tasks, err := repo.QueryTasks(ctx, &models.TaskQuery{Finished: &finished})
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	assert.Equal(t, uint(0), tasks[0].ID)
+	assert.Equal(t, uint(2), tasks[1].ID)
+
+	tasks, err = repo.QueryTasks(ctx, &models.TaskQuery{
+		HeaderPrefix: "DEPLOY",
+		Sort:         []models.SortKey{{Field: models.SortByHeader, Desc: true}},
+	})
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	assert.Equal(t, "deploy frontend", tasks[0].Header)
+	assert.Equal(t, "Deploy backend", tasks[1].Header)
+
+	notFinished := false
+	tasks, err = repo.QueryTasks(ctx, &models.TaskQuery{Finished: &notFinished, HeaderPrefix: "write"})
+	require.NoError(t, err)
+	assert.Len(t, tasks, 0)
+
+	since := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	_, err = repo.SwapTask(ctx, 1, func(task *models.TaskDomain) error {
+		task.UpdatedAt = since
+		return nil
+	})
+	require.NoError(t, err)
+	tasks, err = repo.QueryTasks(ctx, &models.TaskQuery{UpdatedSince: &since})
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, uint(1), tasks[0].ID)
+
+	_, err = repo.SwapTask(ctx, 1, func(task *models.TaskDomain) error {
+		task.Status = models.StatusInReview
+		return nil
+	})
+	require.NoError(t, err)
+	tasks, err = repo.QueryTasks(ctx, &models.TaskQuery{Statuses: []string{models.StatusInReview, models.StatusCancelled}})
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, uint(1), tasks[0].ID)
+}
+
+func TestRepo_QueryTasks_DueDates(t *testing.T) {
+	ctx := context.Background()
+	repo := New()
+
+	at := func(day int) *time.Time {
+		due := time.Date(2024, 5, day, 9, 0, 0, 0, time.UTC)
+		return &due
+	}
+	_, _ = repo.StoreTask(ctx, &models.TaskDomain{Header: "May 3", DueAt: at(3)})
+	_, _ = repo.StoreTask(ctx, &models.TaskDomain{Header: "No due date"})
+	_, _ = repo.StoreTask(ctx, &models.TaskDomain{Header: "May 1", DueAt: at(1)})
+	_, _ = repo.StoreTask(ctx, &models.TaskDomain{Header: "May 2", DueAt: at(2), Finished: true})
+
+	tasks, err := repo.QueryTasks(ctx, &models.TaskQuery{DueAfter: at(1), DueBefore: at(3)})
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	assert.Equal(t, "May 1", tasks[0].Header)
+	assert.Equal(t, "May 2", tasks[1].Header)
+
+	notFinished := false
+	tasks, err = repo.QueryTasks(ctx, &models.TaskQuery{
+		DueBefore: at(4),
+		Finished:  &notFinished,
+		Sort:      []models.SortKey{{Field: models.SortByDueAt}},
+	})
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	assert.Equal(t, "May 1", tasks[0].Header)
+	assert.Equal(t, "May 3", tasks[1].Header)
+
+	t.Run("Sort Puts Missing Due Dates Last", func(t *testing.T) {
+		tasks, err := repo.QueryTasks(ctx, &models.TaskQuery{Sort: []models.SortKey{{Field: models.SortByDueAt}}})
+		require.NoError(t, err)
+		require.Len(t, tasks, 4)
+		assert.Equal(t, "No due date", tasks[3].Header)
+	})
+
+	t.Run("Index Follows Updates", func(t *testing.T) {
+		_, err := repo.SwapTask(ctx, 0, func(task *models.TaskDomain) error {
+			task.DueAt = at(10)
+			return nil
+		})
+		require.NoError(t, err)
+		_, err = repo.DeleteTask(ctx, 2, models.ChildrenReject, deletedAt, nil)
+		require.NoError(t, err)
+
+		assert.Len(t, repo.due.entries, 2)
+		tasks, err := repo.QueryTasks(ctx, &models.TaskQuery{DueBefore: at(5)})
+		require.NoError(t, err)
+		require.Len(t, tasks, 1)
+		assert.Equal(t, "May 2", tasks[0].Header)
+	})
+}
+
+func TestRepo_Tags(t *testing.T) {
+	ctx := context.Background()
+	repo := New()
+
+	_, _ = repo.StoreTask(ctx, &models.TaskDomain{Header: "Deploy", Tags: []string{"backend", "ops"}})
+	_, _ = repo.StoreTask(ctx, &models.TaskDomain{Header: "Fix login", Tags: []string{"backend", "urgent"}})
+	_, _ = repo.StoreTask(ctx, &models.TaskDomain{Header: "Untagged"})
+
+	tags, err := repo.LoadTags(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*models.TagCount{
+		{Name: "backend", Count: 2},
+		{Name: "ops", Count: 1},
+		{Name: "urgent", Count: 1},
+	}, tags)
+
+	t.Run("Any And All Filters", func(t *testing.T) {
+		tasks, err := repo.QueryTasks(ctx, &models.TaskQuery{Tags: []string{"ops", "urgent"}, TagMode: models.TagModeAny})
+		require.NoError(t, err)
+		assert.Len(t, tasks, 2)
+
+		tasks, err = repo.QueryTasks(ctx, &models.TaskQuery{Tags: []string{"backend", "urgent"}, TagMode: models.TagModeAll})
+		require.NoError(t, err)
+		require.Len(t, tasks, 1)
+		assert.Equal(t, "Fix login", tasks[0].Header)
+	})
+
+	t.Run("Swap Tagged Tasks", func(t *testing.T) {
+		swapped, err := repo.SwapTaggedTasks(ctx, "backend", func(task *models.TaskDomain) error {
+			task.Tags = append(slices.DeleteFunc(task.Tags, func(tag string) bool { return tag == "backend" }), "api")
+			slices.Sort(task.Tags)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, swapped)
+
+		tags, err := repo.LoadTags(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []*models.TagCount{
+			{Name: "api", Count: 2},
+			{Name: "ops", Count: 1},
+			{Name: "urgent", Count: 1},
+		}, tags)
+
+		task, err := repo.LoadTask(ctx, 0)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2), task.Version)
+	})
+
+	t.Run("Failed Update Swaps Nothing", func(t *testing.T) {
+		_, err := repo.SwapTaggedTasks(ctx, "api", func(task *models.TaskDomain) error {
+			if task.ID == 1 {
+				return assert.AnError
+			}
+			task.Tags = nil
+			return nil
+		})
+		assert.True(t, errors.Is(err, assert.AnError))
+
+		task, err := repo.LoadTask(ctx, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"api", "ops"}, task.Tags)
+	})
+
+	t.Run("Unknown Tag", func(t *testing.T) {
+		_, err := repo.SwapTaggedTasks(ctx, "missing", func(task *models.TaskDomain) error { return nil })
+		assert.True(t, errors.Is(err, ErrTagNotFound))
+	})
+}
+
+func TestRepo_Subtasks(t *testing.T) {
+	ctx := context.Background()
+	parentOf := func(taskID uint) *uint { return &taskID }
+
+	newTree := func(t *testing.T) *Repo {
+		repo := New()
+		_, err := repo.StoreTask(ctx, &models.TaskDomain{Header: "Release"})
+		require.NoError(t, err)
+		_, err = repo.StoreTask(ctx, &models.TaskDomain{Header: "Build", ParentID: parentOf(0), Finished: true})
+		require.NoError(t, err)
+		_, err = repo.StoreTask(ctx, &models.TaskDomain{Header: "Deploy", ParentID: parentOf(0)})
+		require.NoError(t, err)
+		_, err = repo.StoreTask(ctx, &models.TaskDomain{Header: "Smoke test", ParentID: parentOf(2)})
+		require.NoError(t, err)
+		return repo
+	}
+
+	t.Run("Children And Counts", func(t *testing.T) {
+		repo := newTree(t)
+
+		children, err := repo.LoadChildren(ctx, 0)
+		require.NoError(t, err)
+		require.Len(t, children, 2)
+		assert.Equal(t, "Build", children[0].Header)
+		assert.Equal(t, &models.ChildCount{Total: 1, Finished: 0}, children[1].Children)
+
+		parent, err := repo.LoadTask(ctx, 0)
+		require.NoError(t, err)
+		assert.Equal(t, &models.ChildCount{Total: 2, Finished: 1}, parent.Children)
+		assert.Nil(t, repo.storage[0].Children)
+
+		_, err = repo.LoadChildren(ctx, 99)
+		assert.True(t, errors.Is(err, ErrTaskNotFound))
+	})
+
+	t.Run("Trees", func(t *testing.T) {
+		repo := newTree(t)
+
+		trees, err := repo.LoadTaskTrees(ctx, []uint{0})
+		require.NoError(t, err)
+		require.Len(t, trees, 1)
+		require.Len(t, trees[0].Subtasks, 2)
+		assert.Equal(t, "Smoke test", trees[0].Subtasks[1].Subtasks[0].Header)
+
+		roots, err := repo.QueryTasks(ctx, &models.TaskQuery{Tree: true})
+		require.NoError(t, err)
+		require.Len(t, roots, 1)
+		assert.Equal(t, uint(0), roots[0].ID)
+	})
+
+	t.Run("Parent Checks", func(t *testing.T) {
+		repo := newTree(t)
+
+		_, err := repo.StoreTask(ctx, &models.TaskDomain{Header: "Dangling", ParentID: parentOf(99)})
+		assert.True(t, errors.Is(err, ErrParentNotFound))
+
+		_, err = repo.SwapTask(ctx, 0, func(task *models.TaskDomain) error {
+			task.ParentID = parentOf(3)
+			return nil
+		})
+		assert.True(t, errors.Is(err, ErrParentCycle))
+
+		_, err = repo.SwapTask(ctx, 2, func(task *models.TaskDomain) error {
+			task.ParentID = parentOf(2)
+			return nil
+		})
+		assert.True(t, errors.Is(err, ErrParentCycle))
+
+		_, err = repo.SwapTask(ctx, 3, func(task *models.TaskDomain) error {
+			task.ParentID = parentOf(1)
+			return nil
+		})
+		require.NoError(t, err)
+		children, err := repo.LoadChildren(ctx, 2)
+		require.NoError(t, err)
+		assert.Len(t, children, 0)
+	})
+
+	t.Run("Delete Reject", func(t *testing.T) {
+		repo := newTree(t)
+
+		_, err := repo.DeleteTask(ctx, 0, models.ChildrenReject, deletedAt, nil)
+		assert.True(t, errors.Is(err, ErrTaskHasChildren))
+		assert.Len(t, repo.storage, 4)
+	})
+
+	t.Run("Delete Cascade", func(t *testing.T) {
+		repo := newTree(t)
+		_, _ = repo.StoreTask(ctx, &models.TaskDomain{Header: "Unrelated"})
+
+		deleted, err := repo.DeleteTask(ctx, 0, models.ChildrenCascade, deletedAt, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []uint{1, 2, 3, 0}, deleted)
+		assert.Len(t, repo.storage, 1)
+		assert.Empty(t, repo.children)
+	})
+
+	t.Run("Delete Orphan", func(t *testing.T) {
+		repo := newTree(t)
+
+		_, err := repo.DeleteTask(ctx, 0, models.ChildrenOrphan, deletedAt, func(task *models.TaskDomain) error {
+			task.Description = "orphaned"
+			return nil
+		})
+		require.NoError(t, err)
+
+		roots, err := repo.QueryTasks(ctx, &models.TaskQuery{Tree: true})
+		require.NoError(t, err)
+		require.Len(t, roots, 2)
+		assert.Equal(t, "orphaned", roots[0].Description)
+		assert.Equal(t, uint64(2), roots[0].Version)
+		assert.Equal(t, uint(2), *repo.storage[3].ParentID)
+	})
+}
+
+func TestRepo_Dependencies(t *testing.T) {
+	ctx := context.Background()
+	noop := func(task *models.TaskDomain) error { return nil }
+
+	// 0 depends on 1, 1 depends on 2.
+	newChain := func(t *testing.T) *Repo {
+		repo := New()
+		for _, header := range []string{"Deploy", "Build", "Test"} {
+			_, err := repo.StoreTask(ctx, &models.TaskDomain{Header: header})
+			require.NoError(t, err)
+		}
+		_, err := repo.AddDependency(ctx, 0, 1, noop)
+		require.NoError(t, err)
+		_, err = repo.AddDependency(ctx, 1, 2, noop)
+		require.NoError(t, err)
+		return repo
+	}
+
+	t.Run("Add And Blocked", func(t *testing.T) {
+		repo := newChain(t)
+
+		task, err := repo.LoadTask(ctx, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []uint{1}, task.DependsOn)
+		assert.True(t, task.Blocked)
+		assert.Equal(t, uint64(2), task.Version)
+		assert.False(t, repo.storage[0].Blocked)
+
+		again, err := repo.AddDependency(ctx, 0, 1, noop)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2), again.Version)
+
+		_, err = repo.SwapTask(ctx, 1, func(task *models.TaskDomain) error {
+			task.Finished = true
+			return nil
+		})
+		require.NoError(t, err)
+		task, err = repo.LoadTask(ctx, 0)
+		require.NoError(t, err)
+		assert.False(t, task.Blocked)
+	})
+
+	t.Run("Cancelled Dependency Unblocks", func(t *testing.T) {
+		repo := newChain(t)
+
+		_, err := repo.SwapTask(ctx, 1, func(task *models.TaskDomain) error {
+			task.Status = models.StatusCancelled
+			return nil
+		})
+		require.NoError(t, err)
+		task, err := repo.LoadTask(ctx, 0)
+		require.NoError(t, err)
+		assert.False(t, task.Blocked)
+
+		cancelled, err := repo.LoadTask(ctx, 1)
+		require.NoError(t, err)
+		assert.False(t, cancelled.Blocked)
+	})
+
+	t.Run("Update Sees Blocked", func(t *testing.T) {
+		repo := newChain(t)
+
+		_, err := repo.SwapTask(ctx, 1, func(task *models.TaskDomain) error {
+			assert.True(t, task.Blocked)
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("Missing Dependency", func(t *testing.T) {
+		repo := newChain(t)
+
+		_, err := repo.AddDependency(ctx, 0, 99, noop)
+		assert.True(t, errors.Is(err, ErrDependencyNotFound))
+		_, err = repo.AddDependency(ctx, 99, 0, noop)
+		assert.True(t, errors.Is(err, ErrTaskNotFound))
+		_, err = repo.RemoveDependency(ctx, 0, 2, noop)
+		assert.True(t, errors.Is(err, ErrDependencyNotFound))
+	})
+
+	t.Run("Cycle", func(t *testing.T) {
+		repo := newChain(t)
+
+		_, err := repo.AddDependency(ctx, 2, 0, noop)
+		var cycleErr *DependencyCycleError
+		require.ErrorAs(t, err, &cycleErr)
+		assert.Equal(t, []uint{2, 0, 1, 2}, cycleErr.Path)
+		assert.Equal(t, "dependency cycle: 2 -> 0 -> 1 -> 2", cycleErr.Error())
+
+		_, err = repo.AddDependency(ctx, 1, 1, noop)
+		require.ErrorAs(t, err, &cycleErr)
+		assert.Equal(t, []uint{1, 1}, cycleErr.Path)
+
+		_, err = repo.AddDependency(ctx, 0, 2, noop)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		repo := newChain(t)
+
+		task, err := repo.RemoveDependency(ctx, 0, 1, noop)
+		require.NoError(t, err)
+		assert.Nil(t, task.DependsOn)
+		assert.False(t, task.Blocked)
+		assert.NotContains(t, repo.dependents, uint(1))
+	})
+
+	t.Run("Delete Drops Dependency", func(t *testing.T) {
+		repo := newChain(t)
+
+		var touched []uint
+		_, err := repo.DeleteTask(ctx, 1, models.ChildrenReject, deletedAt, func(task *models.TaskDomain) error {
+			touched = append(touched, task.ID)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []uint{0}, touched)
+
+		task, err := repo.LoadTask(ctx, 0)
+		require.NoError(t, err)
+		assert.Nil(t, task.DependsOn)
+		assert.Equal(t, uint64(3), task.Version)
+		assert.Empty(t, repo.dependents)
+	})
+}
+
+func TestRepo_Occurrences(t *testing.T) {
+	ctx := context.Background()
+	repo := New()
+	updatedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	touch := func(task *models.TaskDomain) error {
+		task.UpdatedAt = updatedAt
+		return nil
+	}
+
+	_, err := repo.StoreTask(ctx, &models.TaskDomain{Header: "Rotate logs", Status: models.StatusDone, Occurrence: 1})
+	require.NoError(t, err)
+
+	t.Run("Store And Link", func(t *testing.T) {
+		task, err := repo.StoreNextOccurrence(ctx, 0, &models.TaskDomain{Header: "Rotate logs", Occurrence: 2}, touch)
+		require.NoError(t, err)
+		require.NotNil(t, task.NextOccurrenceID)
+		assert.Equal(t, uint(1), *task.NextOccurrenceID)
+		assert.Equal(t, uint64(2), task.Version)
+		assert.Equal(t, updatedAt, task.UpdatedAt)
+
+		next, err := repo.LoadTask(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 2, next.Occurrence)
+		assert.Equal(t, uint64(1), next.Version)
+		require.NotNil(t, next.PreviousOccurrenceID)
+		assert.Equal(t, uint(0), *next.PreviousOccurrenceID)
+	})
+
+	t.Run("Never Forks", func(t *testing.T) {
+		task, err := repo.StoreNextOccurrence(ctx, 0, &models.TaskDomain{Header: "Rotate logs", Occurrence: 2}, touch)
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), *task.NextOccurrenceID)
+		assert.Equal(t, uint64(2), task.Version)
+
+		tasks, err := repo.QueryTasks(ctx, &models.TaskQuery{})
+		require.NoError(t, err)
+		assert.Len(t, tasks, 2)
+	})
+
+	t.Run("Missing Task", func(t *testing.T) {
+		_, err := repo.StoreNextOccurrence(ctx, 42, &models.TaskDomain{Header: "Rotate logs"}, touch)
+		assert.ErrorIs(t, err, ErrTaskNotFound)
+	})
+}
+
+func TestRepo_SearchTasks(t *testing.T) {
+	ctx := context.Background()
+	repo := New()
+
+	deployID, _ := repo.StoreTask(ctx, &models.TaskDomain{
+		Header:      "Деплой бэкенда",
+		Description: "Выкатить новую версию и проверить логи",
+	})
+	docsID, _ := repo.StoreTask(ctx, &models.TaskDomain{
+		Header:      "Документация",
+		Description: "Описать деплой в README",
+	})
+	_, _ = repo.StoreTask(ctx, &models.TaskDomain{Header: "Ёлка", Description: "Купить ёлку"})
+
+	t.Run("Ranking", func(t *testing.T) {
+		results, err := repo.SearchTasks(ctx, "ДЕПЛОЙ", 10)
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		assert.Equal(t, deployID, results[0].Task.ID)
+		assert.Equal(t, docsID, results[1].Task.ID)
+		assert.Greater(t, results[0].Score, results[1].Score)
+		assert.Equal(t, []models.Highlight{{Field: "header", Start: 0, End: 6}}, results[0].Highlights)
+		assert.Equal(t, []models.Highlight{{Field: "description", Start: 8, End: 14}}, results[1].Highlights)
+	})
+
+	t.Run("All Terms Required", func(t *testing.T) {
+		results, err := repo.SearchTasks(ctx, "деплой логи", 10)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, deployID, results[0].Task.ID)
+	})
+
+	t.Run("Yo Folding", func(t *testing.T) {
+		results, err := repo.SearchTasks(ctx, "елку", 10)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "Ёлка", results[0].Task.Header)
+	})
+
+	t.Run("Index Follows Updates", func(t *testing.T) {
+		_, err := repo.SwapTask(ctx, docsID, replaceWith(&models.TaskDomain{Header: "Документация"}))
+		require.NoError(t, err)
+		_, err = repo.DeleteTask(ctx, deployID, models.ChildrenReject, deletedAt, nil)
+		require.NoError(t, err)
+
+		results, err := repo.SearchTasks(ctx, "деплой", 10)
+		require.NoError(t, err)
+		assert.Len(t, results, 0)
+
+		results, err = repo.SearchTasks(ctx, "документация", 10)
+		require.NoError(t, err)
+		assert.Len(t, results, 1)
 	})
 }
 
@@ -195,21 +797,22 @@ func TestRepo_SwapTask(t *testing.T) {
 	t.Run("Update Existing Task", func(t *testing.T) {
 		repo := New()
 
-		originalTask := &models.TaskDTO{
+		originalTask := &models.TaskDomain{
 			Header:      "Original Task",
 			Description: "Original Description",
 			Finished:    false,
 		}
 		taskID, _ := repo.StoreTask(ctx, originalTask)
 
-		updatedTask := &models.TaskDTO{
+		updatedTask := &models.TaskDomain{
 			Header:      "Updated Task",
 			Description: "Updated Description",
 			Finished:    true,
 		}
 
-		err := repo.SwapTask(ctx, taskID, updatedTask)
+		swapped, err := repo.SwapTask(ctx, taskID, replaceWith(updatedTask))
 		assert.NoError(t, err)
+		assert.Equal(t, uint64(2), swapped.Version)
 
 		loadedTask, err := repo.LoadTask(ctx, taskID)
 		assert.NoError(t, err)
@@ -218,24 +821,184 @@ func TestRepo_SwapTask(t *testing.T) {
 		assert.Equal(t, updatedTask.Finished, loadedTask.Finished)
 	})
 
+	t.Run("Update Error Aborts Swap", func(t *testing.T) {
+		repo := New()
+		taskID, _ := repo.StoreTask(ctx, &models.TaskDomain{Header: "Original Task"})
+
+		_, err := repo.SwapTask(ctx, taskID, func(task *models.TaskDomain) error {
+			task.Header = "Discarded"
+			return assert.AnError
+		})
+		assert.True(t, errors.Is(err, assert.AnError))
+
+		loadedTask, err := repo.LoadTask(ctx, taskID)
+		require.NoError(t, err)
+		assert.Equal(t, "Original Task", loadedTask.Header)
+		assert.Equal(t, uint64(1), loadedTask.Version)
+	})
+
+	t.Run("Non-existent Task", func(t *testing.T) {
+		repo := New()
+
+		_, err := repo.SwapTask(ctx, uint(42), replaceWith(&models.TaskDomain{Header: "New Task"}))
+		assert.True(t, errors.Is(err, ErrTaskNotFound))
+
+		_, err = repo.LoadTask(ctx, uint(42))
+		assert.True(t, errors.Is(err, ErrTaskNotFound))
+	})
+}
+
+func TestRepo_SwapReminders(t *testing.T) {
+	ctx := context.Background()
+	hour := models.Duration(time.Hour)
+	updatedAt := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	firedAt := updatedAt.Add(time.Hour)
+
+	t.Run("Version Unchanged", func(t *testing.T) {
+		repo := New()
+		taskID, _ := repo.StoreTask(ctx, &models.TaskDomain{
+			Header:    "Standup",
+			UpdatedAt: updatedAt,
+			Reminders: []models.Reminder{{Before: hour}},
+		})
+
+		err := repo.SwapReminders(ctx, taskID, func(task *models.TaskDomain) error {
+			task.Header = "Discarded"
+			task.Reminders[0].FiredAt = &firedAt
+			return nil
+		})
+		require.NoError(t, err)
+
+		task, err := repo.LoadTask(ctx, taskID)
+		require.NoError(t, err)
+		assert.Equal(t, "Standup", task.Header)
+		assert.Equal(t, uint64(1), task.Version)
+		assert.Equal(t, updatedAt, task.UpdatedAt)
+		assert.Equal(t, []models.Reminder{{Before: hour, FiredAt: &firedAt}}, task.Reminders)
+	})
+
+	t.Run("Non-existent Task", func(t *testing.T) {
+		repo := New()
+
+		err := repo.SwapReminders(ctx, uint(42), func(task *models.TaskDomain) error { return nil })
+		assert.True(t, errors.Is(err, ErrTaskNotFound))
+	})
+}
+
+func TestRepo_UpsertTask(t *testing.T) {
+	ctx := context.Background()
+
 	t.Run("Create New Task", func(t *testing.T) {
 		repo := New()
 
-		newTask := &models.TaskDTO{
+		newTask := &models.TaskDomain{
 			Header:      "New Task",
 			Description: "New Description",
 			Finished:    false,
 		}
 		newID := uint(42)
 
-		err := repo.SwapTask(ctx, newID, newTask)
+		_, created, err := repo.UpsertTask(ctx, newID, replaceWith(newTask))
 		assert.NoError(t, err)
+		assert.True(t, created)
 
 		loadedTask, err := repo.LoadTask(ctx, newID)
 		assert.NoError(t, err)
 		assert.Equal(t, newTask.Header, loadedTask.Header)
 		assert.Equal(t, newTask.Description, loadedTask.Description)
 		assert.Equal(t, newTask.Finished, loadedTask.Finished)
+		assert.Equal(t, uint64(1), loadedTask.Version)
+	})
+
+	t.Run("Replace Existing Task", func(t *testing.T) {
+		repo := New()
+		taskID, _ := repo.StoreTask(ctx, &models.TaskDomain{Header: "Original Task"})
+
+		_, created, err := repo.UpsertTask(ctx, taskID, replaceWith(&models.TaskDomain{Header: "Updated Task"}))
+		assert.NoError(t, err)
+		assert.False(t, created)
+
+		loadedTask, err := repo.LoadTask(ctx, taskID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Updated Task", loadedTask.Header)
+		assert.Equal(t, uint64(2), loadedTask.Version)
+	})
+
+	t.Run("No ID Collision", func(t *testing.T) {
+		repo := New()
+
+		_, _, err := repo.UpsertTask(ctx, 2, replaceWith(&models.TaskDomain{Header: "Upserted Task"}))
+		require.NoError(t, err)
+		_, err = repo.DeleteTask(ctx, 2, models.ChildrenReject, deletedAt, nil)
+		require.NoError(t, err)
+
+		for i := 0; i < 5; i++ {
+			taskID, err := repo.StoreTask(ctx, &models.TaskDomain{Header: fmt.Sprintf("Task %d", i)})
+			require.NoError(t, err)
+			assert.NotEqual(t, uint(2), taskID)
+		}
+	})
+
+	t.Run("Largest ID Refused", func(t *testing.T) {
+		repo := New()
+
+		_, _, err := repo.UpsertTask(ctx, math.MaxUint, replaceWith(&models.TaskDomain{Header: "Upserted Task"}))
+		assert.ErrorIs(t, err, ErrTaskIDOutOfRange)
+
+		taskID, err := repo.StoreTask(ctx, &models.TaskDomain{Header: "New Task"})
+		require.NoError(t, err)
+		assert.Equal(t, uint(0), taskID)
+	})
+
+	t.Run("Counter Never Moves Back", func(t *testing.T) {
+		repo := New()
+
+		_, _, err := repo.UpsertTask(ctx, 10, replaceWith(&models.TaskDomain{Header: "Upserted Task"}))
+		require.NoError(t, err)
+		require.NoError(t, repo.apply(change{Op: opDelete, ID: 3, NextID: 4}))
+
+		taskID, err := repo.StoreTask(ctx, &models.TaskDomain{Header: "New Task"})
+		require.NoError(t, err)
+		assert.Equal(t, uint(11), taskID)
+	})
+
+	t.Run("Skip Trashed Slot", func(t *testing.T) {
+		repo := New()
+		repo.trash[0] = &models.TaskDomain{ID: 0, Header: "Trashed Task", Version: 1, DeletedAt: &deletedAt}
+
+		taskID, err := repo.StoreTask(ctx, &models.TaskDomain{Header: "New Task"})
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), taskID)
+
+		trash, err := repo.LoadTrash(ctx)
+		require.NoError(t, err)
+		require.Len(t, trash, 1)
+		assert.Equal(t, "Trashed Task", trash[0].Header)
+	})
+
+	t.Run("Put Never Replaces Trashed Task", func(t *testing.T) {
+		repo := New()
+		repo.trash[5] = &models.TaskDomain{ID: 5, Header: "Trashed Task", Version: 1, DeletedAt: &deletedAt}
+
+		err := repo.apply(change{Op: opPut, ID: 5, Task: &models.TaskDomain{Header: "New Task"}, NextID: 6})
+		assert.ErrorIs(t, err, ErrTaskTrashed)
+		assert.Contains(t, repo.trash, uint(5))
+		_, err = repo.LoadTask(ctx, 5)
+		assert.ErrorIs(t, err, ErrTaskNotFound)
+	})
+
+	t.Run("Skip Occupied Slot", func(t *testing.T) {
+		repo := New()
+		repo.storage[0] = &models.TaskDomain{ID: 0, Header: "Legacy Task", Version: 1}
+		repo.reindex()
+
+		taskID, err := repo.StoreTask(ctx, &models.TaskDomain{Header: "New Task"})
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), taskID)
+
+		loadedTask, err := repo.LoadTask(ctx, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "Legacy Task", loadedTask.Header)
 	})
 }
 
@@ -245,14 +1008,14 @@ func TestRepo_DeleteTask(t *testing.T) {
 	t.Run("Delete Existing Task", func(t *testing.T) {
 		repo := New()
 
-		task := &models.TaskDTO{
+		task := &models.TaskDomain{
 			Header:      "Task to Delete",
 			Description: "Description",
 			Finished:    false,
 		}
 		taskID, _ := repo.StoreTask(ctx, task)
 
-		err := repo.DeleteTask(ctx, taskID)
+		_, err := repo.DeleteTask(ctx, taskID, models.ChildrenReject, deletedAt, nil)
 		assert.NoError(t, err)
 
 		loadedTask, err := repo.LoadTask(ctx, taskID)
@@ -264,8 +1027,373 @@ func TestRepo_DeleteTask(t *testing.T) {
 	t.Run("Delete Non-existent Task", func(t *testing.T) {
 		repo := New()
 
-		err := repo.DeleteTask(ctx, uint(999))
-		assert.NoError(t, err)
+		_, err := repo.DeleteTask(ctx, uint(999), models.ChildrenReject, deletedAt, nil)
+		assert.True(t, errors.Is(err, ErrTaskNotFound))
+	})
+}
+
+func TestRepo_CompareAndSwapTask(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Matching Version", func(t *testing.T) {
+		repo := New()
+		taskID, _ := repo.StoreTask(ctx, &models.TaskDomain{Header: "Original Task"})
+
+		swapped, err := repo.CompareAndSwapTask(ctx, taskID, 1, replaceWith(&models.TaskDomain{Header: "Updated Task"}))
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2), swapped.Version)
+
+		loadedTask, err := repo.LoadTask(ctx, taskID)
+		require.NoError(t, err)
+		assert.Equal(t, "Updated Task", loadedTask.Header)
+		assert.Equal(t, uint64(2), loadedTask.Version)
+	})
+
+	t.Run("Stale Version", func(t *testing.T) {
+		repo := New()
+		taskID, _ := repo.StoreTask(ctx, &models.TaskDomain{Header: "Original Task"})
+		_, err := repo.SwapTask(ctx, taskID, replaceWith(&models.TaskDomain{Header: "Concurrent Update"}))
+		require.NoError(t, err)
+
+		_, err = repo.CompareAndSwapTask(ctx, taskID, 1, replaceWith(&models.TaskDomain{Header: "Lost Update"}))
+		assert.True(t, errors.Is(err, ErrVersionMismatch))
+
+		loadedTask, err := repo.LoadTask(ctx, taskID)
+		require.NoError(t, err)
+		assert.Equal(t, "Concurrent Update", loadedTask.Header)
+	})
+
+	t.Run("Non-existent Task", func(t *testing.T) {
+		repo := New()
+
+		_, err := repo.CompareAndSwapTask(ctx, uint(999), 1, replaceWith(&models.TaskDomain{Header: "Task"}))
+		assert.True(t, errors.Is(err, ErrTaskNotFound))
+	})
+}
+
+func TestRepo_CompareAndDeleteTask(t *testing.T) {
+	ctx := context.Background()
+	repo := New()
+	taskID, _ := repo.StoreTask(ctx, &models.TaskDomain{Header: "Task"})
+
+	_, err := repo.CompareAndDeleteTask(ctx, taskID, 2, models.ChildrenReject, deletedAt, nil)
+	assert.True(t, errors.Is(err, ErrVersionMismatch))
+
+	_, err = repo.CompareAndDeleteTask(ctx, taskID, 1, models.ChildrenReject, deletedAt, nil)
+	require.NoError(t, err)
+
+	_, err = repo.LoadTask(ctx, taskID)
+	assert.True(t, errors.Is(err, ErrTaskNotFound))
+}
+
+func TestRepo_Trash(t *testing.T) {
+	ctx := context.Background()
+	parentOf := func(taskID uint) *uint { return &taskID }
+	ids := func(tasks []*models.TaskDomain) []uint {
+		result := []uint{}
+		for _, task := range tasks {
+			result = append(result, task.ID)
+		}
+		return result
+	}
+
+	// newTrashed stores Release (0) with Build (1) and Deploy (2) under it and
+	// an unrelated Docs (3) that depends on Deploy, then trashes Release with
+	// its children.
+	newTrashed := func(t *testing.T) *Repo {
+		repo := New()
+		for _, task := range []*models.TaskDomain{
+			{Header: "Release", Tags: []string{"ops"}},
+			{Header: "Build", ParentID: parentOf(0)},
+			{Header: "Deploy", ParentID: parentOf(0)},
+			{Header: "Docs"},
+		} {
+			_, err := repo.StoreTask(ctx, task)
+			require.NoError(t, err)
+		}
+		_, err := repo.AddDependency(ctx, 3, 2, func(task *models.TaskDomain) error { return nil })
+		require.NoError(t, err)
+		_, err = repo.DeleteTask(ctx, 0, models.ChildrenCascade, deletedAt, nil)
+		require.NoError(t, err)
+		return repo
+	}
+
+	t.Run("Delete Hides Tasks", func(t *testing.T) {
+		repo := newTrashed(t)
+
+		_, err := repo.LoadTask(ctx, 0)
+		assert.True(t, errors.Is(err, ErrTaskNotFound))
+		all, err := repo.QueryTasks(ctx, &models.TaskQuery{})
+		require.NoError(t, err)
+		assert.Equal(t, []uint{3}, ids(all))
+		tags, err := repo.LoadTags(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, tags)
+		docs, err := repo.LoadTask(ctx, 3)
+		require.NoError(t, err)
+		assert.Empty(t, docs.DependsOn)
+
+		trash, err := repo.LoadTrash(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []uint{0, 1, 2}, ids(trash))
+		assert.Equal(t, deletedAt, *trash[0].DeletedAt)
+	})
+
+	t.Run("Upsert Rejects Trashed ID", func(t *testing.T) {
+		repo := newTrashed(t)
+
+		_, _, err := repo.UpsertTask(ctx, 0, replaceWith(&models.TaskDomain{Header: "Again"}))
+		assert.True(t, errors.Is(err, ErrTaskTrashed))
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		repo := newTrashed(t)
+		later := deletedAt.Add(time.Hour)
+		_, err := repo.StoreTask(ctx, &models.TaskDomain{Header: "Extra", ParentID: parentOf(3)})
+		require.NoError(t, err)
+		_, err = repo.DeleteTask(ctx, 4, models.ChildrenReject, later, nil)
+		require.NoError(t, err)
+
+		_, err = repo.RestoreTask(ctx, 1, func(task *models.TaskDomain) error { return nil })
+		assert.True(t, errors.Is(err, ErrParentNotFound), "the parent is still in the trash")
+
+		var touched []uint
+		restored, err := repo.RestoreTask(ctx, 0, func(task *models.TaskDomain) error {
+			touched = append(touched, task.ID)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []uint{0, 1, 2}, restored)
+		assert.Equal(t, []uint{0, 1, 2}, touched)
+
+		release, err := repo.LoadTask(ctx, 0)
+		require.NoError(t, err)
+		assert.Nil(t, release.DeletedAt)
+		assert.Equal(t, uint64(2), release.Version)
+		assert.Equal(t, &models.ChildCount{Total: 2}, release.Children)
+		tasks, err := repo.QueryTasks(ctx, &models.TaskQuery{Tags: []string{"ops"}})
+		require.NoError(t, err)
+		assert.Equal(t, []uint{0}, ids(tasks))
+
+		trash, err := repo.LoadTrash(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []uint{4}, ids(trash), "tasks trashed on their own stay")
+
+		_, err = repo.RestoreTask(ctx, 0, func(task *models.TaskDomain) error { return nil })
+		assert.True(t, errors.Is(err, ErrTaskNotFound))
+	})
+
+	t.Run("Restore Leaves Tasks Trashed On Their Own", func(t *testing.T) {
+		repo := New()
+		for _, task := range []*models.TaskDomain{{Header: "Release"}, {Header: "Build", ParentID: parentOf(0)}} {
+			_, err := repo.StoreTask(ctx, task)
+			require.NoError(t, err)
+		}
+		_, err := repo.DeleteTask(ctx, 1, models.ChildrenReject, deletedAt, nil)
+		require.NoError(t, err)
+		_, err = repo.DeleteTask(ctx, 0, models.ChildrenCascade, deletedAt, nil)
+		require.NoError(t, err)
+
+		restored, err := repo.RestoreTask(ctx, 0, func(task *models.TaskDomain) error { return nil })
+		require.NoError(t, err)
+		assert.Equal(t, []uint{0}, restored)
+		trash, err := repo.LoadTrash(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []uint{1}, ids(trash))
+	})
+
+	t.Run("Restore Drops Missing Dependencies", func(t *testing.T) {
+		repo := New()
+		for _, header := range []string{"A", "B"} {
+			_, err := repo.StoreTask(ctx, &models.TaskDomain{Header: header})
+			require.NoError(t, err)
+		}
+		_, err := repo.AddDependency(ctx, 0, 1, func(task *models.TaskDomain) error { return nil })
+		require.NoError(t, err)
+		_, err = repo.DeleteTask(ctx, 0, models.ChildrenReject, deletedAt, nil)
+		require.NoError(t, err)
+		_, err = repo.DeleteTask(ctx, 1, models.ChildrenReject, deletedAt.Add(time.Minute), nil)
+		require.NoError(t, err)
+
+		_, err = repo.RestoreTask(ctx, 0, func(task *models.TaskDomain) error { return nil })
+		require.NoError(t, err)
+		task, err := repo.LoadTask(ctx, 0)
+		require.NoError(t, err)
+		assert.Nil(t, task.DependsOn)
+		assert.False(t, task.Blocked)
+	})
+
+	t.Run("Purge", func(t *testing.T) {
+		repo := newTrashed(t)
+
+		_, err := repo.PurgeTask(ctx, 3)
+		assert.True(t, errors.Is(err, ErrTaskNotFound), "live tasks are not purged")
+
+		purged, err := repo.PurgeTask(ctx, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []uint{0, 1, 2}, purged)
+		assert.Empty(t, repo.trash)
+
+		_, err = repo.RestoreTask(ctx, 0, func(task *models.TaskDomain) error { return nil })
+		assert.True(t, errors.Is(err, ErrTaskNotFound))
+	})
+
+	t.Run("Purge Takes Trashed Descendants", func(t *testing.T) {
+		repo := New()
+		for _, task := range []*models.TaskDomain{
+			{Header: "Release"},
+			{Header: "Build", ParentID: parentOf(0)},
+			{Header: "Compile", ParentID: parentOf(1)},
+			{Header: "Link", ParentID: parentOf(1)},
+		} {
+			_, err := repo.StoreTask(ctx, task)
+			require.NoError(t, err)
+		}
+		_, err := repo.DeleteTask(ctx, 3, models.ChildrenReject, deletedAt, nil)
+		require.NoError(t, err)
+		_, err = repo.DeleteTask(ctx, 0, models.ChildrenCascade, deletedAt.Add(time.Minute), nil)
+		require.NoError(t, err)
+
+		purged, err := repo.PurgeTask(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []uint{1, 2, 3}, purged)
+
+		restored, err := repo.RestoreTask(ctx, 0, func(task *models.TaskDomain) error { return nil })
+		require.NoError(t, err)
+		assert.Equal(t, []uint{0}, restored)
+		assert.Empty(t, repo.trash)
+	})
+
+	t.Run("Purge Trash", func(t *testing.T) {
+		repo := newTrashed(t)
+		_, err := repo.DeleteTask(ctx, 3, models.ChildrenReject, deletedAt.Add(time.Hour), nil)
+		require.NoError(t, err)
+
+		purged, err := repo.PurgeTrash(ctx, deletedAt)
+		require.NoError(t, err)
+		assert.Empty(t, purged)
+
+		purged, err = repo.PurgeTrash(ctx, deletedAt.Add(time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, []uint{0, 1, 2}, purged)
+		trash, err := repo.LoadTrash(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []uint{3}, ids(trash))
+	})
+}
+
+func TestRepo_Atomically(t *testing.T) {
+	ctx := context.Background()
+	parentOf := func(taskID uint) *uint { return &taskID }
+
+	newRepo := func(t *testing.T) *Repo {
+		repo := New()
+		for _, task := range []*models.TaskDomain{
+			{Header: "Release", Tags: []string{"ops"}},
+			{Header: "Build", ParentID: parentOf(0)},
+			{Header: "Docs"},
+		} {
+			_, err := repo.StoreTask(ctx, task)
+			require.NoError(t, err)
+		}
+		_, err := repo.DeleteTask(ctx, 2, models.ChildrenReject, deletedAt, nil)
+		require.NoError(t, err)
+		return repo
+	}
+
+	// write creates Deploy under Release, renames Release and trashes Build
+	// through tx.
+	write := func(t *testing.T, ctx context.Context, tx *Tx) {
+		taskID, err := tx.StoreTask(ctx, &models.TaskDomain{Header: "Deploy", ParentID: parentOf(0)})
+		require.NoError(t, err)
+		assert.Equal(t, uint(3), taskID)
+		task, err := tx.LoadTask(ctx, taskID)
+		require.NoError(t, err)
+		assert.Equal(t, "Deploy", task.Header, "the transaction sees its own writes")
+
+		_, err = tx.CompareAndSwapTask(ctx, 0, 1, func(task *models.TaskDomain) error {
+			task.Header = "Release 2"
+			task.Tags = []string{"platform"}
+			return nil
+		})
+		require.NoError(t, err)
+		_, err = tx.CompareAndSwapTask(ctx, 0, 1, replaceWith(&models.TaskDomain{}))
+		assert.True(t, errors.Is(err, ErrVersionMismatch))
+
+		deleted, err := tx.DeleteTask(ctx, 1, models.ChildrenReject, deletedAt, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []uint{1}, deleted)
+
+		created, updated, deleted := tx.Written()
+		assert.Equal(t, []uint{3}, created)
+		assert.Equal(t, []uint{0}, updated)
+		assert.Equal(t, []uint{1}, deleted)
+	}
+
+	t.Run("Commit", func(t *testing.T) {
+		repo := newRepo(t)
+
+		err := repo.Atomically(ctx, func(tx *Tx) error {
+			write(t, ctx, tx)
+			return nil
+		})
+		require.NoError(t, err)
+
+		release, err := repo.LoadTask(ctx, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "Release 2", release.Header)
+		assert.Equal(t, &models.ChildCount{Total: 1}, release.Children)
+		trash, err := repo.LoadTrash(ctx)
+		require.NoError(t, err)
+		assert.Len(t, trash, 2)
+		taskID, err := repo.StoreTask(ctx, &models.TaskDomain{Header: "Next"})
+		require.NoError(t, err)
+		assert.Equal(t, uint(4), taskID)
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		repo := newRepo(t)
+		before, err := repo.QueryTasks(ctx, &models.TaskQuery{})
+		require.NoError(t, err)
+
+		err = repo.Atomically(ctx, func(tx *Tx) error {
+			write(t, ctx, tx)
+			_, err := tx.SwapTask(ctx, 2, replaceWith(&models.TaskDomain{Header: "Trashed"}))
+			return err
+		})
+		assert.True(t, errors.Is(err, ErrTaskNotFound))
+
+		after, err := repo.QueryTasks(ctx, &models.TaskQuery{})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, before, after)
+		tags, err := repo.LoadTags(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []*models.TagCount{{Name: "ops", Count: 1}}, tags)
+		results, err := repo.SearchTasks(ctx, "deploy", 10)
+		require.NoError(t, err)
+		assert.Empty(t, results)
+		trash, err := repo.LoadTrash(ctx)
+		require.NoError(t, err)
+		assert.Len(t, trash, 1)
+
+		taskID, err := repo.StoreTask(ctx, &models.TaskDomain{Header: "Next"})
+		require.NoError(t, err)
+		assert.Equal(t, uint(3), taskID, "the ID counter is rolled back too")
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		repo := newRepo(t)
+
+		assert.Panics(t, func() {
+			_ = repo.Atomically(ctx, func(tx *Tx) error {
+				_, err := tx.StoreTask(ctx, &models.TaskDomain{Header: "Deploy"})
+				require.NoError(t, err)
+				panic("boom")
+			})
+		})
+
+		_, err := repo.LoadTask(ctx, 3)
+		assert.True(t, errors.Is(err, ErrTaskNotFound))
 	})
 }
 
@@ -273,7 +1401,7 @@ func TestRepo_Integration(t *testing.T) {
 	ctx := context.Background()
 	repo := New()
 
-	createTask := &models.TaskDTO{
+	createTask := &models.TaskDomain{
 		Header:      "Integration Task",
 		Description: "Integration Description",
 		Finished:    false,
@@ -287,12 +1415,12 @@ func TestRepo_Integration(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, createTask.Header, loadedTask.Header)
 
-	updatedTask := &models.TaskDTO{
+	updatedTask := &models.TaskDomain{
 		Header:      "Updated Integration Task",
 		Description: "Updated Integration Description",
 		Finished:    true,
 	}
-	err = repo.SwapTask(ctx, taskID, updatedTask)
+	_, err = repo.SwapTask(ctx, taskID, replaceWith(updatedTask))
 	require.NoError(t, err)
 
 	loadedUpdatedTask, err := repo.LoadTask(ctx, taskID)
@@ -300,7 +1428,7 @@ func TestRepo_Integration(t *testing.T) {
 	assert.Equal(t, updatedTask.Header, loadedUpdatedTask.Header)
 	assert.Equal(t, updatedTask.Finished, loadedUpdatedTask.Finished)
 
-	err = repo.DeleteTask(ctx, taskID)
+	_, err = repo.DeleteTask(ctx, taskID, models.ChildrenReject, deletedAt, nil)
 	require.NoError(t, err)
 
 	_, err = repo.LoadTask(ctx, taskID)
@@ -323,7 +1451,7 @@ func TestRepo_ThreadSafety(t *testing.T) {
 			defer wg.Done()
 
 			for j := 0; j < numOperations; j++ {
-				task := &models.TaskDTO{
+				task := &models.TaskDomain{
 					Header:      fmt.Sprintf("Goroutine %d Task %d", goroutineID, j),
 					Description: fmt.Sprintf("Description from goroutine %d", goroutineID),
 					Finished:    j%2 == 0,
@@ -345,7 +1473,7 @@ func TestRepo_ThreadSafety(t *testing.T) {
 
 	wg.Wait()
 
-	allTasks, err := repo.LoadAllTasks(ctx)
+	allTasks, err := repo.QueryTasks(ctx, &models.TaskQuery{})
 	require.NoError(t, err)
 
 	expectedCount := numGoroutines * numOperations
@@ -369,3 +1497,280 @@ func TestRepo_ThreadSafety(t *testing.T) {
 		assert.Contains(t, taskMap, i, "Task with ID %d should exist", i)
 	}
 }
+
+func TestFileRepo_Revisions(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	repo, err := NewFile(dir, 0)
+	require.NoError(t, err)
+
+	for _, version := range []uint64{1, 1, 2} {
+		require.NoError(t, repo.StoreRevision(ctx, &models.Revision{
+			TaskID: 4,
+			Op:     models.RevisionUpdated,
+			Task:   &models.TaskDomain{Header: "Deploy", Version: version},
+			Actor:  "alice",
+		}))
+	}
+	require.NoError(t, repo.Snapshot())
+	require.NoError(t, repo.StoreRevision(ctx, &models.Revision{TaskID: 4, Op: models.RevisionDeleted}))
+	require.NoError(t, repo.wal.Close())
+
+	reopened, err := NewFile(dir, 0)
+	require.NoError(t, err)
+	defer reopened.Close()
+
+	revisions, err := reopened.LoadRevisions(ctx, 4)
+	require.NoError(t, err)
+	require.Len(t, revisions, 3, "the repeated version is dropped")
+	for i, revision := range revisions {
+		assert.Equal(t, i+1, revision.Number)
+	}
+	assert.Equal(t, "alice", revisions[1].Actor)
+	assert.Equal(t, models.RevisionDeleted, revisions[2].Op)
+
+	_, err = reopened.LoadRevisions(ctx, 5)
+	assert.ErrorIs(t, err, ErrTaskNotFound)
+}
+
+func TestFileRepo_Persistence(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Replay WAL", func(t *testing.T) {
+		dir := t.TempDir()
+		repo, err := NewFile(dir, 0)
+		require.NoError(t, err)
+
+		id1, err := repo.StoreTask(ctx, &models.TaskDomain{Header: "Task 1", Description: "Desc 1"})
+		require.NoError(t, err)
+		id2, err := repo.StoreTask(ctx, &models.TaskDomain{Header: "Task 2"})
+		require.NoError(t, err)
+		_, err = repo.SwapTask(ctx, id1, replaceWith(&models.TaskDomain{Header: "Task 1", Finished: true}))
+		require.NoError(t, err)
+		_, err = repo.DeleteTask(ctx, id2, models.ChildrenReject, deletedAt, nil)
+		require.NoError(t, err)
+		require.NoError(t, repo.wal.Close())
+
+		reopened, err := NewFile(dir, 0)
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		loadedTask, err := reopened.LoadTask(ctx, id1)
+		require.NoError(t, err)
+		assert.Equal(t, "Task 1", loadedTask.Header)
+		assert.True(t, loadedTask.Finished)
+
+		_, err = reopened.LoadTask(ctx, id2)
+		assert.True(t, errors.Is(err, ErrTaskNotFound))
+
+		id3, err := reopened.StoreTask(ctx, &models.TaskDomain{Header: "Task 3"})
+		require.NoError(t, err)
+		assert.Equal(t, uint(2), id3)
+	})
+
+	t.Run("Trash Survives Restart", func(t *testing.T) {
+		dir := t.TempDir()
+		repo, err := NewFile(dir, 0)
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			_, err := repo.StoreTask(ctx, &models.TaskDomain{Header: fmt.Sprintf("Task %d", i)})
+			require.NoError(t, err)
+		}
+		_, err = repo.DeleteTask(ctx, 0, models.ChildrenReject, deletedAt, nil)
+		require.NoError(t, err)
+		require.NoError(t, repo.Snapshot())
+		_, err = repo.DeleteTask(ctx, 1, models.ChildrenReject, deletedAt, nil)
+		require.NoError(t, err)
+		_, err = repo.PurgeTask(ctx, 0)
+		require.NoError(t, err)
+		require.NoError(t, repo.wal.Close())
+
+		reopened, err := NewFile(dir, 0)
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		trash, err := reopened.LoadTrash(ctx)
+		require.NoError(t, err)
+		require.Len(t, trash, 1)
+		assert.Equal(t, uint(1), trash[0].ID)
+		assert.Equal(t, deletedAt, *trash[0].DeletedAt)
+
+		_, err = reopened.RestoreTask(ctx, 1, func(task *models.TaskDomain) error { return nil })
+		require.NoError(t, err)
+		tasks, err := reopened.QueryTasks(ctx, &models.TaskQuery{})
+		require.NoError(t, err)
+		assert.Len(t, tasks, 2)
+	})
+
+	t.Run("Replay Batch", func(t *testing.T) {
+		dir := t.TempDir()
+		repo, err := NewFile(dir, 0)
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			_, err := repo.StoreTask(ctx, &models.TaskDomain{Header: fmt.Sprintf("Task %d", i), Tags: []string{"ops"}})
+			require.NoError(t, err)
+		}
+		_, err = repo.SwapTaggedTasks(ctx, "ops", func(task *models.TaskDomain) error {
+			task.Tags = []string{"platform"}
+			return nil
+		})
+		require.NoError(t, err)
+		require.NoError(t, repo.wal.Close())
+
+		reopened, err := NewFile(dir, 0)
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		tags, err := reopened.LoadTags(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []*models.TagCount{{Name: "platform", Count: 3}}, tags)
+	})
+
+	t.Run("Replay Transaction", func(t *testing.T) {
+		dir := t.TempDir()
+		repo, err := NewFile(dir, 0)
+		require.NoError(t, err)
+
+		err = repo.Atomically(ctx, func(tx *Tx) error {
+			for i := 0; i < 3; i++ {
+				if _, err := tx.StoreTask(ctx, &models.TaskDomain{Header: fmt.Sprintf("Task %d", i)}); err != nil {
+					return err
+				}
+			}
+			_, err := tx.DeleteTask(ctx, 1, models.ChildrenReject, deletedAt, nil)
+			return err
+		})
+		require.NoError(t, err)
+		err = repo.Atomically(ctx, func(tx *Tx) error {
+			if _, err := tx.StoreTask(ctx, &models.TaskDomain{Header: "Lost"}); err != nil {
+				return err
+			}
+			return assert.AnError
+		})
+		require.ErrorIs(t, err, assert.AnError)
+		require.NoError(t, repo.wal.Close())
+
+		reopened, err := NewFile(dir, 0)
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		tasks, err := reopened.QueryTasks(ctx, &models.TaskQuery{})
+		require.NoError(t, err)
+		assert.Len(t, tasks, 2)
+		trash, err := reopened.LoadTrash(ctx)
+		require.NoError(t, err)
+		assert.Len(t, trash, 1)
+		taskID, err := reopened.StoreTask(ctx, &models.TaskDomain{Header: "Next"})
+		require.NoError(t, err)
+		assert.Equal(t, uint(3), taskID)
+	})
+
+	t.Run("Snapshot", func(t *testing.T) {
+		dir := t.TempDir()
+		repo, err := NewFile(dir, 0)
+		require.NoError(t, err)
+
+		for i := 0; i < 5; i++ {
+			_, err := repo.StoreTask(ctx, &models.TaskDomain{Header: fmt.Sprintf("Task %d", i)})
+			require.NoError(t, err)
+		}
+		_, err = repo.DeleteTask(ctx, 4, models.ChildrenReject, deletedAt, nil)
+		require.NoError(t, err)
+		require.NoError(t, repo.Close())
+
+		info, err := os.Stat(filepath.Join(dir, walFileName))
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), info.Size())
+
+		reopened, err := NewFile(dir, 0)
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		tasks, err := reopened.QueryTasks(ctx, &models.TaskQuery{})
+		require.NoError(t, err)
+		assert.Len(t, tasks, 4)
+
+		tasks, err = reopened.QueryTasks(ctx, &models.TaskQuery{})
+		require.NoError(t, err)
+		assert.Len(t, tasks, 4)
+
+		id, err := reopened.StoreTask(ctx, &models.TaskDomain{Header: "Task 5"})
+		require.NoError(t, err)
+		assert.Equal(t, uint(5), id)
+	})
+
+	t.Run("Fired Reminders", func(t *testing.T) {
+		dir := t.TempDir()
+		repo, err := NewFile(dir, 0)
+		require.NoError(t, err)
+
+		dueAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		firedAt := dueAt.Add(-time.Hour)
+		_, err = repo.StoreTask(ctx, &models.TaskDomain{
+			Header: "Standup",
+			DueAt:  &dueAt,
+			Reminders: []models.Reminder{
+				{Before: models.Duration(time.Hour), FiredAt: &firedAt},
+				{Before: models.Duration(10 * time.Minute)},
+			},
+		})
+		require.NoError(t, err)
+		require.NoError(t, repo.wal.Close())
+
+		reopened, err := NewFile(dir, 0)
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		task, err := reopened.LoadTask(ctx, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []models.Reminder{
+			{Before: models.Duration(time.Hour), FiredAt: &firedAt},
+			{Before: models.Duration(10 * time.Minute)},
+		}, task.Reminders)
+	})
+
+	t.Run("Legacy Status", func(t *testing.T) {
+		dir := t.TempDir()
+		legacy := `{"next_id":2,"tasks":{"0":{"id":0,"header":"Open","finished":false},` +
+			`"1":{"id":1,"header":"Closed","finished":true}}}`
+		require.NoError(t, os.WriteFile(filepath.Join(dir, snapshotFileName), []byte(legacy), 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, walFileName),
+			[]byte(`{"op":"put","id":2,"next_id":3,"task":{"id":2,"header":"Logged","finished":true}}`+"\n"), 0o644))
+
+		repo, err := NewFile(dir, 0)
+		require.NoError(t, err)
+		defer repo.Close()
+
+		for taskID, status := range []string{models.StatusTodo, models.StatusDone, models.StatusDone} {
+			task, err := repo.LoadTask(ctx, uint(taskID))
+			require.NoError(t, err)
+			assert.Equal(t, status, task.Status)
+		}
+	})
+
+	t.Run("Torn Tail", func(t *testing.T) {
+		dir := t.TempDir()
+		repo, err := NewFile(dir, 0)
+		require.NoError(t, err)
+
+		_, err = repo.StoreTask(ctx, &models.TaskDomain{Header: "Task 1"})
+		require.NoError(t, err)
+		_, err = repo.wal.WriteString(`{"op":"put","id":1,"task":{"hea`)
+		require.NoError(t, err)
+		require.NoError(t, repo.wal.Close())
+
+		reopened, err := NewFile(dir, 0)
+		require.NoError(t, err)
+		defer reopened.Close()
+
+		tasks, err := reopened.QueryTasks(ctx, &models.TaskQuery{})
+		require.NoError(t, err)
+		assert.Len(t, tasks, 1)
+
+		id, err := reopened.StoreTask(ctx, &models.TaskDomain{Header: "Task 2"})
+		require.NoError(t, err)
+		assert.Equal(t, uint(1), id)
+	})
+}
@@ -0,0 +1,37 @@
+package tasks
+
+import (
+	"context"
+	"slices"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+// A revision holding the same task version as the last one is dropped.
+func (r *Repo) StoreRevision(ctx context.Context, revision *models.Revision) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	revisions := r.revisions[revision.TaskID]
+	if len(revisions) > 0 && revision.Task != nil {
+		if last := revisions[len(revisions)-1]; last.Task != nil && last.Task.Version == revision.Task.Version {
+			return nil
+		}
+	}
+	stored := *revision
+	stored.Number = len(revisions) + 1
+
+	return r.apply(change{Op: opRevision, ID: revision.TaskID, Revision: &stored, NextID: r.taskID})
+}
+
+func (r *Repo) LoadRevisions(ctx context.Context, taskID uint) ([]*models.Revision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	revisions := r.revisions[taskID]
+	if len(revisions) == 0 {
+		return nil, ErrTaskNotFound
+	}
+
+	return slices.Clone(revisions), nil
+}
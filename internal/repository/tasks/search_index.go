@@ -0,0 +1,198 @@
+package tasks
+
+import (
+	"math"
+	"slices"
+	"unicode"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+const (
+	fieldHeader      = "header"
+	fieldDescription = "description"
+
+	headerWeight = 2.0
+)
+
+type posting struct {
+	header      int
+	description int
+}
+
+// searchIndex is kept in sync by Repo.replay.
+type searchIndex struct {
+	postings map[string]map[uint]*posting
+	terms    map[uint][]string
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		postings: make(map[string]map[uint]*posting),
+		terms:    make(map[uint][]string),
+	}
+}
+
+func (idx *searchIndex) add(task *models.TaskDomain) {
+	idx.remove(task.ID)
+
+	postings := map[string]*posting{}
+	for _, tok := range tokenize(task.Header) {
+		if postings[tok.term] == nil {
+			postings[tok.term] = &posting{}
+		}
+		postings[tok.term].header++
+	}
+	for _, tok := range tokenize(task.Description) {
+		if postings[tok.term] == nil {
+			postings[tok.term] = &posting{}
+		}
+		postings[tok.term].description++
+	}
+
+	terms := make([]string, 0, len(postings))
+	for term, p := range postings {
+		if idx.postings[term] == nil {
+			idx.postings[term] = make(map[uint]*posting)
+		}
+		idx.postings[term][task.ID] = p
+		terms = append(terms, term)
+	}
+	idx.terms[task.ID] = terms
+}
+
+func (idx *searchIndex) remove(taskID uint) {
+	for _, term := range idx.terms[taskID] {
+		delete(idx.postings[term], taskID)
+		if len(idx.postings[term]) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+	delete(idx.terms, taskID)
+}
+
+type scoredTask struct {
+	taskID uint
+	score  float64
+}
+
+// Tasks are ranked by TF-IDF, with header hits counting double.
+func (idx *searchIndex) search(terms []string) []scoredTask {
+	if len(terms) == 0 {
+		return nil
+	}
+
+	total := float64(len(idx.terms))
+	scores := map[uint]float64{}
+	for i, term := range terms {
+		postings := idx.postings[term]
+		idf := math.Log(1 + total/float64(len(postings)+1))
+		next := map[uint]float64{}
+		for taskID, p := range postings {
+			if _, ok := scores[taskID]; !ok && i > 0 {
+				continue
+			}
+			tf := headerWeight*float64(p.header) + float64(p.description)
+			next[taskID] = scores[taskID] + idf*(1+math.Log(tf))
+		}
+		scores = next
+		if len(scores) == 0 {
+			return nil
+		}
+	}
+
+	result := make([]scoredTask, 0, len(scores))
+	for taskID, score := range scores {
+		result = append(result, scoredTask{taskID: taskID, score: score})
+	}
+	slices.SortFunc(result, func(a, b scoredTask) int {
+		switch {
+		case a.score > b.score:
+			return -1
+		case a.score < b.score:
+			return 1
+		default:
+			return compareUint(a.taskID, b.taskID)
+		}
+	})
+
+	return result
+}
+
+type token struct {
+	term  string
+	start int
+	end   int
+}
+
+// "ё" is folded into "е", as Russian text uses them interchangeably.
+func tokenize(text string) []token {
+	var (
+		tokens []token
+		term   []rune
+		start  int
+		pos    int
+	)
+	flush := func() {
+		if len(term) > 0 {
+			tokens = append(tokens, token{term: string(term), start: start, end: pos})
+			term = term[:0]
+		}
+	}
+
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) {
+			if len(term) == 0 {
+				start = pos
+			}
+			r = unicode.ToLower(r)
+			if r == 'ё' {
+				r = 'е'
+			}
+			term = append(term, r)
+		} else {
+			flush()
+		}
+		pos++
+	}
+	flush()
+
+	return tokens
+}
+
+func queryTerms(query string) []string {
+	seen := map[string]bool{}
+	terms := []string{}
+	for _, tok := range tokenize(query) {
+		if !seen[tok.term] {
+			seen[tok.term] = true
+			terms = append(terms, tok.term)
+		}
+	}
+
+	return terms
+}
+
+func highlights(task *models.TaskDomain, terms []string) []models.Highlight {
+	wanted := map[string]bool{}
+	for _, term := range terms {
+		wanted[term] = true
+	}
+
+	result := []models.Highlight{}
+	for _, field := range []struct {
+		name  string
+		value string
+	}{
+		{fieldHeader, task.Header},
+		{fieldDescription, task.Description},
+	} {
+		for _, tok := range tokenize(field.value) {
+			if wanted[tok.term] {
+				result = append(result, models.Highlight{Field: field.name, Start: tok.start, End: tok.end})
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,31 @@
+package tasks
+
+import (
+	"context"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+func (r *Repo) SearchTasks(ctx context.Context, query string, limit int) ([]*models.SearchResult, error) {
+	terms := queryTerms(query)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matches := r.index.search(terms)
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	results := make([]*models.SearchResult, 0, len(matches))
+	for _, match := range matches {
+		task := r.view(r.storage[match.taskID])
+		results = append(results, &models.SearchResult{
+			Task:       task,
+			Score:      match.score,
+			Highlights: highlights(task, terms),
+		})
+	}
+
+	return results, nil
+}
@@ -6,12 +6,41 @@ import (
 	"github.com/avraam311/tasks-service/internal/models"
 )
 
-func (r *Repo) StoreTask(ctx context.Context, task *models.TaskDTO) (uint, error) {
+func (r *Repo) StoreTask(ctx context.Context, task *models.TaskDomain) (uint, error) {
 	r.mu.Lock()
-	taskID := r.taskID
-	r.storage[r.taskID] = task
-	r.taskID++
-	r.mu.Unlock()
+	defer r.mu.Unlock()
+
+	return r.storeLocked(task)
+}
+
+func (r *Repo) storeLocked(task *models.TaskDomain) (uint, error) {
+	taskID := r.freeID()
+	stored := cloneTask(task)
+	stored.ID = taskID
+	stored.Version = 1
+	if err := r.checkParent(stored); err != nil {
+		return 0, err
+	}
+	err := r.apply(change{Op: opPut, ID: taskID, Task: stored, NextID: taskID + 1})
+	if err != nil {
+		return 0, err
+	}
 
 	return taskID, nil
 }
+
+// The caller must hold r.mu.
+func (r *Repo) freeID() uint {
+	// IDs below the counter are never handed out again. The loop only skips
+	// IDs, stored or trashed, that were created through PUT before UpsertTask
+	// moved the counter.
+	taskID := r.taskID
+	for {
+		_, stored := r.storage[taskID]
+		_, trashed := r.trash[taskID]
+		if !stored && !trashed {
+			return taskID
+		}
+		taskID++
+	}
+}
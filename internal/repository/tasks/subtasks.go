@@ -0,0 +1,130 @@
+package tasks
+
+import (
+	"context"
+	"slices"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+func (r *Repo) LoadChildren(ctx context.Context, taskID uint) ([]*models.TaskDomain, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.storage[taskID]; !ok {
+		return nil, ErrTaskNotFound
+	}
+
+	children := make([]*models.TaskDomain, 0, len(r.children[taskID]))
+	for _, childID := range r.children[taskID] {
+		children = append(children, r.view(r.storage[childID]))
+	}
+
+	return children, nil
+}
+
+// The trees are read under one lock so that each is a consistent snapshot.
+func (r *Repo) LoadTaskTrees(ctx context.Context, taskIDs []uint) ([]*models.TaskTree, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	trees := make([]*models.TaskTree, 0, len(taskIDs))
+	for _, taskID := range taskIDs {
+		if _, ok := r.storage[taskID]; !ok {
+			return nil, ErrTaskNotFound
+		}
+		trees = append(trees, r.tree(taskID))
+	}
+
+	return trees, nil
+}
+
+func (r *Repo) tree(taskID uint) *models.TaskTree {
+	tree := &models.TaskTree{
+		TaskDomain: r.view(r.storage[taskID]),
+		Subtasks:   make([]*models.TaskTree, 0, len(r.children[taskID])),
+	}
+	for _, childID := range r.children[taskID] {
+		tree.Subtasks = append(tree.Subtasks, r.tree(childID))
+	}
+
+	return tree
+}
+
+// The caller must hold r.mu.
+func (r *Repo) view(task *models.TaskDomain) *models.TaskDomain {
+	taskCopy := cloneTask(task)
+	if childIDs := r.children[task.ID]; len(childIDs) > 0 {
+		count := &models.ChildCount{Total: len(childIDs)}
+		for _, childID := range childIDs {
+			if r.storage[childID].Finished {
+				count.Finished++
+			}
+		}
+		taskCopy.Children = count
+	}
+	if !closed(task) {
+		for _, dependencyID := range task.DependsOn {
+			if !closed(r.storage[dependencyID]) {
+				taskCopy.Blocked = true
+				break
+			}
+		}
+	}
+
+	return taskCopy
+}
+
+func closed(task *models.TaskDomain) bool {
+	return task.Finished || task.Status == models.StatusCancelled
+}
+
+// The caller must hold r.mu.
+func (r *Repo) checkParent(task *models.TaskDomain) error {
+	for parentID := task.ParentID; parentID != nil; {
+		if *parentID == task.ID {
+			return ErrParentCycle
+		}
+		parent, ok := r.storage[*parentID]
+		if !ok {
+			return ErrParentNotFound
+		}
+		parentID = parent.ParentID
+	}
+
+	return nil
+}
+
+func (r *Repo) link(task *models.TaskDomain) {
+	if task.ParentID == nil {
+		return
+	}
+	r.children[*task.ParentID] = insertID(r.children[*task.ParentID], task.ID)
+}
+
+func (r *Repo) unlink(task *models.TaskDomain) {
+	if task.ParentID == nil {
+		return
+	}
+	siblings := removeID(r.children[*task.ParentID], task.ID)
+	if len(siblings) == 0 {
+		delete(r.children, *task.ParentID)
+	} else {
+		r.children[*task.ParentID] = siblings
+	}
+}
+
+// descendants lists children before their own children. The caller must
+// hold r.mu.
+func (r *Repo) descendants(taskID uint) []uint {
+	var ids []uint
+	queue := slices.Clone(r.children[taskID])
+	for len(queue) > 0 {
+		childID := queue[0]
+		queue = queue[1:]
+		ids = append(ids, childID)
+		queue = append(queue, r.children[childID]...)
+	}
+
+	return ids
+}
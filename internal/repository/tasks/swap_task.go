@@ -2,14 +2,130 @@ package tasks
 
 import (
 	"context"
+	"math"
 
 	"github.com/avraam311/tasks-service/internal/models"
 )
 
-func (r *Repo) SwapTask(ctx context.Context, taskID uint, task *models.TaskDTO) error {
+// update modifies a copy of the stored task in place. Changes to the derived
+// fields are discarded.
+func (r *Repo) SwapTask(ctx context.Context, taskID uint,
+	update func(task *models.TaskDomain) error) (*models.TaskDomain, error) {
 	r.mu.Lock()
-	r.storage[taskID] = task
-	r.mu.Unlock()
+	defer r.mu.Unlock()
 
-	return nil
+	stored, ok := r.storage[taskID]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+
+	return r.swapLocked(stored, update)
+}
+
+func (r *Repo) CompareAndSwapTask(ctx context.Context, taskID uint, version uint64,
+	update func(task *models.TaskDomain) error) (*models.TaskDomain, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.storage[taskID]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+	if stored.Version != version {
+		return nil, ErrVersionMismatch
+	}
+
+	return r.swapLocked(stored, update)
+}
+
+// SwapReminders records when reminders fired. Only FiredAt is kept from
+// update, and the version is left alone, so ETags handed out earlier stay
+// valid.
+func (r *Repo) SwapReminders(ctx context.Context, taskID uint,
+	update func(task *models.TaskDomain) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.storage[taskID]
+	if !ok {
+		return ErrTaskNotFound
+	}
+	task := r.view(stored)
+	if err := update(task); err != nil {
+		return err
+	}
+
+	next := cloneTask(stored)
+	for i := range next.Reminders {
+		if i >= len(task.Reminders) || task.Reminders[i].Before != next.Reminders[i].Before {
+			continue
+		}
+		next.Reminders[i].FiredAt = nil
+		if firedAt := task.Reminders[i].FiredAt; firedAt != nil {
+			firedAt := *firedAt
+			next.Reminders[i].FiredAt = &firedAt
+		}
+	}
+
+	return r.apply(change{Op: opPut, ID: taskID, Task: next, NextID: r.taskID})
+}
+
+// Creating a task moves the ID counter past taskID, so StoreTask never hands
+// the ID out again. A task in the trash is not recreated, and the largest ID
+// is refused because no counter value lies past it.
+func (r *Repo) UpsertTask(ctx context.Context, taskID uint,
+	update func(task *models.TaskDomain) error) (*models.TaskDomain, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if stored, ok := r.storage[taskID]; ok {
+		task, err := r.swapLocked(stored, update)
+		return task, false, err
+	}
+	if _, ok := r.trash[taskID]; ok {
+		return nil, false, ErrTaskTrashed
+	}
+	if taskID == math.MaxUint {
+		return nil, false, ErrTaskIDOutOfRange
+	}
+
+	task := &models.TaskDomain{ID: taskID}
+	if err := update(task); err != nil {
+		return nil, false, err
+	}
+	task.ID = taskID
+	task.Version = 1
+	if err := r.checkParent(task); err != nil {
+		return nil, false, err
+	}
+
+	nextID := r.taskID
+	if taskID >= nextID {
+		nextID = taskID + 1
+	}
+	if err := r.apply(change{Op: opPut, ID: taskID, Task: task, NextID: nextID}); err != nil {
+		return nil, false, err
+	}
+
+	return r.view(task), true, nil
+}
+
+func (r *Repo) swapLocked(stored *models.TaskDomain,
+	update func(task *models.TaskDomain) error) (*models.TaskDomain, error) {
+	task := r.view(stored)
+	if err := update(task); err != nil {
+		return nil, err
+	}
+	clearDerived(task)
+	task.ID = stored.ID
+	task.Version = stored.Version + 1
+	if err := r.checkParent(task); err != nil {
+		return nil, err
+	}
+
+	if err := r.apply(change{Op: opPut, ID: stored.ID, Task: task, NextID: r.taskID}); err != nil {
+		return nil, err
+	}
+
+	return r.view(task), nil
 }
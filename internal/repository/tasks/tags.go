@@ -0,0 +1,58 @@
+package tasks
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+func (r *Repo) LoadTags(ctx context.Context) ([]*models.TagCount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tags := make([]*models.TagCount, 0, len(r.tags))
+	for name, taskIDs := range r.tags {
+		tags = append(tags, &models.TagCount{Name: name, Count: len(taskIDs)})
+	}
+	slices.SortFunc(tags, func(a, b *models.TagCount) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return tags, nil
+}
+
+// Either every task carrying tag is swapped or, when any update fails, none
+// is.
+func (r *Repo) SwapTaggedTasks(ctx context.Context, tag string,
+	update func(task *models.TaskDomain) error) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	taskIDs := make([]uint, 0, len(r.tags[tag]))
+	for taskID := range r.tags[tag] {
+		taskIDs = append(taskIDs, taskID)
+	}
+	if len(taskIDs) == 0 {
+		return 0, ErrTagNotFound
+	}
+	slices.Sort(taskIDs)
+
+	batch := change{Op: opBatch, NextID: r.taskID}
+	for _, taskID := range taskIDs {
+		stored := r.storage[taskID]
+		task := cloneTask(stored)
+		if err := update(task); err != nil {
+			return 0, err
+		}
+		task.ID = taskID
+		task.Version = stored.Version + 1
+		batch.Changes = append(batch.Changes, change{Op: opPut, ID: taskID, Task: task, NextID: r.taskID})
+	}
+	if err := r.apply(batch); err != nil {
+		return 0, err
+	}
+
+	return len(taskIDs), nil
+}
@@ -0,0 +1,154 @@
+package tasks
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+func (r *Repo) LoadTrash(ctx context.Context) ([]*models.TaskDomain, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tasks := make([]*models.TaskDomain, 0, len(r.trash))
+	for _, task := range r.trash {
+		tasks = append(tasks, cloneTask(task))
+	}
+	slices.SortFunc(tasks, func(a, b *models.TaskDomain) int {
+		if c := b.DeletedAt.Compare(*a.DeletedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	return tasks, nil
+}
+
+// Dependencies on tasks that are gone are dropped.
+func (r *Repo) RestoreTask(ctx context.Context, taskID uint,
+	update func(task *models.TaskDomain) error) ([]uint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	trashed, ok := r.trash[taskID]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+	if err := r.checkParent(trashed); err != nil {
+		return nil, err
+	}
+
+	restored := r.trashedWith(taskID)
+	back := make(map[uint]bool, len(restored))
+	for _, restoredID := range restored {
+		back[restoredID] = true
+	}
+
+	batch := change{Op: opBatch, NextID: r.taskID}
+	for _, restoredID := range restored {
+		stored := r.trash[restoredID]
+		task := cloneTask(stored)
+		if err := update(task); err != nil {
+			return nil, err
+		}
+		task.ID = restoredID
+		task.Version = stored.Version + 1
+		task.DeletedAt = nil
+		task.TrashedWith = nil
+		task.DependsOn = slices.DeleteFunc(task.DependsOn, func(dependencyID uint) bool {
+			_, stored := r.storage[dependencyID]
+			return !stored && !back[dependencyID]
+		})
+		if len(task.DependsOn) == 0 {
+			task.DependsOn = nil
+		}
+		batch.Changes = append(batch.Changes, change{Op: opRestore, ID: restoredID, Task: task, NextID: r.taskID})
+	}
+	if err := r.apply(batch); err != nil {
+		return nil, err
+	}
+
+	return restored, nil
+}
+
+func (r *Repo) PurgeTask(ctx context.Context, taskID uint) ([]uint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.trash[taskID]; !ok {
+		return nil, ErrTaskNotFound
+	}
+
+	// Every trashed descendant goes too, as none of them could be restored
+	// without the task.
+	purged := r.trashedTree(taskID, func(task *models.TaskDomain) bool { return true })
+	if err := r.apply(r.purgeChange(purged)); err != nil {
+		return nil, err
+	}
+
+	return purged, nil
+}
+
+func (r *Repo) PurgeTrash(ctx context.Context, before time.Time) ([]uint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged []uint
+	for taskID, task := range r.trash {
+		if task.DeletedAt.Before(before) {
+			purged = append(purged, taskID)
+		}
+	}
+	if len(purged) == 0 {
+		return nil, nil
+	}
+	slices.Sort(purged)
+	if err := r.apply(r.purgeChange(purged)); err != nil {
+		return nil, err
+	}
+
+	return purged, nil
+}
+
+func (r *Repo) purgeChange(taskIDs []uint) change {
+	if len(taskIDs) == 1 {
+		return change{Op: opDelete, ID: taskIDs[0], NextID: r.taskID}
+	}
+
+	batch := change{Op: opBatch, NextID: r.taskID}
+	for _, taskID := range taskIDs {
+		batch.Changes = append(batch.Changes, change{Op: opDelete, ID: taskID, NextID: r.taskID})
+	}
+
+	return batch
+}
+
+// The caller must hold r.mu.
+func (r *Repo) trashedWith(taskID uint) []uint {
+	return r.trashedTree(taskID, func(task *models.TaskDomain) bool {
+		return task.TrashedWith != nil && *task.TrashedWith == taskID
+	})
+}
+
+// trashedTree returns taskID followed by its trashed descendants that member
+// accepts, parents before their children. The caller must hold r.mu.
+func (r *Repo) trashedTree(taskID uint, member func(task *models.TaskDomain) bool) []uint {
+	children := make(map[uint][]uint)
+	for trashedID, task := range r.trash {
+		if task.ParentID != nil && member(task) {
+			children[*task.ParentID] = append(children[*task.ParentID], trashedID)
+		}
+	}
+
+	result := []uint{taskID}
+	for i := 0; i < len(result); i++ {
+		childIDs := children[result[i]]
+		slices.Sort(childIDs)
+		result = append(result, childIDs...)
+	}
+
+	return result
+}
@@ -0,0 +1,176 @@
+package tasks
+
+import (
+	"context"
+	"time"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+// Writes are visible to later calls on the Tx right away, but to nobody else
+// until the transaction commits.
+type Tx struct {
+	r       *Repo
+	durable journal
+	changes []change
+	// before is in the order the tasks were first written.
+	before []txState
+	seen   map[uint]bool
+	nextID uint
+}
+
+type txState struct {
+	id      uint
+	stored  *models.TaskDomain
+	trashed *models.TaskDomain
+}
+
+// If fn returns an error or panics, every write it made is undone. Otherwise
+// the writes are journaled as one batch.
+func (r *Repo) Atomically(ctx context.Context, fn func(tx *Tx) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx := &Tx{
+		r:       r,
+		durable: r.journal,
+		seen:    make(map[uint]bool),
+		nextID:  r.taskID,
+	}
+	r.journal = tx
+	committed := false
+	defer func() {
+		r.journal = tx.durable
+		if !committed {
+			tx.rollback()
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if tx.durable != nil && len(tx.changes) > 0 {
+		if err := tx.durable.append(change{Op: opBatch, Changes: tx.changes, NextID: r.taskID}); err != nil {
+			return err
+		}
+	}
+	committed = true
+
+	return nil
+}
+
+func (tx *Tx) LoadTask(ctx context.Context, taskID uint) (*models.TaskDomain, error) {
+	task, ok := tx.r.storage[taskID]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+
+	return tx.r.view(task), nil
+}
+
+func (tx *Tx) StoreTask(ctx context.Context, task *models.TaskDomain) (uint, error) {
+	return tx.r.storeLocked(task)
+}
+
+func (tx *Tx) SwapTask(ctx context.Context, taskID uint,
+	update func(task *models.TaskDomain) error) (*models.TaskDomain, error) {
+	stored, ok := tx.r.storage[taskID]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+
+	return tx.r.swapLocked(stored, update)
+}
+
+func (tx *Tx) CompareAndSwapTask(ctx context.Context, taskID uint, version uint64,
+	update func(task *models.TaskDomain) error) (*models.TaskDomain, error) {
+	stored, ok := tx.r.storage[taskID]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+	if stored.Version != version {
+		return nil, ErrVersionMismatch
+	}
+
+	return tx.r.swapLocked(stored, update)
+}
+
+func (tx *Tx) DeleteTask(ctx context.Context, taskID uint, mode string, deletedAt time.Time,
+	detach func(task *models.TaskDomain) error) ([]uint, error) {
+	if _, ok := tx.r.storage[taskID]; !ok {
+		return nil, ErrTaskNotFound
+	}
+
+	return tx.r.deleteLocked(taskID, mode, deletedAt, detach)
+}
+
+func (tx *Tx) CompareAndDeleteTask(ctx context.Context, taskID uint, version uint64, mode string,
+	deletedAt time.Time, detach func(task *models.TaskDomain) error) ([]uint, error) {
+	stored, ok := tx.r.storage[taskID]
+	if !ok {
+		return nil, ErrTaskNotFound
+	}
+	if stored.Version != version {
+		return nil, ErrVersionMismatch
+	}
+
+	return tx.r.deleteLocked(taskID, mode, deletedAt, detach)
+}
+
+// A task created and deleted again counts as deleted.
+func (tx *Tx) Written() (created, updated, deleted []uint) {
+	for _, state := range tx.before {
+		_, stored := tx.r.storage[state.id]
+		switch {
+		case stored && state.stored == nil:
+			created = append(created, state.id)
+		case stored:
+			updated = append(updated, state.id)
+		default:
+			deleted = append(deleted, state.id)
+		}
+	}
+
+	return created, updated, deleted
+}
+
+// Tx is the journal of the repository while the transaction runs.
+func (tx *Tx) append(c change) error {
+	tx.remember(c)
+	tx.changes = append(tx.changes, c)
+
+	return nil
+}
+
+func (tx *Tx) remember(c change) {
+	if c.Op == opBatch {
+		for _, inner := range c.Changes {
+			tx.remember(inner)
+		}
+		return
+	}
+	if tx.seen[c.ID] {
+		return
+	}
+	tx.seen[c.ID] = true
+	tx.before = append(tx.before, txState{
+		id:      c.ID,
+		stored:  tx.r.storage[c.ID],
+		trashed: tx.r.trash[c.ID],
+	})
+}
+
+func (tx *Tx) rollback() {
+	for i := len(tx.before) - 1; i >= 0; i-- {
+		state := tx.before[i]
+		switch {
+		case state.stored != nil:
+			tx.r.replay(change{Op: opPut, ID: state.id, Task: state.stored})
+		case state.trashed != nil:
+			tx.r.replay(change{Op: opTrash, ID: state.id, Task: state.trashed})
+		default:
+			tx.r.replay(change{Op: opDelete, ID: state.id})
+		}
+	}
+	tx.r.taskID = tx.nextID
+}
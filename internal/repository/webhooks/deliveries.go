@@ -0,0 +1,45 @@
+package webhooks
+
+import (
+	"context"
+	"slices"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+func (r *Repo) StoreDelivery(ctx context.Context, delivery *models.Delivery) (uint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.storage[delivery.WebhookID]; !ok {
+		return 0, ErrWebhookNotFound
+	}
+	stored := *delivery
+	stored.ID = r.deliveryID
+	r.deliveryID++
+
+	entries := append(r.deliveries[delivery.WebhookID], &stored)
+	if len(entries) > MaxDeliveries {
+		entries = slices.Delete(entries, 0, len(entries)-MaxDeliveries)
+	}
+	r.deliveries[delivery.WebhookID] = entries
+
+	return stored.ID, nil
+}
+
+func (r *Repo) LoadDeliveries(ctx context.Context, webhookID uint) ([]*models.Delivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.storage[webhookID]; !ok {
+		return nil, ErrWebhookNotFound
+	}
+	entries := r.deliveries[webhookID]
+	deliveries := make([]*models.Delivery, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		delivery := *entries[i]
+		deliveries = append(deliveries, &delivery)
+	}
+
+	return deliveries, nil
+}
@@ -0,0 +1,39 @@
+package webhooks
+
+import (
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+const MaxDeliveries = 100
+
+type Repo struct {
+	storage    map[uint]*models.Webhook
+	deliveries map[uint][]*models.Delivery
+	webhookID  uint
+	deliveryID uint
+	mu         sync.RWMutex
+}
+
+func New() *Repo {
+	return &Repo{
+		storage:    make(map[uint]*models.Webhook),
+		deliveries: make(map[uint][]*models.Delivery),
+	}
+}
+
+func cloneWebhook(webhook *models.Webhook) *models.Webhook {
+	cloned := *webhook
+	cloned.Events = slices.Clone(webhook.Events)
+	if webhook.DisabledAt != nil {
+		disabledAt := *webhook.DisabledAt
+		cloned.DisabledAt = &disabledAt
+	}
+
+	return &cloned
+}
@@ -0,0 +1,82 @@
+package webhooks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+func TestRepo_Webhooks(t *testing.T) {
+	ctx := context.Background()
+	repo := New()
+
+	firstID, err := repo.StoreWebhook(ctx, &models.Webhook{URL: "http://a.example", Events: []string{"task.created"}})
+	require.NoError(t, err)
+	secondID, err := repo.StoreWebhook(ctx, &models.Webhook{URL: "http://b.example"})
+	require.NoError(t, err)
+	assert.Equal(t, uint(0), firstID)
+	assert.Equal(t, uint(1), secondID)
+
+	webhook, err := repo.LoadWebhook(ctx, firstID)
+	require.NoError(t, err)
+	webhook.Events[0] = "changed"
+	stored, err := repo.LoadWebhook(ctx, firstID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"task.created"}, stored.Events, "loaded webhooks are copies")
+
+	swapped, err := repo.SwapWebhook(ctx, secondID, func(webhook *models.Webhook) error {
+		webhook.Failures = 3
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, swapped.Failures)
+
+	_, err = repo.SwapWebhook(ctx, secondID, func(webhook *models.Webhook) error {
+		webhook.Failures = 4
+		return assert.AnError
+	})
+	require.ErrorIs(t, err, assert.AnError)
+
+	webhooks, err := repo.LoadWebhooks(ctx)
+	require.NoError(t, err)
+	require.Len(t, webhooks, 2)
+	assert.Equal(t, firstID, webhooks[0].ID)
+	assert.Equal(t, 3, webhooks[1].Failures, "a failed swap stores nothing")
+
+	require.NoError(t, repo.DeleteWebhook(ctx, firstID))
+	_, err = repo.LoadWebhook(ctx, firstID)
+	require.ErrorIs(t, err, ErrWebhookNotFound)
+	require.ErrorIs(t, repo.DeleteWebhook(ctx, firstID), ErrWebhookNotFound)
+	_, err = repo.SwapWebhook(ctx, firstID, func(webhook *models.Webhook) error { return nil })
+	require.ErrorIs(t, err, ErrWebhookNotFound)
+}
+
+func TestRepo_Deliveries(t *testing.T) {
+	ctx := context.Background()
+	repo := New()
+	webhookID, err := repo.StoreWebhook(ctx, &models.Webhook{URL: "http://a.example"})
+	require.NoError(t, err)
+
+	for attempt := 1; attempt <= MaxDeliveries+5; attempt++ {
+		_, err := repo.StoreDelivery(ctx, &models.Delivery{WebhookID: webhookID, Attempt: attempt})
+		require.NoError(t, err)
+	}
+
+	deliveries, err := repo.LoadDeliveries(ctx, webhookID)
+	require.NoError(t, err)
+	require.Len(t, deliveries, MaxDeliveries)
+	assert.Equal(t, MaxDeliveries+5, deliveries[0].Attempt, "newest first")
+	assert.Equal(t, 6, deliveries[MaxDeliveries-1].Attempt, "oldest dropped")
+
+	_, err = repo.StoreDelivery(ctx, &models.Delivery{WebhookID: 42})
+	require.ErrorIs(t, err, ErrWebhookNotFound)
+	_, err = repo.LoadDeliveries(ctx, 42)
+	require.ErrorIs(t, err, ErrWebhookNotFound)
+
+	require.NoError(t, repo.DeleteWebhook(ctx, webhookID))
+	assert.Empty(t, repo.deliveries)
+}
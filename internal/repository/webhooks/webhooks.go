@@ -0,0 +1,77 @@
+package webhooks
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+func (r *Repo) StoreWebhook(ctx context.Context, webhook *models.Webhook) (uint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := cloneWebhook(webhook)
+	stored.ID = r.webhookID
+	r.storage[stored.ID] = stored
+	r.webhookID++
+
+	return stored.ID, nil
+}
+
+func (r *Repo) LoadWebhook(ctx context.Context, webhookID uint) (*models.Webhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	webhook, ok := r.storage[webhookID]
+	if !ok {
+		return nil, ErrWebhookNotFound
+	}
+
+	return cloneWebhook(webhook), nil
+}
+
+func (r *Repo) LoadWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	webhooks := make([]*models.Webhook, 0, len(r.storage))
+	for _, webhookID := range slices.Sorted(maps.Keys(r.storage)) {
+		webhooks = append(webhooks, cloneWebhook(r.storage[webhookID]))
+	}
+
+	return webhooks, nil
+}
+
+func (r *Repo) SwapWebhook(ctx context.Context, webhookID uint,
+	update func(webhook *models.Webhook) error) (*models.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.storage[webhookID]
+	if !ok {
+		return nil, ErrWebhookNotFound
+	}
+	webhook := cloneWebhook(stored)
+	if err := update(webhook); err != nil {
+		return nil, err
+	}
+	webhook.ID = webhookID
+	r.storage[webhookID] = webhook
+
+	return cloneWebhook(webhook), nil
+}
+
+func (r *Repo) DeleteWebhook(ctx context.Context, webhookID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.storage[webhookID]; !ok {
+		return ErrWebhookNotFound
+	}
+	delete(r.storage, webhookID)
+	delete(r.deliveries, webhookID)
+
+	return nil
+}
@@ -0,0 +1,155 @@
+package events
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+const (
+	DefaultBufferSize = 1000
+	// A subscriber this many events behind is dropped.
+	SubscriberBuffer = 64
+)
+
+type Message struct {
+	ID    string
+	Event *models.TaskEvent
+}
+
+// Message IDs are "<stream>-<seq>" with a random stream, so an ID from
+// before a restart is not mistaken for a current one.
+type Broker struct {
+	stream string
+	buffer []Message
+	start  int
+	seq    uint64
+
+	subscribers map[*Subscription]struct{}
+	closed      bool
+	mu          sync.Mutex
+}
+
+type Subscription struct {
+	Replay []Message
+	// Reset is set when messages after the requested ID were missed.
+	Reset bool
+	C     <-chan Message
+
+	c      chan Message
+	broker *Broker
+}
+
+func New(size int) *Broker {
+	if size <= 0 {
+		size = DefaultBufferSize
+	}
+
+	return &Broker{
+		stream:      strings.ToLower(rand.Text()[:8]),
+		buffer:      make([]Message, 0, size),
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// task.finished is not streamed: the task.updated event before it already
+// carries the done task.
+func (b *Broker) Publish(event *models.TaskEvent) {
+	if event.Type == models.EventTaskFinished {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+
+	b.seq++
+	msg := Message{ID: b.id(b.seq), Event: event}
+	if len(b.buffer) < cap(b.buffer) {
+		b.buffer = append(b.buffer, msg)
+	} else {
+		b.buffer[b.start] = msg
+		b.start = (b.start + 1) % len(b.buffer)
+	}
+
+	for sub := range b.subscribers {
+		select {
+		case sub.c <- msg:
+		default:
+			b.dropLocked(sub)
+		}
+	}
+}
+
+func (b *Broker) Subscribe(lastID string) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := make(chan Message, SubscriberBuffer)
+	sub := &Subscription{C: c, c: c, broker: b}
+	if b.closed {
+		close(c)
+		return sub
+	}
+	if lastID != "" {
+		sub.Replay, sub.Reset = b.replayLocked(lastID)
+	}
+	b.subscribers[sub] = struct{}{}
+
+	return sub
+}
+
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	if _, ok := s.broker.subscribers[s]; ok {
+		s.broker.dropLocked(s)
+	}
+}
+
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subscribers {
+		b.dropLocked(sub)
+	}
+}
+
+func (b *Broker) dropLocked(sub *Subscription) {
+	delete(b.subscribers, sub)
+	close(sub.c)
+}
+
+func (b *Broker) id(seq uint64) string {
+	return fmt.Sprintf("%s-%d", b.stream, seq)
+}
+
+func (b *Broker) replayLocked(lastID string) (replay []Message, reset bool) {
+	stream, seqText, ok := strings.Cut(lastID, "-")
+	if !ok || stream != b.stream {
+		return nil, true
+	}
+	seq, err := strconv.ParseUint(seqText, 10, 64)
+	if err != nil || seq > b.seq {
+		return nil, true
+	}
+
+	oldest := b.seq - uint64(len(b.buffer)) + 1
+	if seq+1 < oldest {
+		return nil, true
+	}
+	for i := seq + 1 - oldest; i < uint64(len(b.buffer)); i++ {
+		replay = append(replay, b.buffer[(b.start+int(i))%len(b.buffer)])
+	}
+
+	return replay, false
+}
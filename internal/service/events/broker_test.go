@@ -0,0 +1,123 @@
+package events
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+func newEvent(eventType string, taskID uint) *models.TaskEvent {
+	return &models.TaskEvent{ID: fmt.Sprintf("evt-%d", taskID), Type: eventType, TaskID: taskID}
+}
+
+func taskIDs(msgs []Message) []uint {
+	ids := []uint{}
+	for _, msg := range msgs {
+		ids = append(ids, msg.Event.TaskID)
+	}
+
+	return ids
+}
+
+func TestBroker_Publish(t *testing.T) {
+	broker := New(10)
+	sub := broker.Subscribe("")
+	defer sub.Close()
+
+	broker.Publish(newEvent(models.EventTaskCreated, 1))
+	broker.Publish(newEvent(models.EventTaskFinished, 1))
+	broker.Publish(newEvent(models.EventTaskDeleted, 1))
+
+	first, second := <-sub.C, <-sub.C
+	assert.Equal(t, models.EventTaskCreated, first.Event.Type)
+	assert.Equal(t, models.EventTaskDeleted, second.Event.Type, "task.finished is not streamed")
+	assert.Equal(t, broker.stream+"-1", first.ID)
+	assert.Equal(t, broker.stream+"-2", second.ID)
+	assert.Empty(t, sub.C)
+}
+
+func TestBroker_Resume(t *testing.T) {
+	broker := New(3)
+	for taskID := uint(1); taskID <= 5; taskID++ {
+		broker.Publish(newEvent(models.EventTaskCreated, taskID))
+	}
+
+	tests := []struct {
+		name   string
+		lastID string
+		replay []uint
+		reset  bool
+	}{
+		{name: "Buffered", lastID: broker.stream + "-3", replay: []uint{4, 5}},
+		{name: "OldestMissing", lastID: broker.stream + "-2", replay: []uint{3, 4, 5}},
+		{name: "UpToDate", lastID: broker.stream + "-5", replay: []uint{}},
+		{name: "Evicted", lastID: broker.stream + "-1", replay: []uint{}, reset: true},
+		{name: "Future", lastID: broker.stream + "-6", replay: []uint{}, reset: true},
+		{name: "OtherStream", lastID: "other-4", replay: []uint{}, reset: true},
+		{name: "Malformed", lastID: "4", replay: []uint{}, reset: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := broker.Subscribe(tt.lastID)
+			defer sub.Close()
+
+			assert.Equal(t, tt.replay, taskIDs(sub.Replay))
+			assert.Equal(t, tt.reset, sub.Reset)
+		})
+	}
+
+	t.Run("ReplayThenLive", func(t *testing.T) {
+		sub := broker.Subscribe(broker.stream + "-4")
+		defer sub.Close()
+
+		broker.Publish(newEvent(models.EventTaskUpdated, 6))
+		assert.Equal(t, []uint{5}, taskIDs(sub.Replay))
+		assert.Equal(t, uint(6), (<-sub.C).Event.TaskID)
+	})
+}
+
+func TestBroker_DropsSlowSubscriber(t *testing.T) {
+	broker := New(0)
+	slow := broker.Subscribe("")
+	fast := broker.Subscribe("")
+	defer fast.Close()
+
+	for taskID := uint(1); taskID <= SubscriberBuffer+1; taskID++ {
+		broker.Publish(newEvent(models.EventTaskCreated, taskID))
+		if taskID <= SubscriberBuffer {
+			<-fast.C
+		}
+	}
+
+	for range SubscriberBuffer {
+		<-slow.C
+	}
+	_, ok := <-slow.C
+	assert.False(t, ok, "slow subscriber is closed")
+	msg, ok := <-fast.C
+	require.True(t, ok)
+	assert.Equal(t, uint(SubscriberBuffer+1), msg.Event.TaskID)
+
+	resumed := broker.Subscribe(fmt.Sprintf("%s-%d", broker.stream, SubscriberBuffer))
+	defer resumed.Close()
+	assert.Equal(t, []uint{SubscriberBuffer + 1}, taskIDs(resumed.Replay))
+}
+
+func TestBroker_Close(t *testing.T) {
+	broker := New(10)
+	sub := broker.Subscribe("")
+
+	broker.Close()
+	_, ok := <-sub.C
+	assert.False(t, ok)
+	sub.Close()
+
+	broker.Publish(newEvent(models.EventTaskCreated, 1))
+	_, ok = <-broker.Subscribe("").C
+	assert.False(t, ok)
+}
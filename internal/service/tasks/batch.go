@@ -0,0 +1,208 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/avraam311/tasks-service/internal/models"
+	repoTasks "github.com/avraam311/tasks-service/internal/repository/tasks"
+)
+
+const (
+	MaxBatchOperations = 1000
+
+	RuleOneOf = "oneof"
+)
+
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("batch operation %d failed: %v", e.Index, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// batchRepo is the Repo in best-effort mode and a transaction in atomic mode.
+type batchRepo interface {
+	LoadTask(ctx context.Context, taskID uint) (*models.TaskDomain, error)
+	StoreTask(ctx context.Context, task *models.TaskDomain) (uint, error)
+	SwapTask(ctx context.Context, taskID uint,
+		update func(task *models.TaskDomain) error) (*models.TaskDomain, error)
+	CompareAndSwapTask(ctx context.Context, taskID uint, version uint64,
+		update func(task *models.TaskDomain) error) (*models.TaskDomain, error)
+	DeleteTask(ctx context.Context, taskID uint, mode string, deletedAt time.Time,
+		detach func(task *models.TaskDomain) error) ([]uint, error)
+	CompareAndDeleteTask(ctx context.Context, taskID uint, version uint64, mode string,
+		deletedAt time.Time, detach func(task *models.TaskDomain) error) ([]uint, error)
+}
+
+type batchWrite struct {
+	op       string
+	taskID   uint
+	task     *models.TaskDomain
+	finished bool
+	deleted  []uint
+	detached []uint
+}
+
+// Events and next occurrences of recurring tasks wait until the writes are
+// committed.
+func (s *Service) BatchTasks(ctx context.Context, ops []models.BatchOperation,
+	atomic bool) ([]*models.BatchResult, error) {
+	results := make([]*models.BatchResult, len(ops))
+	for i, op := range ops {
+		results[i] = &models.BatchResult{Index: i, Op: op.Op, Status: models.BatchSkipped, ID: op.ID}
+	}
+
+	if !atomic {
+		for i := range ops {
+			write, err := s.writeBatchOp(ctx, s.repo, &ops[i])
+			if err != nil {
+				results[i].Status = models.BatchFailed
+				results[i].Err = err
+				continue
+			}
+			s.commitBatchOp(ctx, write, results[i])
+		}
+		return results, nil
+	}
+
+	writes := make([]*batchWrite, 0, len(ops))
+	err := s.repo.Atomically(ctx, func(tx *repoTasks.Tx) error {
+		for i := range ops {
+			write, err := s.writeBatchOp(ctx, tx, &ops[i])
+			if err != nil {
+				return &BatchError{Index: i, Err: err}
+			}
+			writes = append(writes, write)
+		}
+		return nil
+	})
+	if err != nil {
+		var batchErr *BatchError
+		if !errors.As(err, &batchErr) {
+			return nil, fmt.Errorf("service/batch.go - %w", err)
+		}
+		for i := 0; i < batchErr.Index; i++ {
+			results[i].Status = models.BatchRolledBack
+		}
+		results[batchErr.Index].Status = models.BatchFailed
+		results[batchErr.Index].Err = batchErr.Err
+		return results, fmt.Errorf("service/batch.go - %w", batchErr)
+	}
+	for i, write := range writes {
+		s.commitBatchOp(ctx, write, results[i])
+	}
+
+	return results, nil
+}
+
+func (s *Service) writeBatchOp(ctx context.Context, repo batchRepo, op *models.BatchOperation) (*batchWrite, error) {
+	if err := checkBatchOp(op); err != nil {
+		return nil, err
+	}
+
+	write := &batchWrite{op: op.Op}
+	switch op.Op {
+	case models.BatchCreate:
+		task, err := s.newTask(op.Task)
+		if err != nil {
+			return nil, err
+		}
+		write.taskID, err = repo.StoreTask(ctx, task)
+		if err != nil {
+			return nil, parentError(err)
+		}
+		// A later operation of the batch may delete the task before the
+		// created event goes out, so the event carries it as stored now.
+		write.task, err = repo.LoadTask(ctx, write.taskID)
+		if err != nil {
+			return nil, err
+		}
+	case models.BatchUpdate:
+		if err := prepareTask(op.Task); err != nil {
+			return nil, err
+		}
+		update := finishing(s.updateWith(op.Task, op.Force), &write.finished)
+		var err error
+		if op.Version != nil {
+			write.task, err = repo.CompareAndSwapTask(ctx, *op.ID, *op.Version, update)
+		} else {
+			write.task, err = repo.SwapTask(ctx, *op.ID, update)
+		}
+		if err != nil {
+			return nil, parentError(err)
+		}
+		write.taskID = *op.ID
+	case models.BatchDelete:
+		mode := childrenMode(op.Children)
+		deletedAt := s.clock.Now().UTC()
+		detach := recording(s.touch, &write.detached)
+		var err error
+		if op.Version != nil {
+			write.deleted, err = repo.CompareAndDeleteTask(ctx, *op.ID, *op.Version, mode, deletedAt, detach)
+		} else {
+			write.deleted, err = repo.DeleteTask(ctx, *op.ID, mode, deletedAt, detach)
+		}
+		if err != nil {
+			return nil, err
+		}
+		write.taskID = *op.ID
+	}
+
+	return write, nil
+}
+
+func (s *Service) commitBatchOp(ctx context.Context, write *batchWrite, result *models.BatchResult) {
+	result.Status = models.BatchDone
+	result.ID = &write.taskID
+
+	switch write.op {
+	case models.BatchCreate:
+		s.publish(models.EventTaskCreated, write.taskID, write.task)
+		result.Version = s.recur(ctx, write.task).Version
+	case models.BatchUpdate:
+		s.publishUpdate(write.task, write.finished)
+		result.Version = s.recur(ctx, write.task).Version
+	case models.BatchDelete:
+		result.Deleted = write.deleted
+		s.publishDelete(ctx, write.deleted, write.detached)
+	}
+}
+
+func checkBatchOp(op *models.BatchOperation) error {
+	var fields []FieldError
+	switch op.Op {
+	case models.BatchCreate, models.BatchUpdate, models.BatchDelete:
+	default:
+		return &ValidationError{Fields: []FieldError{
+			{Field: "op", Rule: RuleOneOf, Message: "must be create, update or delete"},
+		}}
+	}
+	if op.Op != models.BatchCreate && op.ID == nil {
+		fields = append(fields, FieldError{Field: "id", Rule: RuleRequired, Message: "is required"})
+	}
+	if op.Op != models.BatchDelete && op.Task == nil {
+		fields = append(fields, FieldError{Field: "task", Rule: RuleRequired, Message: "is required"})
+	}
+	if op.Op == models.BatchDelete {
+		switch op.Children {
+		case "", models.ChildrenReject, models.ChildrenCascade, models.ChildrenOrphan:
+		default:
+			fields = append(fields, FieldError{Field: "children", Rule: RuleOneOf,
+				Message: "must be reject, cascade or orphan"})
+		}
+	}
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package tasks
+
+import (
+	"slices"
+	"time"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+type Clock interface {
+	Now() time.Time
+}
+
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// The status is left to setStatus.
+func applyTaskDTO(task *models.TaskDomain, dto *models.TaskDTO, now time.Time) {
+	now = now.UTC()
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = now
+	}
+	task.UpdatedAt = now
+
+	task.Header = dto.Header
+	task.Description = dto.Description
+	task.Tags = slices.Clone(dto.Tags)
+	task.ParentID = nil
+	if dto.ParentID != nil {
+		parentID := *dto.ParentID
+		task.ParentID = &parentID
+	}
+	var dueAt *time.Time
+	if dto.DueAt != nil {
+		utc := dto.DueAt.UTC()
+		dueAt = &utc
+	}
+	applyReminders(task, dto.Reminders, dueAt)
+	task.DueAt = dueAt
+	task.Recurrence = dto.Recurrence
+	task.TimeZone = dto.TimeZone
+	if task.Recurrence != "" && task.Occurrence == 0 {
+		task.Occurrence = 1
+	}
+}
+
+func (s *Service) touch(task *models.TaskDomain) error {
+	task.UpdatedAt = s.clock.Now().UTC()
+	return nil
+}
@@ -3,15 +3,48 @@ package tasks
 import (
 	"context"
 	"fmt"
+	"log/slog"
 
 	"github.com/avraam311/tasks-service/internal/models"
 )
 
 func (s *Service) CreateTask(ctx context.Context, task *models.TaskDTO) (uint, error) {
-	taskID, err := s.repo.StoreTask(ctx, task)
+	stored, err := s.newTask(task)
 	if err != nil {
 		return 0, fmt.Errorf("service/create_task.go - %w", err)
 	}
+	taskID, err := s.repo.StoreTask(ctx, stored)
+	if err != nil {
+		return 0, fmt.Errorf("service/create_task.go - %w", parentError(err))
+	}
+	s.commitCreate(ctx, taskID, stored)
 
 	return taskID, nil
 }
+
+func (s *Service) commitCreate(ctx context.Context, taskID uint, stored *models.TaskDomain) {
+	s.publishLoaded(ctx, models.EventTaskCreated, taskID)
+	// A recurring task created done gets its next occurrence like one
+	// finished later.
+	if stored.Status == models.StatusDone && stored.Recurrence != "" {
+		created, err := s.repo.LoadTask(ctx, taskID)
+		if err != nil {
+			slog.Error("failed to create next occurrence", slog.Any("task_id", taskID), slog.Any("error", err))
+			return
+		}
+		s.recur(ctx, created)
+	}
+}
+
+func (s *Service) newTask(dto *models.TaskDTO) (*models.TaskDomain, error) {
+	if err := prepareTask(dto); err != nil {
+		return nil, err
+	}
+
+	task := &models.TaskDomain{}
+	if err := s.applyDTO(task, dto, false); err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
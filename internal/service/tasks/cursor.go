@@ -0,0 +1,67 @@
+package tasks
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursor records the sort it was issued for so it cannot be replayed against
+// another one.
+type cursor struct {
+	Sort   string     `json:"s"`
+	ID     uint       `json:"id"`
+	Header string     `json:"h,omitempty"`
+	DueAt  *time.Time `json:"d,omitempty"`
+}
+
+func encodeCursor(task *models.TaskDomain, sort []models.SortKey) string {
+	data, _ := json.Marshal(cursor{
+		Sort:   sortString(sort),
+		ID:     task.ID,
+		Header: task.Header,
+		DueAt:  task.DueAt,
+	})
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string, sort []models.SortKey) (*models.TaskDomain, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.Sort != sortString(sort) {
+		return nil, ErrInvalidCursor
+	}
+
+	return &models.TaskDomain{
+		ID:     c.ID,
+		Header: c.Header,
+		DueAt:  c.DueAt,
+	}, nil
+}
+
+func sortString(sort []models.SortKey) string {
+	keys := make([]string, 0, len(sort))
+	for _, key := range sort {
+		if key.Desc {
+			keys = append(keys, "-"+key.Field)
+		} else {
+			keys = append(keys, key.Field)
+		}
+	}
+
+	return strings.Join(keys, ",")
+}
@@ -3,13 +3,45 @@ package tasks
 import (
 	"context"
 	"fmt"
+
+	"github.com/avraam311/tasks-service/internal/models"
 )
 
-func (s *Service) DeleteTask(ctx context.Context, taskID uint) error {
-	err := s.repo.DeleteTask(ctx, taskID)
+// An empty mode is models.ChildrenReject.
+func (s *Service) DeleteTask(ctx context.Context, taskID uint, mode string) error {
+	var detached []uint
+	deleted, err := s.repo.DeleteTask(ctx, taskID, childrenMode(mode), s.clock.Now().UTC(), recording(s.touch, &detached))
+	if err != nil {
+		return fmt.Errorf("service/delete_task.go - %w", err)
+	}
+	s.publishDelete(ctx, deleted, detached)
+
+	return nil
+}
+
+func (s *Service) DeleteTaskIfMatch(ctx context.Context, taskID uint, version uint64, mode string) error {
+	var detached []uint
+	deleted, err := s.repo.CompareAndDeleteTask(ctx, taskID, version, childrenMode(mode),
+		s.clock.Now().UTC(), recording(s.touch, &detached))
 	if err != nil {
 		return fmt.Errorf("service/delete_task.go - %w", err)
 	}
+	s.publishDelete(ctx, deleted, detached)
 
 	return nil
 }
+
+func (s *Service) publishDelete(ctx context.Context, deleted, detached []uint) {
+	for _, taskID := range deleted {
+		s.publish(models.EventTaskDeleted, taskID, nil)
+	}
+	s.publishLoaded(ctx, models.EventTaskUpdated, detached...)
+}
+
+func childrenMode(mode string) string {
+	if mode == "" {
+		return models.ChildrenReject
+	}
+
+	return mode
+}
@@ -0,0 +1,40 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+var ErrTaskBlocked = errors.New("task has unfinished dependencies")
+
+func (s *Service) AddDependency(ctx context.Context, taskID, dependencyID uint) (*models.TaskDomain, error) {
+	task, err := s.repo.AddDependency(ctx, taskID, dependencyID, s.touch)
+	if err != nil {
+		return nil, fmt.Errorf("service/dependencies.go - %w", err)
+	}
+	s.publishUpdate(task, false)
+
+	return task, nil
+}
+
+func (s *Service) RemoveDependency(ctx context.Context, taskID, dependencyID uint) (*models.TaskDomain, error) {
+	task, err := s.repo.RemoveDependency(ctx, taskID, dependencyID, s.touch)
+	if err != nil {
+		return nil, fmt.Errorf("service/dependencies.go - %w", err)
+	}
+	s.publishUpdate(task, false)
+
+	return task, nil
+}
+
+// task must come from a repository update so its Blocked flag is current.
+func checkBlocked(task *models.TaskDomain, status string, force bool) error {
+	if status == models.StatusDone && !task.Finished && task.Blocked && !force {
+		return ErrTaskBlocked
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package tasks
+
+import (
+	"context"
+	"crypto/rand"
+	"log/slog"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+// Publish is called after the write succeeded and must not block it.
+type Publisher interface {
+	Publish(event *models.TaskEvent)
+}
+
+type Publishers []Publisher
+
+func (p Publishers) Publish(event *models.TaskEvent) {
+	for _, publisher := range p {
+		publisher.Publish(event)
+	}
+}
+
+func (s *Service) publish(eventType string, taskID uint, task *models.TaskDomain) {
+	if s.publisher == nil {
+		return
+	}
+
+	s.publisher.Publish(&models.TaskEvent{
+		ID:         rand.Text(),
+		Type:       eventType,
+		TaskID:     taskID,
+		Task:       task,
+		OccurredAt: s.clock.Now().UTC(),
+	})
+}
+
+func (s *Service) publishUpdate(task *models.TaskDomain, finished bool) {
+	s.publish(models.EventTaskUpdated, task.ID, task)
+	if finished {
+		s.publish(models.EventTaskFinished, task.ID, task)
+	}
+}
+
+func (s *Service) publishLoaded(ctx context.Context, eventType string, taskIDs ...uint) {
+	if s.publisher == nil {
+		return
+	}
+
+	for _, taskID := range taskIDs {
+		task, err := s.repo.LoadTask(ctx, taskID)
+		if err != nil {
+			slog.Warn("failed to load task for event", slog.Any("task_id", taskID), slog.Any("error", err))
+			continue
+		}
+		s.publish(eventType, taskID, task)
+	}
+}
+
+func finishing(update func(task *models.TaskDomain) error, finished *bool) func(task *models.TaskDomain) error {
+	return func(task *models.TaskDomain) error {
+		wasDone := task.Status == models.StatusDone
+		if err := update(task); err != nil {
+			return err
+		}
+		*finished = !wasDone && task.Status == models.StatusDone
+		return nil
+	}
+}
+
+func recording(update func(task *models.TaskDomain) error, taskIDs *[]uint) func(task *models.TaskDomain) error {
+	return func(task *models.TaskDomain) error {
+		if err := update(task); err != nil {
+			return err
+		}
+		*taskIDs = append(*taskIDs, task.ID)
+		return nil
+	}
+}
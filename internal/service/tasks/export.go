@@ -0,0 +1,37 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+// Tasks are read a page at a time, so the repository is not locked while
+// write runs.
+func (s *Service) ExportTasks(ctx context.Context, query *models.TaskQuery,
+	write func(task *models.TaskDomain) error) error {
+	pageQuery := *query
+	pageQuery.Tree = false
+	pageQuery.Limit = MaxPageLimit
+	pageQuery.Cursor = ""
+
+	for {
+		page, err := s.GetAllTasks(ctx, &pageQuery)
+		if err != nil {
+			return fmt.Errorf("service/export.go - %w", err)
+		}
+		for _, task := range page.Tasks {
+			if err := write(task); err != nil {
+				return fmt.Errorf("service/export.go - %w", err)
+			}
+		}
+		if page.NextCursor == "" {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("service/export.go - %w", err)
+		}
+		pageQuery.Cursor = page.NextCursor
+	}
+}
@@ -3,15 +3,69 @@ package tasks
 import (
 	"context"
 	"fmt"
+	"slices"
 
 	"github.com/avraam311/tasks-service/internal/models"
 )
 
-func (s *Service) GetAllTasks(ctx context.Context) ([]*models.TaskDomain, error) {
-	tasks, err := s.repo.LoadAllTasks(ctx)
+const MaxPageLimit = 1000
+
+func (s *Service) GetAllTasks(ctx context.Context, query *models.TaskQuery) (*models.TaskPage, error) {
+	limit := min(max(query.Limit, 0), MaxPageLimit)
+
+	repoQuery := *query
+	if limit > 0 {
+		repoQuery.Limit = limit + 1
+	}
+	repoQuery.Tags = normalizeTags(query.Tags)
+	if query.Cursor != "" {
+		after, err := decodeCursor(query.Cursor, query.Sort)
+		if err != nil {
+			return nil, fmt.Errorf("service/get_all_tasks.go - %w", err)
+		}
+		repoQuery.After = after
+	}
+	if query.Overdue {
+		repoQuery.Statuses = openStatuses(query.Statuses)
+		if (query.Finished != nil && *query.Finished) || len(repoQuery.Statuses) == 0 {
+			return &models.TaskPage{Tasks: []*models.TaskDomain{}, Trees: []*models.TaskTree{}}, nil
+		}
+		now := s.clock.Now()
+		if repoQuery.DueBefore == nil || now.Before(*repoQuery.DueBefore) {
+			repoQuery.DueBefore = &now
+		}
+	}
+
+	tasks, err := s.repo.QueryTasks(ctx, &repoQuery)
 	if err != nil {
 		return nil, fmt.Errorf("service/get_all_tasks.go - %w", err)
 	}
 
-	return tasks, nil
+	page := &models.TaskPage{Tasks: tasks}
+	if limit > 0 && len(tasks) > limit {
+		page.Tasks = tasks[:limit]
+		page.NextCursor = encodeCursor(tasks[limit-1], query.Sort)
+	}
+	if query.Tree {
+		rootIDs := make([]uint, 0, len(page.Tasks))
+		for _, task := range page.Tasks {
+			rootIDs = append(rootIDs, task.ID)
+		}
+		page.Trees, err = s.repo.LoadTaskTrees(ctx, rootIDs)
+		if err != nil {
+			return nil, fmt.Errorf("service/get_all_tasks.go - %w", err)
+		}
+	}
+
+	return page, nil
+}
+
+func openStatuses(statuses []string) []string {
+	if len(statuses) == 0 {
+		statuses = models.Statuses
+	}
+
+	return slices.DeleteFunc(slices.Clone(statuses), func(status string) bool {
+		return status == models.StatusDone || status == models.StatusCancelled
+	})
 }
@@ -0,0 +1,165 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+var (
+	ErrNoHistory        = errors.New("task history is not recorded")
+	ErrRevisionNotFound = errors.New("revision not found")
+	ErrNotRevertible    = errors.New("revision has no task state to restore")
+)
+
+type historian interface {
+	LoadRevisions(ctx context.Context, taskID uint) ([]*models.Revision, error)
+}
+
+func (s *Service) GetHistory(ctx context.Context, taskID uint) ([]*models.RevisionDiff, error) {
+	revisions, err := s.revisions(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("service/history.go - %w", err)
+	}
+
+	diffs := make([]*models.RevisionDiff, 0, len(revisions))
+	var previous *models.TaskDomain
+	for _, revision := range revisions {
+		diff := &models.RevisionDiff{
+			Number:     revision.Number,
+			Op:         revision.Op,
+			Actor:      revision.Actor,
+			RecordedAt: revision.RecordedAt,
+			Changes:    diffTasks(previous, revision.Task),
+		}
+		if revision.Task != nil {
+			diff.Version = revision.Task.Version
+		}
+		diffs = append(diffs, diff)
+		previous = revision.Task
+	}
+
+	return diffs, nil
+}
+
+// The status is restored without consulting the workflow, and dependencies
+// are left as they are. A purged task is created again under its ID.
+func (s *Service) RevertTask(ctx context.Context, taskID uint, number int) (*models.TaskDomain, error) {
+	revisions, err := s.revisions(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("service/history.go - %w", err)
+	}
+	pos := slices.IndexFunc(revisions, func(revision *models.Revision) bool {
+		return revision.Number == number
+	})
+	if pos < 0 {
+		return nil, fmt.Errorf("service/history.go - %w", ErrRevisionNotFound)
+	}
+	target := revisions[pos].Task
+	if target == nil {
+		return nil, fmt.Errorf("service/history.go - %w", ErrNotRevertible)
+	}
+
+	var finished bool
+	task, created, err := s.repo.UpsertTask(ctx, taskID, finishing(func(task *models.TaskDomain) error {
+		now := s.clock.Now().UTC()
+		if task.CreatedAt.IsZero() {
+			task.CreatedAt = target.CreatedAt
+		}
+		task.UpdatedAt = now
+		task.Header = target.Header
+		task.Description = target.Description
+		task.Tags = slices.Clone(target.Tags)
+		task.ParentID = nil
+		if target.ParentID != nil {
+			parentID := *target.ParentID
+			task.ParentID = &parentID
+		}
+		applyReminders(task, reminderOffsets(target), target.DueAt)
+		task.DueAt = target.DueAt
+		task.Recurrence = target.Recurrence
+		task.TimeZone = target.TimeZone
+		if task.Occurrence == 0 {
+			task.Occurrence = target.Occurrence
+		}
+		setStatus(task, target.Status, now)
+		return nil
+	}, &finished))
+	if err != nil {
+		return nil, fmt.Errorf("service/history.go - %w", parentError(err))
+	}
+	if created {
+		s.publish(models.EventTaskCreated, task.ID, task)
+	} else {
+		s.publishUpdate(task, finished)
+	}
+
+	return s.recur(ctx, task), nil
+}
+
+func (s *Service) revisions(ctx context.Context, taskID uint) ([]*models.Revision, error) {
+	history, ok := s.repo.(historian)
+	if !ok {
+		return nil, ErrNoHistory
+	}
+
+	return history.LoadRevisions(ctx, taskID)
+}
+
+// Fields every write changes or the repository derives make no useful diff.
+var historyIgnored = map[string]bool{
+	"id":         true,
+	"version":    true,
+	"updated_at": true,
+	"finished":   true,
+	"children":   true,
+	"blocked":    true,
+}
+
+var historyFields = func() []string {
+	var fields []string
+	taskType := reflect.TypeOf(models.TaskDomain{})
+	for i := 0; i < taskType.NumField(); i++ {
+		name, _, _ := strings.Cut(taskType.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" && !historyIgnored[name] {
+			fields = append(fields, name)
+		}
+	}
+
+	return fields
+}()
+
+func diffTasks(from, to *models.TaskDomain) []models.FieldChange {
+	fromFields, toFields := taskFields(from), taskFields(to)
+
+	changes := []models.FieldChange{}
+	for _, field := range historyFields {
+		fromValue, toValue := fromFields[field], toFields[field]
+		if reflect.DeepEqual(fromValue, toValue) {
+			continue
+		}
+		changes = append(changes, models.FieldChange{Field: field, From: fromValue, To: toValue})
+	}
+
+	return changes
+}
+
+func taskFields(task *models.TaskDomain) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if task == nil {
+		return fields
+	}
+	data, err := json.Marshal(task)
+	if err != nil {
+		return fields
+	}
+	_ = json.Unmarshal(data, &fields)
+
+	return fields
+}
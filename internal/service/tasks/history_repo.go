@@ -0,0 +1,284 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/avraam311/tasks-service/internal/actor"
+	"github.com/avraam311/tasks-service/internal/models"
+	repoTasks "github.com/avraam311/tasks-service/internal/repository/tasks"
+)
+
+type RevisionStore interface {
+	StoreRevision(ctx context.Context, revision *models.Revision) error
+	LoadRevisions(ctx context.Context, taskID uint) ([]*models.Revision, error)
+}
+
+// Writes go through the wrapped repo one at a time, so revisions are
+// recorded in the order the writes happened.
+type HistoryRepo struct {
+	Repo
+	revisions RevisionStore
+	clock     Clock
+	writeMu   sync.Mutex
+}
+
+func NewHistoryRepo(repo Repo, revisions RevisionStore, clock Clock) *HistoryRepo {
+	return &HistoryRepo{
+		Repo:      repo,
+		revisions: revisions,
+		clock:     clock,
+	}
+}
+
+func (h *HistoryRepo) LoadRevisions(ctx context.Context, taskID uint) ([]*models.Revision, error) {
+	return h.revisions.LoadRevisions(ctx, taskID)
+}
+
+func (h *HistoryRepo) StoreTask(ctx context.Context, task *models.TaskDomain) (uint, error) {
+	h.writeMu.Lock()
+	defer h.writeMu.Unlock()
+
+	taskID, err := h.Repo.StoreTask(ctx, task)
+	if err != nil {
+		return 0, err
+	}
+	h.recordLoaded(ctx, models.RevisionCreated, taskID)
+
+	return taskID, nil
+}
+
+func (h *HistoryRepo) SwapTask(ctx context.Context, taskID uint,
+	update func(task *models.TaskDomain) error) (*models.TaskDomain, error) {
+	h.writeMu.Lock()
+	defer h.writeMu.Unlock()
+
+	task, err := h.Repo.SwapTask(ctx, taskID, update)
+
+	return h.recordSwap(ctx, task, err)
+}
+
+func (h *HistoryRepo) UpsertTask(ctx context.Context, taskID uint,
+	update func(task *models.TaskDomain) error) (*models.TaskDomain, bool, error) {
+	h.writeMu.Lock()
+	defer h.writeMu.Unlock()
+
+	task, created, err := h.Repo.UpsertTask(ctx, taskID, update)
+	if err != nil {
+		return nil, false, err
+	}
+	op := models.RevisionUpdated
+	if created {
+		op = models.RevisionCreated
+	}
+	h.record(ctx, op, taskID, task)
+
+	return task, created, nil
+}
+
+func (h *HistoryRepo) CompareAndSwapTask(ctx context.Context, taskID uint, version uint64,
+	update func(task *models.TaskDomain) error) (*models.TaskDomain, error) {
+	h.writeMu.Lock()
+	defer h.writeMu.Unlock()
+
+	task, err := h.Repo.CompareAndSwapTask(ctx, taskID, version, update)
+
+	return h.recordSwap(ctx, task, err)
+}
+
+func (h *HistoryRepo) SwapTaggedTasks(ctx context.Context, tag string,
+	update func(task *models.TaskDomain) error) (int, error) {
+	h.writeMu.Lock()
+	defer h.writeMu.Unlock()
+
+	var taskIDs []uint
+	swapped, err := h.Repo.SwapTaggedTasks(ctx, tag, recording(update, &taskIDs))
+	if err != nil {
+		return 0, err
+	}
+	h.recordLoaded(ctx, models.RevisionUpdated, taskIDs...)
+
+	return swapped, nil
+}
+
+func (h *HistoryRepo) AddDependency(ctx context.Context, taskID, dependencyID uint,
+	update func(task *models.TaskDomain) error) (*models.TaskDomain, error) {
+	h.writeMu.Lock()
+	defer h.writeMu.Unlock()
+
+	task, err := h.Repo.AddDependency(ctx, taskID, dependencyID, update)
+
+	return h.recordSwap(ctx, task, err)
+}
+
+func (h *HistoryRepo) RemoveDependency(ctx context.Context, taskID, dependencyID uint,
+	update func(task *models.TaskDomain) error) (*models.TaskDomain, error) {
+	h.writeMu.Lock()
+	defer h.writeMu.Unlock()
+
+	task, err := h.Repo.RemoveDependency(ctx, taskID, dependencyID, update)
+
+	return h.recordSwap(ctx, task, err)
+}
+
+func (h *HistoryRepo) StoreNextOccurrence(ctx context.Context, taskID uint, next *models.TaskDomain,
+	update func(task *models.TaskDomain) error) (*models.TaskDomain, error) {
+	h.writeMu.Lock()
+	defer h.writeMu.Unlock()
+
+	task, err := h.Repo.StoreNextOccurrence(ctx, taskID, next, update)
+	if err != nil {
+		return nil, err
+	}
+	// The task comes back unchanged when it already had a next occurrence;
+	// record skips both then.
+	if task.NextOccurrenceID != nil {
+		h.recordLoaded(ctx, models.RevisionCreated, *task.NextOccurrenceID)
+	}
+	h.record(ctx, models.RevisionUpdated, taskID, task)
+
+	return task, nil
+}
+
+func (h *HistoryRepo) DeleteTask(ctx context.Context, taskID uint, mode string, deletedAt time.Time,
+	detach func(task *models.TaskDomain) error) ([]uint, error) {
+	h.writeMu.Lock()
+	defer h.writeMu.Unlock()
+
+	var detached []uint
+	deleted, err := h.Repo.DeleteTask(ctx, taskID, mode, deletedAt, recording(orNop(detach), &detached))
+	if err != nil {
+		return nil, err
+	}
+	h.recordDelete(ctx, deleted, detached)
+
+	return deleted, nil
+}
+
+func (h *HistoryRepo) CompareAndDeleteTask(ctx context.Context, taskID uint, version uint64, mode string,
+	deletedAt time.Time, detach func(task *models.TaskDomain) error) ([]uint, error) {
+	h.writeMu.Lock()
+	defer h.writeMu.Unlock()
+
+	var detached []uint
+	deleted, err := h.Repo.CompareAndDeleteTask(ctx, taskID, version, mode, deletedAt,
+		recording(orNop(detach), &detached))
+	if err != nil {
+		return nil, err
+	}
+	h.recordDelete(ctx, deleted, detached)
+
+	return deleted, nil
+}
+
+func (h *HistoryRepo) RestoreTask(ctx context.Context, taskID uint,
+	update func(task *models.TaskDomain) error) ([]uint, error) {
+	h.writeMu.Lock()
+	defer h.writeMu.Unlock()
+
+	restored, err := h.Repo.RestoreTask(ctx, taskID, update)
+	if err != nil {
+		return nil, err
+	}
+	h.recordLoaded(ctx, models.RevisionRestored, restored...)
+
+	return restored, nil
+}
+
+// A task written several times within the transaction gets one revision.
+func (h *HistoryRepo) Atomically(ctx context.Context, fn func(tx *repoTasks.Tx) error) error {
+	h.writeMu.Lock()
+	defer h.writeMu.Unlock()
+
+	var created, updated, deleted []uint
+	err := h.Repo.Atomically(ctx, func(tx *repoTasks.Tx) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		created, updated, deleted = tx.Written()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	h.recordLoaded(ctx, models.RevisionCreated, created...)
+	h.recordLoaded(ctx, models.RevisionUpdated, updated...)
+	for _, taskID := range deleted {
+		h.record(ctx, models.RevisionDeleted, taskID, nil)
+	}
+
+	return nil
+}
+
+func (h *HistoryRepo) recordSwap(ctx context.Context, task *models.TaskDomain, err error) (*models.TaskDomain, error) {
+	if err != nil {
+		return nil, err
+	}
+	h.record(ctx, models.RevisionUpdated, task.ID, task)
+
+	return task, nil
+}
+
+func (h *HistoryRepo) recordDelete(ctx context.Context, deleted, detached []uint) {
+	for _, taskID := range deleted {
+		h.record(ctx, models.RevisionDeleted, taskID, nil)
+	}
+	h.recordLoaded(ctx, models.RevisionUpdated, detached...)
+}
+
+func (h *HistoryRepo) recordLoaded(ctx context.Context, op string, taskIDs ...uint) {
+	for _, taskID := range taskIDs {
+		task, err := h.Repo.LoadTask(ctx, taskID)
+		if err != nil {
+			continue
+		}
+		h.record(ctx, op, taskID, task)
+	}
+}
+
+// The write is already committed, so a failure is only logged.
+func (h *HistoryRepo) record(ctx context.Context, op string, taskID uint, task *models.TaskDomain) {
+	var snapshot *models.TaskDomain
+	if task != nil {
+		snapshot = snapshotTask(task)
+	}
+	err := h.revisions.StoreRevision(ctx, &models.Revision{
+		TaskID:     taskID,
+		Op:         op,
+		Task:       snapshot,
+		Actor:      actor.From(ctx),
+		RecordedAt: h.clock.Now().UTC(),
+	})
+	if err != nil {
+		slog.Error("failed to record task revision", slog.Any("task_id", taskID), slog.Any("error", err))
+	}
+}
+
+func orNop(update func(task *models.TaskDomain) error) func(task *models.TaskDomain) error {
+	if update == nil {
+		return func(task *models.TaskDomain) error { return nil }
+	}
+
+	return update
+}
+
+// When reminders fired is bookkeeping and left out.
+func snapshotTask(task *models.TaskDomain) *models.TaskDomain {
+	var snapshot models.TaskDomain
+	data, err := json.Marshal(task)
+	if err == nil {
+		err = json.Unmarshal(data, &snapshot)
+	}
+	if err != nil {
+		snapshot = *task
+		snapshot.Reminders = append([]models.Reminder(nil), task.Reminders...)
+	}
+	for i := range snapshot.Reminders {
+		snapshot.Reminders[i].FiredAt = nil
+	}
+
+	return &snapshot
+}
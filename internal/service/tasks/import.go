@@ -0,0 +1,245 @@
+package tasks
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/avraam311/tasks-service/internal/models"
+	repoTasks "github.com/avraam311/tasks-service/internal/repository/tasks"
+)
+
+const MaxImportErrors = 1000
+
+var errImportAborted = errors.New("import aborted")
+
+type importer struct {
+	s       *Service
+	report  *models.ImportReport
+	create  func(ctx context.Context, dto *models.TaskDTO) (uint, error)
+	created map[string]uint
+	// pending holds the rows waiting for the row with their ParentUID.
+	pending map[string][]*models.ImportRow
+	dryRun  bool
+	abort   bool
+}
+
+// With abort the file is read whole and imported in one repository
+// transaction, so a failed row leaves nothing created. An error of next or
+// the repository stops the import and is returned with the report so far.
+func (s *Service) ImportTasks(ctx context.Context, next func() (*models.ImportRow, error), dryRun,
+	abort bool) (*models.ImportReport, error) {
+	im := &importer{
+		s:       s,
+		report:  &models.ImportReport{DryRun: dryRun, Errors: []models.ImportError{}},
+		create:  s.CreateTask,
+		created: make(map[string]uint),
+		pending: make(map[string][]*models.ImportRow),
+		dryRun:  dryRun,
+		abort:   abort,
+	}
+	if abort && !dryRun {
+		return s.importAtomically(ctx, im, next)
+	}
+
+	for {
+		row, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return im.report, fmt.Errorf("service/import.go - %w", err)
+		}
+		if stop, err := im.feed(ctx, row); stop {
+			return im.report, err
+		}
+	}
+	if _, err := im.finish(ctx); err != nil {
+		return im.report, err
+	}
+
+	return im.report, nil
+}
+
+// Events and next occurrences of recurring tasks wait until the transaction
+// is committed.
+func (s *Service) importAtomically(ctx context.Context, im *importer,
+	next func() (*models.ImportRow, error)) (*models.ImportReport, error) {
+	var rows []*models.ImportRow
+	for {
+		row, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return im.report, fmt.Errorf("service/import.go - %w", err)
+		}
+		rows = append(rows, row)
+	}
+
+	type createdTask struct {
+		id   uint
+		task *models.TaskDomain
+	}
+	var created []createdTask
+	err := s.repo.Atomically(ctx, func(tx *repoTasks.Tx) error {
+		im.create = func(ctx context.Context, dto *models.TaskDTO) (uint, error) {
+			task, err := s.newTask(dto)
+			if err != nil {
+				return 0, err
+			}
+			taskID, err := tx.StoreTask(ctx, task)
+			if err != nil {
+				return 0, parentError(err)
+			}
+			created = append(created, createdTask{id: taskID, task: task})
+			return taskID, nil
+		}
+		for _, row := range rows {
+			if stop, err := im.feed(ctx, row); stop {
+				return cmp.Or(err, errImportAborted)
+			}
+		}
+		if stop, err := im.finish(ctx); stop {
+			return cmp.Or(err, errImportAborted)
+		}
+		return nil
+	})
+	if err != nil {
+		im.report.Imported = 0
+		if errors.Is(err, errImportAborted) {
+			return im.report, nil
+		}
+		return im.report, fmt.Errorf("service/import.go - %w", err)
+	}
+	for _, c := range created {
+		s.commitCreate(ctx, c.id, c.task)
+	}
+
+	return im.report, nil
+}
+
+// feed imports the row, unless it has to wait for its parent. It returns
+// true when the import has to stop.
+func (im *importer) feed(ctx context.Context, row *models.ImportRow) (bool, error) {
+	im.report.Rows++
+	if _, ok := im.created[row.ParentUID]; row.Err == nil && row.ParentUID != "" && !ok {
+		im.pending[row.ParentUID] = append(im.pending[row.ParentUID], row)
+		return false, nil
+	}
+
+	return im.importRow(ctx, row)
+}
+
+// finish imports the rows whose parent never came. Unless their parents are
+// pending too, they fall back to the ParentID of their task.
+func (im *importer) finish(ctx context.Context) (bool, error) {
+	for len(im.pending) > 0 {
+		waiting := make(map[string]bool)
+		for _, rows := range im.pending {
+			for _, row := range rows {
+				if row.UID != "" {
+					waiting[row.UID] = true
+				}
+			}
+		}
+		var ready []*models.ImportRow
+		for parentUID, rows := range im.pending {
+			if !waiting[parentUID] {
+				ready = append(ready, rows...)
+				delete(im.pending, parentUID)
+			}
+		}
+		// Only a cycle of rows is left, none of which can come first.
+		if len(ready) == 0 {
+			for parentUID, rows := range im.pending {
+				ready = append(ready, rows...)
+				delete(im.pending, parentUID)
+			}
+		}
+		slices.SortFunc(ready, func(a, b *models.ImportRow) int { return cmp.Compare(a.Row, b.Row) })
+		for _, row := range ready {
+			if stop, err := im.importRow(ctx, row); stop {
+				return true, err
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// importRow reports the row and imports the rows waiting for it.
+func (im *importer) importRow(ctx context.Context, row *models.ImportRow) (bool, error) {
+	err := row.Err
+	if err == nil {
+		var taskID uint
+		taskID, err = im.importTask(ctx, row)
+		var validationErr *ValidationError
+		if err != nil && !errors.As(err, &validationErr) {
+			return true, fmt.Errorf("service/import.go - row %d: %w", row.Row, err)
+		}
+		if err == nil && row.UID != "" {
+			im.created[row.UID] = taskID
+		}
+	}
+	if err != nil {
+		im.report.Failed++
+		if len(im.report.Errors) < MaxImportErrors {
+			im.report.Errors = append(im.report.Errors, models.ImportError{Row: row.Row, Err: err})
+		}
+		if im.abort {
+			im.report.Aborted = true
+			return true, nil
+		}
+		return false, nil
+	}
+
+	im.report.Imported++
+	if row.UID == "" {
+		return false, nil
+	}
+	children := im.pending[row.UID]
+	delete(im.pending, row.UID)
+	for _, child := range children {
+		if stop, err := im.importRow(ctx, child); stop {
+			return true, err
+		}
+	}
+
+	return false, nil
+}
+
+func (im *importer) importTask(ctx context.Context, row *models.ImportRow) (uint, error) {
+	dto := row.Task
+	if parentID, ok := im.created[row.ParentUID]; ok && row.ParentUID != "" {
+		// On a dry run the parent was not created, so only its row is known.
+		if im.dryRun {
+			_, err := im.s.newTask(dto)
+			return 0, err
+		}
+		dto.ParentID = &parentID
+	} else if row.ParentUID != "" && dto.ParentID == nil {
+		return 0, parentError(repoTasks.ErrParentNotFound)
+	}
+	if !im.dryRun {
+		return im.create(ctx, dto)
+	}
+
+	if _, err := im.s.newTask(dto); err != nil {
+		return 0, err
+	}
+	if dto.ParentID != nil {
+		_, err := im.s.repo.LoadTask(ctx, *dto.ParentID)
+		if errors.Is(err, repoTasks.ErrTaskNotFound) {
+			return 0, parentError(repoTasks.ErrParentNotFound)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return 0, nil
+}
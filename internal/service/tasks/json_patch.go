@@ -0,0 +1,227 @@
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	patchOpAdd     = "add"
+	patchOpRemove  = "remove"
+	patchOpReplace = "replace"
+	patchOpTest    = "test"
+)
+
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// PatchTestError reports a failed JSON Patch "test" operation.
+type PatchTestError struct {
+	Index int    `json:"index"`
+	Path  string `json:"path"`
+}
+
+func (e *PatchTestError) Error() string {
+	return fmt.Sprintf("patch test operation %d failed at %q", e.Index, e.Path)
+}
+
+func applyJSONPatch(doc interface{}, ops []patchOperation) (interface{}, error) {
+	for i, op := range ops {
+		tokens, err := parsePointer(op.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: operation %d: %s", ErrInvalidPatch, i, err.Error())
+		}
+
+		var value interface{}
+		if op.Op != patchOpRemove {
+			if op.Value == nil {
+				return nil, fmt.Errorf("%w: operation %d: missing value", ErrInvalidPatch, i)
+			}
+			if err := json.Unmarshal(op.Value, &value); err != nil {
+				return nil, fmt.Errorf("%w: operation %d: %s", ErrInvalidPatch, i, err.Error())
+			}
+		}
+
+		switch op.Op {
+		case patchOpTest:
+			current, err := getPointer(doc, tokens)
+			if err != nil || !reflect.DeepEqual(current, value) {
+				return nil, &PatchTestError{Index: i, Path: op.Path}
+			}
+		case patchOpAdd:
+			doc, err = updatePointer(doc, tokens, value, addAt)
+		case patchOpRemove:
+			if len(tokens) == 0 {
+				return nil, fmt.Errorf("%w: operation %d: cannot remove the whole document", ErrInvalidPatch, i)
+			}
+			doc, err = updatePointer(doc, tokens, nil, removeAt)
+		case patchOpReplace:
+			doc, err = updatePointer(doc, tokens, value, replaceAt)
+		default:
+			err = fmt.Errorf("unsupported op %q", op.Op)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: operation %d: %s", ErrInvalidPatch, i, err.Error())
+		}
+	}
+
+	return doc, nil
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into unescaped reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("path %q must start with /", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+func getPointer(node interface{}, tokens []string) (interface{}, error) {
+	for _, token := range tokens {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			child, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("path member %q not found", token)
+			}
+			node = child
+		case []interface{}:
+			idx, err := arrayIndex(token, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = n[idx]
+		default:
+			return nil, fmt.Errorf("path member %q not found", token)
+		}
+	}
+
+	return node, nil
+}
+
+type leafUpdate func(parent interface{}, token string, value interface{}) (interface{}, error)
+
+// updatePointer returns the document, which leaf may have reallocated.
+func updatePointer(node interface{}, tokens []string, value interface{}, leaf leafUpdate) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	if len(tokens) == 1 {
+		return leaf(node, tokens[0], value)
+	}
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[tokens[0]]
+		if !ok {
+			return nil, fmt.Errorf("path member %q not found", tokens[0])
+		}
+		child, err := updatePointer(child, tokens[1:], value, leaf)
+		if err != nil {
+			return nil, err
+		}
+		n[tokens[0]] = child
+		return n, nil
+	case []interface{}:
+		idx, err := arrayIndex(tokens[0], len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		child, err := updatePointer(n[idx], tokens[1:], value, leaf)
+		if err != nil {
+			return nil, err
+		}
+		n[idx] = child
+		return n, nil
+	default:
+		return nil, fmt.Errorf("path member %q not found", tokens[0])
+	}
+}
+
+func addAt(parent interface{}, token string, value interface{}) (interface{}, error) {
+	switch n := parent.(type) {
+	case map[string]interface{}:
+		n[token] = value
+		return n, nil
+	case []interface{}:
+		if token == "-" {
+			return append(n, value), nil
+		}
+		idx, err := arrayIndex(token, len(n))
+		if err != nil {
+			return nil, err
+		}
+		n = append(n, nil)
+		copy(n[idx+1:], n[idx:])
+		n[idx] = value
+		return n, nil
+	default:
+		return nil, fmt.Errorf("cannot add member %q to a scalar", token)
+	}
+}
+
+func removeAt(parent interface{}, token string, _ interface{}) (interface{}, error) {
+	switch n := parent.(type) {
+	case map[string]interface{}:
+		if _, ok := n[token]; !ok {
+			return nil, fmt.Errorf("path member %q not found", token)
+		}
+		delete(n, token)
+		return n, nil
+	case []interface{}:
+		idx, err := arrayIndex(token, len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		return append(n[:idx], n[idx+1:]...), nil
+	default:
+		return nil, fmt.Errorf("path member %q not found", token)
+	}
+}
+
+func replaceAt(parent interface{}, token string, value interface{}) (interface{}, error) {
+	switch n := parent.(type) {
+	case map[string]interface{}:
+		if _, ok := n[token]; !ok {
+			return nil, fmt.Errorf("path member %q not found", token)
+		}
+		n[token] = value
+		return n, nil
+	case []interface{}:
+		idx, err := arrayIndex(token, len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		n[idx] = value
+		return n, nil
+	default:
+		return nil, fmt.Errorf("path member %q not found", token)
+	}
+}
+
+func arrayIndex(token string, maxIdx int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	idx, err := strconv.Atoi(token)
+	if err != nil || idx < 0 || idx > maxIdx {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+
+	return idx, nil
+}
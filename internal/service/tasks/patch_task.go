@@ -0,0 +1,177 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+var ErrInvalidPatch = errors.New("invalid patch")
+
+// The document carries these so that a "test" can make a patch conditional,
+// but a patch may not change them.
+var readOnlyMembers = []string{"id", "version"}
+
+// PatchTask applies a JSON Merge Patch (RFC 7396).
+func (s *Service) PatchTask(ctx context.Context, taskID uint, patch []byte, force bool) (*models.TaskDomain, error) {
+	var patchDoc interface{}
+	if err := json.Unmarshal(patch, &patchDoc); err != nil {
+		return nil, fmt.Errorf("service/patch_task.go - %w: %s", ErrInvalidPatch, err.Error())
+	}
+	if _, ok := patchDoc.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("service/patch_task.go - %w: merge patch must be a JSON object", ErrInvalidPatch)
+	}
+
+	task, err := s.patchTask(ctx, taskID, force, func(doc interface{}) (interface{}, error) {
+		return applyMergePatch(doc, patchDoc), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("service/patch_task.go - %w", err)
+	}
+
+	return task, nil
+}
+
+// JSONPatchTask applies a JSON Patch (RFC 6902). If any operation fails,
+// including a "test", nothing is written.
+func (s *Service) JSONPatchTask(ctx context.Context, taskID uint, patch []byte, force bool) (*models.TaskDomain, error) {
+	var ops []patchOperation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("service/patch_task.go - %w: %s", ErrInvalidPatch, err.Error())
+	}
+
+	task, err := s.patchTask(ctx, taskID, force, func(doc interface{}) (interface{}, error) {
+		for i, op := range ops {
+			tokens, err := parsePointer(op.Path)
+			if err == nil && op.Op != patchOpTest && len(tokens) > 0 && slices.Contains(readOnlyMembers, tokens[0]) {
+				return nil, fmt.Errorf("%w: operation %d: %s is read-only", ErrInvalidPatch, i, tokens[0])
+			}
+		}
+		return applyJSONPatch(doc, ops)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("service/patch_task.go - %w", err)
+	}
+
+	return task, nil
+}
+
+// The read-modify-write happens inside one SwapTask so a concurrent update
+// cannot be lost.
+func (s *Service) patchTask(ctx context.Context, taskID uint, force bool,
+	apply func(doc interface{}) (interface{}, error)) (*models.TaskDomain, error) {
+	var finished bool
+	task, err := s.repo.SwapTask(ctx, taskID, finishing(func(task *models.TaskDomain) error {
+		doc, err := taskToDocument(task)
+		if err != nil {
+			return err
+		}
+		readOnly := maps.Clone(doc.(map[string]interface{}))
+		doc, err = apply(doc)
+		if err != nil {
+			return err
+		}
+		if obj, ok := doc.(map[string]interface{}); ok {
+			for _, member := range readOnlyMembers {
+				if !reflect.DeepEqual(obj[member], readOnly[member]) {
+					return fmt.Errorf("%w: %s is read-only", ErrInvalidPatch, member)
+				}
+				delete(obj, member)
+			}
+		}
+		patched, err := documentToTask(doc)
+		if err != nil {
+			return err
+		}
+		// The document carries both status and finished, only the one the
+		// patch changed may move the task.
+		switch {
+		case patched.Finished != nil && *patched.Finished == task.Finished:
+			patched.Finished = nil
+		case patched.Finished != nil && patched.Status == task.Status:
+			patched.Status = ""
+		}
+		if err := prepareTask(patched); err != nil {
+			return err
+		}
+
+		return s.applyDTO(task, patched, force)
+	}, &finished))
+	if err != nil {
+		return nil, parentError(err)
+	}
+	s.publishUpdate(task, finished)
+
+	return s.recur(ctx, task), nil
+}
+
+func taskToDocument(task *models.TaskDomain) (interface{}, error) {
+	data, err := json.Marshal(models.TaskDTO{
+		Header:      task.Header,
+		Description: task.Description,
+		Status:      task.Status,
+		Finished:    &task.Finished,
+		DueAt:       task.DueAt,
+		Tags:        task.Tags,
+		ParentID:    task.ParentID,
+		Recurrence:  task.Recurrence,
+		TimeZone:    task.TimeZone,
+		Reminders:   reminderOffsets(task),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	doc["id"] = float64(task.ID)
+	doc["version"] = float64(task.Version)
+
+	return doc, nil
+}
+
+func documentToTask(doc interface{}) (*models.TaskDTO, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var task models.TaskDTO
+	if err := decoder.Decode(&task); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPatch, err.Error())
+	}
+
+	return &task, nil
+}
+
+func applyMergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = applyMergePatch(targetObj[key], value)
+	}
+
+	return targetObj
+}
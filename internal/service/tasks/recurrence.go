@@ -0,0 +1,348 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+)
+
+const (
+	DefaultOccurrencePreview = 10
+	MaxOccurrencePreview     = 100
+)
+
+var (
+	ErrInvalidRecurrence = errors.New("invalid recurrence rule")
+	ErrNotRecurring      = errors.New("task is not recurring")
+)
+
+var untilLayouts = []string{"20060102T150405Z", "20060102"}
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// Recurrence is a subset of the iCalendar RRULE (RFC 5545). Weeks start on
+// Monday.
+type Recurrence struct {
+	Freq       string
+	Interval   int
+	ByDay      []time.Weekday
+	ByMonthDay []int
+	Count      int
+	Until      *time.Time
+}
+
+func ParseRecurrence(rule string) (*Recurrence, error) {
+	rule = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(rule)), "RRULE:")
+	recurrence := &Recurrence{Interval: 1}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(rule, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("%w: %q is not KEY=VALUE", ErrInvalidRecurrence, part)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("%w: %s is given twice", ErrInvalidRecurrence, key)
+		}
+		seen[key] = true
+
+		var err error
+		switch key {
+		case "FREQ":
+			if value != FreqDaily && value != FreqWeekly && value != FreqMonthly {
+				err = errors.New("FREQ must be DAILY, WEEKLY or MONTHLY")
+			}
+			recurrence.Freq = value
+		case "INTERVAL":
+			recurrence.Interval, err = parsePositive(key, value)
+		case "COUNT":
+			recurrence.Count, err = parsePositive(key, value)
+		case "UNTIL":
+			recurrence.Until, err = parseUntil(value)
+		case "BYDAY":
+			recurrence.ByDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			recurrence.ByMonthDay, err = parseByMonthDay(value)
+		default:
+			err = fmt.Errorf("%s is not supported", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidRecurrence, err.Error())
+		}
+	}
+
+	switch {
+	case recurrence.Freq == "":
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRecurrence)
+	case recurrence.Count > 0 && recurrence.Until != nil:
+		return nil, fmt.Errorf("%w: COUNT and UNTIL cannot be combined", ErrInvalidRecurrence)
+	case len(recurrence.ByDay) > 0 && recurrence.Freq != FreqWeekly:
+		return nil, fmt.Errorf("%w: BYDAY needs FREQ=WEEKLY", ErrInvalidRecurrence)
+	case len(recurrence.ByMonthDay) > 0 && recurrence.Freq != FreqMonthly:
+		return nil, fmt.Errorf("%w: BYMONTHDAY needs FREQ=MONTHLY", ErrInvalidRecurrence)
+	}
+
+	return recurrence, nil
+}
+
+func parsePositive(key, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer", key)
+	}
+
+	return n, nil
+}
+
+func parseUntil(value string) (*time.Time, error) {
+	for _, layout := range untilLayouts {
+		until, err := time.Parse(layout, value)
+		if err != nil {
+			continue
+		}
+		if layout == "20060102" {
+			// A date includes the whole day.
+			until = until.Add(24*time.Hour - time.Nanosecond)
+		}
+		return &until, nil
+	}
+
+	return nil, errors.New("UNTIL must look like 20240510 or 20240510T180000Z")
+}
+
+func parseByDay(value string) ([]time.Weekday, error) {
+	var days []time.Weekday
+	for _, name := range strings.Split(value, ",") {
+		day, ok := weekdays[name]
+		if !ok {
+			return nil, fmt.Errorf("BYDAY has unknown weekday %q", name)
+		}
+		if !slices.Contains(days, day) {
+			days = append(days, day)
+		}
+	}
+
+	return days, nil
+}
+
+func parseByMonthDay(value string) ([]int, error) {
+	var days []int
+	for _, part := range strings.Split(value, ",") {
+		day, err := strconv.Atoi(part)
+		if err != nil || day == 0 || day < -31 || day > 31 {
+			return nil, fmt.Errorf("BYMONTHDAY must be between 1 and 31 or -31 and -1, got %q", part)
+		}
+		if !slices.Contains(days, day) {
+			days = append(days, day)
+		}
+	}
+
+	return days, nil
+}
+
+// Occurrences returns up to n dates that follow prev, the due date of
+// occurrence number index of the series.
+func (r *Recurrence) Occurrences(prev time.Time, index, n int) []time.Time {
+	var dates []time.Time
+	for len(dates) < n {
+		index++
+		if r.Count > 0 && index > r.Count {
+			break
+		}
+		next, ok := r.next(prev)
+		if !ok || (r.Until != nil && next.After(*r.Until)) {
+			break
+		}
+		dates = append(dates, next)
+		prev = next
+	}
+
+	return dates
+}
+
+func (r *Recurrence) next(prev time.Time) (time.Time, bool) {
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, prev.Hour(), prev.Minute(), prev.Second(), prev.Nanosecond(),
+			prev.Location())
+	}
+
+	switch r.Freq {
+	case FreqWeekly:
+		days := r.ByDay
+		if len(days) == 0 {
+			days = []time.Weekday{prev.Weekday()}
+		}
+		offsets := make([]int, len(days))
+		for i, day := range days {
+			offsets[i] = mondayOffset(day)
+		}
+		slices.Sort(offsets)
+
+		weekStart := at(prev.Year(), prev.Month(), prev.Day()-mondayOffset(prev.Weekday()))
+		for _, offset := range offsets {
+			if candidate := weekStart.AddDate(0, 0, offset); candidate.After(prev) {
+				return candidate, true
+			}
+		}
+		return weekStart.AddDate(0, 0, 7*r.Interval+offsets[0]), true
+	case FreqMonthly:
+		days := r.ByMonthDay
+		if len(days) == 0 {
+			days = []int{prev.Day()}
+		}
+		// The days may never come up in the months the interval visits, such
+		// as day 30 every 12 months from February.
+		year, month := prev.Year(), prev.Month()
+		for range 4800 {
+			daysIn := at(year, month+1, 0).Day()
+			var candidates []int
+			for _, day := range days {
+				if day < 0 {
+					day += daysIn + 1
+				}
+				if day >= 1 && day <= daysIn {
+					candidates = append(candidates, day)
+				}
+			}
+			slices.Sort(candidates)
+			for _, day := range candidates {
+				if candidate := at(year, month, day); candidate.After(prev) {
+					return candidate, true
+				}
+			}
+			first := at(year, month+time.Month(r.Interval), 1)
+			year, month = first.Year(), first.Month()
+		}
+		return time.Time{}, false
+	default:
+		return prev.AddDate(0, 0, r.Interval), true
+	}
+}
+
+func mondayOffset(day time.Weekday) int {
+	return (int(day) + 6) % 7
+}
+
+func (s *Service) PreviewOccurrences(ctx context.Context, taskID uint, n int) ([]time.Time, error) {
+	if n <= 0 {
+		n = DefaultOccurrencePreview
+	}
+	if n > MaxOccurrencePreview {
+		n = MaxOccurrencePreview
+	}
+
+	task, err := s.repo.LoadTask(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("service/recurrence.go - %w", err)
+	}
+	if task.Recurrence == "" || task.DueAt == nil {
+		return nil, fmt.Errorf("service/recurrence.go - %w", ErrNotRecurring)
+	}
+	recurrence, err := ParseRecurrence(task.Recurrence)
+	if err != nil {
+		return nil, fmt.Errorf("service/recurrence.go - %w", err)
+	}
+
+	dates := recurrence.Occurrences(localDue(task), occurrence(task), n)
+	for i := range dates {
+		dates[i] = dates[i].UTC()
+	}
+
+	return dates, nil
+}
+
+// recur is safe to run after every write: only a finished task without a next
+// occurrence gets one. It runs after the write is committed, so a failure is
+// only logged.
+func (s *Service) recur(ctx context.Context, task *models.TaskDomain) *models.TaskDomain {
+	stored, err := s.storeNextOccurrence(ctx, task)
+	if err != nil {
+		slog.Error("failed to create next occurrence", slog.Any("task_id", task.ID), slog.Any("error", err))
+		return task
+	}
+
+	return stored
+}
+
+func (s *Service) storeNextOccurrence(ctx context.Context, task *models.TaskDomain) (*models.TaskDomain, error) {
+	if task.Status != models.StatusDone || task.Recurrence == "" || task.DueAt == nil ||
+		task.NextOccurrenceID != nil {
+		return task, nil
+	}
+	recurrence, err := ParseRecurrence(task.Recurrence)
+	if err != nil {
+		return nil, err
+	}
+	dates := recurrence.Occurrences(localDue(task), occurrence(task), 1)
+	if len(dates) == 0 {
+		return task, nil
+	}
+	dueAt := dates[0].UTC()
+
+	now := s.clock.Now().UTC()
+	next := &models.TaskDomain{
+		Header:      task.Header,
+		Description: task.Description,
+		Status:      models.StatusTodo,
+		DueAt:       &dueAt,
+		Tags:        slices.Clone(task.Tags),
+		ParentID:    task.ParentID,
+		Recurrence:  task.Recurrence,
+		TimeZone:    task.TimeZone,
+		Occurrence:  occurrence(task) + 1,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	for _, before := range reminderOffsets(task) {
+		next.Reminders = append(next.Reminders, models.Reminder{Before: before})
+	}
+
+	stored, err := s.repo.StoreNextOccurrence(ctx, task.ID, next, s.touch)
+	if err != nil {
+		return nil, err
+	}
+	// A concurrent write may have created the occurrence already, in which
+	// case the stored task is unchanged and there is nothing to report.
+	if stored.Version != task.Version {
+		s.publishLoaded(ctx, models.EventTaskCreated, *stored.NextOccurrenceID)
+		s.publishUpdate(stored, false)
+	}
+
+	return stored, nil
+}
+
+// A zone that no longer loads falls back to UTC.
+func localDue(task *models.TaskDomain) time.Time {
+	location, err := time.LoadLocation(task.TimeZone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	return task.DueAt.In(location)
+}
+
+// Tasks that got their rule before occurrences were numbered count as the
+// first one.
+func occurrence(task *models.TaskDomain) int {
+	return max(task.Occurrence, 1)
+}
@@ -0,0 +1,222 @@
+package tasks
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/avraam311/tasks-service/internal/models"
+	repoTasks "github.com/avraam311/tasks-service/internal/repository/tasks"
+)
+
+const (
+	MaxReminders = 5
+	// MaxReminderOffset also bounds how far ahead the scheduler looks.
+	MaxReminderOffset = 30 * 24 * time.Hour
+
+	DefaultReminderInterval = 30 * time.Second
+)
+
+var errReminderGone = errors.New("reminder is no longer due")
+
+// An error from Notify leaves the reminder unfired for the next pass.
+type Notifier interface {
+	Notify(ctx context.Context, reminder *models.DueReminder) error
+}
+
+// Each reminder is marked fired before it is handed to the notifier, so with
+// a persistent repository it fires once even across restarts.
+type ReminderScheduler struct {
+	service  *Service
+	notifier Notifier
+	interval time.Duration
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+func NewReminderScheduler(service *Service, notifier Notifier, interval time.Duration) *ReminderScheduler {
+	if interval <= 0 {
+		interval = DefaultReminderInterval
+	}
+
+	return &ReminderScheduler{
+		service:  service,
+		notifier: notifier,
+		interval: interval,
+	}
+}
+
+func (s *ReminderScheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go s.loop(ctx)
+}
+
+func (s *ReminderScheduler) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+func (s *ReminderScheduler) loop(ctx context.Context) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.FireDue(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("failed to fire reminders", slog.Any("error", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ReminderScheduler) FireDue(ctx context.Context) (int, error) {
+	now := s.service.clock.Now().UTC()
+	horizon := now.Add(MaxReminderOffset + time.Nanosecond)
+	tasks, err := s.service.repo.QueryTasks(ctx, &models.TaskQuery{
+		Statuses:  openStatuses(nil),
+		DueBefore: &horizon,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("service/reminders.go - %w", err)
+	}
+
+	fired := 0
+	var errs []error
+	for _, task := range tasks {
+		for _, reminder := range task.Reminders {
+			fireAt := task.DueAt.Add(-time.Duration(reminder.Before))
+			if reminder.FiredAt != nil || fireAt.After(now) {
+				continue
+			}
+			if ctx.Err() != nil {
+				return fired, ctx.Err()
+			}
+
+			err := s.fire(ctx, &models.DueReminder{
+				TaskID: task.ID,
+				Header: task.Header,
+				DueAt:  *task.DueAt,
+				Before: reminder.Before,
+				FireAt: fireAt,
+			}, now)
+			switch {
+			case errors.Is(err, errReminderGone):
+			case err != nil:
+				errs = append(errs, err)
+			default:
+				fired++
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fired, fmt.Errorf("service/reminders.go - %w", errors.Join(errs...))
+	}
+
+	return fired, nil
+}
+
+// If the notifier fails, the claim is released so a later pass retries.
+func (s *ReminderScheduler) fire(ctx context.Context, reminder *models.DueReminder, now time.Time) error {
+	err := s.service.repo.SwapReminders(ctx, reminder.TaskID, func(task *models.TaskDomain) error {
+		stored := findReminder(task, reminder)
+		if stored == nil || stored.FiredAt != nil || !slices.Contains(openStatuses(nil), task.Status) {
+			return errReminderGone
+		}
+		stored.FiredAt = &now
+		return nil
+	})
+	if errors.Is(err, repoTasks.ErrTaskNotFound) {
+		return errReminderGone
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.notifier.Notify(ctx, reminder); err != nil {
+		releaseErr := s.service.repo.SwapReminders(ctx, reminder.TaskID, func(task *models.TaskDomain) error {
+			stored := findReminder(task, reminder)
+			if stored == nil || stored.FiredAt == nil || !stored.FiredAt.Equal(now) {
+				return errReminderGone
+			}
+			stored.FiredAt = nil
+			return nil
+		})
+		if releaseErr != nil && !errors.Is(releaseErr, errReminderGone) {
+			return errors.Join(err, releaseErr)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// findReminder only matches while the task is still due when reminder was
+// computed for.
+func findReminder(task *models.TaskDomain, reminder *models.DueReminder) *models.Reminder {
+	if task.DueAt == nil || !task.DueAt.Equal(reminder.DueAt) {
+		return nil
+	}
+	for i := range task.Reminders {
+		if task.Reminders[i].Before == reminder.Before {
+			return &task.Reminders[i]
+		}
+	}
+
+	return nil
+}
+
+func normalizeReminders(offsets []models.Duration) []models.Duration {
+	if len(offsets) == 0 {
+		return nil
+	}
+	normalized := slices.Clone(offsets)
+	slices.SortFunc(normalized, func(a, b models.Duration) int {
+		return cmp.Compare(b, a)
+	})
+
+	return slices.Compact(normalized)
+}
+
+// Fired reminders stay fired unless the due date changes to dueAt.
+func applyReminders(task *models.TaskDomain, offsets []models.Duration, dueAt *time.Time) {
+	sameDue := (task.DueAt == nil && dueAt == nil) ||
+		(task.DueAt != nil && dueAt != nil && task.DueAt.Equal(*dueAt))
+
+	var reminders []models.Reminder
+	for _, before := range offsets {
+		reminder := models.Reminder{Before: before}
+		if sameDue {
+			for _, old := range task.Reminders {
+				if old.Before == before {
+					reminder.FiredAt = old.FiredAt
+				}
+			}
+		}
+		reminders = append(reminders, reminder)
+	}
+	task.Reminders = reminders
+}
+
+func reminderOffsets(task *models.TaskDomain) []models.Duration {
+	var offsets []models.Duration
+	for _, reminder := range task.Reminders {
+		offsets = append(offsets, reminder.Before)
+	}
+
+	return offsets
+}
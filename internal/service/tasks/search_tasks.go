@@ -0,0 +1,36 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+var ErrEmptySearchQuery = errors.New("empty search query")
+
+func (s *Service) SearchTasks(ctx context.Context, query string, limit int) ([]*models.SearchResult, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("service/search_tasks.go - %w", ErrEmptySearchQuery)
+	}
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	results, err := s.repo.SearchTasks(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("service/search_tasks.go - %w", err)
+	}
+
+	return results, nil
+}
@@ -2,24 +2,61 @@ package tasks
 
 import (
 	"context"
+	"time"
 
 	"github.com/avraam311/tasks-service/internal/models"
+	repoTasks "github.com/avraam311/tasks-service/internal/repository/tasks"
 )
 
 type Repo interface {
-	StoreTask(ctx context.Context, task *models.TaskDTO) (uint, error)
-	LoadAllTasks(ctx context.Context) ([]*models.TaskDomain, error)
+	StoreTask(ctx context.Context, task *models.TaskDomain) (uint, error)
+	QueryTasks(ctx context.Context, query *models.TaskQuery) ([]*models.TaskDomain, error)
 	LoadTask(ctx context.Context, taskID uint) (*models.TaskDomain, error)
-	SwapTask(ctx context.Context, taskID uint, task *models.TaskDTO) error
-	DeleteTask(ctx context.Context, taskID uint) error
+	SearchTasks(ctx context.Context, query string, limit int) ([]*models.SearchResult, error)
+	SwapTask(ctx context.Context, taskID uint,
+		update func(task *models.TaskDomain) error) (*models.TaskDomain, error)
+	SwapReminders(ctx context.Context, taskID uint,
+		update func(task *models.TaskDomain) error) error
+	UpsertTask(ctx context.Context, taskID uint,
+		update func(task *models.TaskDomain) error) (*models.TaskDomain, bool, error)
+	CompareAndSwapTask(ctx context.Context, taskID uint, version uint64,
+		update func(task *models.TaskDomain) error) (*models.TaskDomain, error)
+	LoadTags(ctx context.Context) ([]*models.TagCount, error)
+	SwapTaggedTasks(ctx context.Context, tag string,
+		update func(task *models.TaskDomain) error) (int, error)
+	LoadChildren(ctx context.Context, taskID uint) ([]*models.TaskDomain, error)
+	LoadTaskTrees(ctx context.Context, taskIDs []uint) ([]*models.TaskTree, error)
+	AddDependency(ctx context.Context, taskID, dependencyID uint,
+		update func(task *models.TaskDomain) error) (*models.TaskDomain, error)
+	RemoveDependency(ctx context.Context, taskID, dependencyID uint,
+		update func(task *models.TaskDomain) error) (*models.TaskDomain, error)
+	StoreNextOccurrence(ctx context.Context, taskID uint, next *models.TaskDomain,
+		update func(task *models.TaskDomain) error) (*models.TaskDomain, error)
+	DeleteTask(ctx context.Context, taskID uint, mode string, deletedAt time.Time,
+		detach func(task *models.TaskDomain) error) ([]uint, error)
+	CompareAndDeleteTask(ctx context.Context, taskID uint, version uint64, mode string,
+		deletedAt time.Time, detach func(task *models.TaskDomain) error) ([]uint, error)
+	LoadTrash(ctx context.Context) ([]*models.TaskDomain, error)
+	RestoreTask(ctx context.Context, taskID uint,
+		update func(task *models.TaskDomain) error) ([]uint, error)
+	PurgeTask(ctx context.Context, taskID uint) ([]uint, error)
+	PurgeTrash(ctx context.Context, before time.Time) ([]uint, error)
+	Atomically(ctx context.Context, fn func(tx *repoTasks.Tx) error) error
 }
 
 type Service struct {
-	repo Repo
+	repo      Repo
+	clock     Clock
+	workflow  *Workflow
+	publisher Publisher
 }
 
-func New(repo Repo) *Service {
+// publisher may be nil.
+func New(repo Repo, clock Clock, workflow *Workflow, publisher Publisher) *Service {
 	return &Service{
-		repo: repo,
+		repo:      repo,
+		clock:     clock,
+		workflow:  workflow,
+		publisher: publisher,
 	}
 }
@@ -2,27 +2,70 @@ package tasks
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/avraam311/tasks-service/internal/actor"
 	"github.com/avraam311/tasks-service/internal/mocks"
 	"github.com/avraam311/tasks-service/internal/models"
+	repoTasks "github.com/avraam311/tasks-service/internal/repository/tasks"
 )
 
+var testNow = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+// swapStored fakes Repo.SwapTask by running the update against a copy of
+// stored, or fails with repoErr when it is set.
+func swapStored(stored *models.TaskDomain, repoErr error) func(ctx context.Context, taskID uint,
+	update func(task *models.TaskDomain) error) (*models.TaskDomain, error) {
+	return func(ctx context.Context, taskID uint, update func(task *models.TaskDomain) error) (*models.TaskDomain, error) {
+		if repoErr != nil {
+			return nil, repoErr
+		}
+		task := *stored
+		if err := update(&task); err != nil {
+			return nil, err
+		}
+		task.Version++
+		return &task, nil
+	}
+}
+
+func swapReminders(stored *models.TaskDomain) func(ctx context.Context, taskID uint,
+	update func(task *models.TaskDomain) error) error {
+	return func(ctx context.Context, taskID uint, update func(task *models.TaskDomain) error) error {
+		task := *stored
+		task.Reminders = slices.Clone(stored.Reminders)
+		return update(&task)
+	}
+}
+
 func TestCreateTask(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockRepo(ctrl)
-	service := New(mockRepo)
+	service := New(mockRepo, &fakeClock{now: testNow}, DefaultWorkflow(), nil)
 
 	testTask := &models.TaskDTO{
 		Header:      "Test Task",
 		Description: "Test Description",
-		Finished:    false,
 	}
 
 	tests := []struct {
@@ -54,7 +97,13 @@ func TestCreateTask(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx := context.Background()
-			mockRepo.EXPECT().StoreTask(ctx, tt.task).Return(tt.repoReturnID, tt.repoReturnErr)
+			mockRepo.EXPECT().StoreTask(ctx, &models.TaskDomain{
+				Header:      tt.task.Header,
+				Description: tt.task.Description,
+				Status:      models.StatusTodo,
+				CreatedAt:   testNow,
+				UpdatedAt:   testNow,
+			}).Return(tt.repoReturnID, tt.repoReturnErr)
 
 			taskID, err := service.CreateTask(ctx, tt.task)
 
@@ -69,12 +118,111 @@ func TestCreateTask(t *testing.T) {
 	}
 }
 
+func TestCreateTask_Validation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepo(ctrl)
+	service := New(mockRepo, &fakeClock{now: testNow}, DefaultWorkflow(), nil)
+
+	tests := []struct {
+		name           string
+		task           *models.TaskDTO
+		expectedFields []FieldError
+	}{
+		{
+			name: "EmptyHeader",
+			task: &models.TaskDTO{},
+			expectedFields: []FieldError{
+				{Field: "header", Rule: RuleRequired, Message: "is required"},
+			},
+		},
+		{
+			name: "BlankHeader",
+			task: &models.TaskDTO{Header: " \t "},
+			expectedFields: []FieldError{
+				{Field: "header", Rule: RuleNotBlank, Message: "must not be blank"},
+			},
+		},
+		{
+			name: "MultilineHeaderAndControlDescription",
+			task: &models.TaskDTO{Header: "Line 1\nLine 2", Description: "bell\a"},
+			expectedFields: []FieldError{
+				{Field: "header", Rule: RuleSingleLine, Message: "must be a single line"},
+				{Field: "description", Rule: RuleNoControl, Message: "must not contain control characters"},
+			},
+		},
+		{
+			name: "TooLongHeader",
+			task: &models.TaskDTO{Header: strings.Repeat("ж", 201)},
+			expectedFields: []FieldError{
+				{Field: "header", Rule: RuleMax, Message: "must be at most 200 characters long"},
+			},
+		},
+		{
+			name: "BlankTag",
+			task: &models.TaskDTO{Header: "Task", Tags: []string{"ops", "  "}},
+			expectedFields: []FieldError{
+				{Field: "tags", Rule: RuleTags, Message: "must not contain blank tags"},
+			},
+		},
+		{
+			name: "ZeroDueAt",
+			task: &models.TaskDTO{Header: "Task", DueAt: &time.Time{}},
+			expectedFields: []FieldError{
+				{Field: "due_at", Rule: RuleTimeRange, Message: "must be between years 1970 and 9999"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.CreateTask(context.Background(), tt.task)
+
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.expectedFields, validationErr.Fields)
+			assert.Contains(t, err.Error(), "service/create_task.go -")
+		})
+	}
+
+	t.Run("MultilineDescription", func(t *testing.T) {
+		task := &models.TaskDTO{Header: strings.Repeat("ж", 200), Description: "Line 1\r\n\tLine 2"}
+		mockRepo.EXPECT().StoreTask(gomock.Any(), gomock.Any()).Return(uint(1), nil)
+
+		_, err := service.CreateTask(context.Background(), task)
+		require.NoError(t, err)
+	})
+}
+
+func TestCreateTask_NormalizesTags(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepo(ctrl)
+	service := New(mockRepo, &fakeClock{now: testNow}, DefaultWorkflow(), nil)
+
+	mockRepo.EXPECT().StoreTask(gomock.Any(), &models.TaskDomain{
+		Header:    "Task",
+		Status:    models.StatusTodo,
+		Tags:      []string{"backend", "ops"},
+		CreatedAt: testNow,
+		UpdatedAt: testNow,
+	}).Return(uint(1), nil)
+
+	_, err := service.CreateTask(context.Background(), &models.TaskDTO{
+		Header: "Task",
+		Tags:   []string{" Ops", "BACKEND", "ops "},
+	})
+	require.NoError(t, err)
+}
+
 func TestGetTask(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockRepo(ctrl)
-	service := New(mockRepo)
+	service := New(mockRepo, &fakeClock{now: testNow}, DefaultWorkflow(), nil)
 
 	testTask := &models.TaskDomain{
 		ID:          123,
@@ -132,7 +280,7 @@ func TestGetAllTasks(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockRepo(ctrl)
-	service := New(mockRepo)
+	service := New(mockRepo, &fakeClock{now: testNow}, DefaultWorkflow(), nil)
 
 	testTasks := []*models.TaskDomain{
 		{
@@ -182,13 +330,15 @@ func TestGetAllTasks(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx := context.Background()
-			mockRepo.EXPECT().LoadAllTasks(ctx).Return(tt.repoReturn, tt.repoReturnErr)
+			mockRepo.EXPECT().QueryTasks(ctx, &models.TaskQuery{}).
+				Return(tt.repoReturn, tt.repoReturnErr)
 
-			tasks, err := service.GetAllTasks(ctx)
+			page, err := service.GetAllTasks(ctx, &models.TaskQuery{})
 
 			if tt.expectedErr == "" {
 				require.NoError(t, err)
-				assert.Equal(t, tt.expectedTasks, tasks)
+				assert.Equal(t, tt.expectedTasks, page.Tasks)
+				assert.Empty(t, page.NextCursor)
 			} else {
 				require.Error(t, err)
 				assert.Contains(t, err.Error(), tt.expectedErr)
@@ -197,17 +347,184 @@ func TestGetAllTasks(t *testing.T) {
 	}
 }
 
+func TestGetAllTasks_Pagination(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepo(ctrl)
+	service := New(mockRepo, &fakeClock{now: testNow}, DefaultWorkflow(), nil)
+	ctx := context.Background()
+
+	sort := []models.SortKey{{Field: models.SortByHeader}}
+	firstPage := []*models.TaskDomain{
+		{ID: 3, Header: "A"},
+		{ID: 1, Header: "B"},
+		{ID: 2, Header: "C"},
+	}
+	mockRepo.EXPECT().QueryTasks(ctx, &models.TaskQuery{Sort: sort, Limit: 3}).Return(firstPage, nil)
+
+	page, err := service.GetAllTasks(ctx, &models.TaskQuery{Sort: sort, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, firstPage[:2], page.Tasks)
+	require.NotEmpty(t, page.NextCursor)
+
+	mockRepo.EXPECT().QueryTasks(ctx, &models.TaskQuery{
+		Sort:   sort,
+		Limit:  3,
+		Cursor: page.NextCursor,
+		After:  &models.TaskDomain{ID: 1, Header: "B"},
+	}).Return(firstPage[2:], nil)
+
+	page, err = service.GetAllTasks(ctx, &models.TaskQuery{Sort: sort, Limit: 2, Cursor: page.NextCursor})
+	require.NoError(t, err)
+	assert.Equal(t, firstPage[2:], page.Tasks)
+	assert.Empty(t, page.NextCursor)
+
+	t.Run("CursorForAnotherSort", func(t *testing.T) {
+		cursor := encodeCursor(firstPage[0], sort)
+
+		_, err := service.GetAllTasks(ctx, &models.TaskQuery{Cursor: cursor})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	t.Run("MalformedCursor", func(t *testing.T) {
+		_, err := service.GetAllTasks(ctx, &models.TaskQuery{Cursor: "not a cursor!"})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+}
+
+func TestGetAllTasks_Overdue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepo(ctrl)
+	service := New(mockRepo, &fakeClock{now: testNow}, DefaultWorkflow(), nil)
+	ctx := context.Background()
+
+	open := []string{models.StatusTodo, models.StatusInProgress, models.StatusInReview}
+	mockRepo.EXPECT().QueryTasks(ctx, &models.TaskQuery{
+		Overdue:   true,
+		Statuses:  open,
+		DueBefore: &testNow,
+	}).Return([]*models.TaskDomain{}, nil)
+
+	_, err := service.GetAllTasks(ctx, &models.TaskQuery{Overdue: true})
+	require.NoError(t, err)
+
+	t.Run("EarlierDueBeforeWins", func(t *testing.T) {
+		dueBefore := testNow.Add(-time.Hour)
+		mockRepo.EXPECT().QueryTasks(ctx, &models.TaskQuery{
+			Overdue:   true,
+			Statuses:  open,
+			DueBefore: &dueBefore,
+		}).Return([]*models.TaskDomain{}, nil)
+
+		_, err := service.GetAllTasks(ctx, &models.TaskQuery{Overdue: true, DueBefore: &dueBefore})
+		require.NoError(t, err)
+	})
+
+	t.Run("FinishedIsNeverOverdue", func(t *testing.T) {
+		finished := true
+		page, err := service.GetAllTasks(ctx, &models.TaskQuery{Overdue: true, Finished: &finished})
+		require.NoError(t, err)
+		assert.Empty(t, page.Tasks)
+	})
+
+	t.Run("ClosedIsNeverOverdue", func(t *testing.T) {
+		page, err := service.GetAllTasks(ctx, &models.TaskQuery{
+			Overdue:  true,
+			Statuses: []string{models.StatusDone, models.StatusCancelled},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, page.Tasks)
+	})
+
+	t.Run("StatusFilterNarrowsOverdue", func(t *testing.T) {
+		mockRepo.EXPECT().QueryTasks(ctx, &models.TaskQuery{
+			Overdue:   true,
+			Statuses:  []string{models.StatusInReview},
+			DueBefore: &testNow,
+		}).Return([]*models.TaskDomain{}, nil)
+
+		_, err := service.GetAllTasks(ctx, &models.TaskQuery{
+			Overdue:  true,
+			Statuses: []string{models.StatusInReview, models.StatusDone},
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestExportTasks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepo(ctrl)
+	service := New(mockRepo, &fakeClock{now: testNow}, DefaultWorkflow(), nil)
+	ctx := context.Background()
+
+	stored := make([]*models.TaskDomain, 0, MaxPageLimit+2)
+	for i := 1; i <= MaxPageLimit+2; i++ {
+		stored = append(stored, &models.TaskDomain{ID: uint(i), Header: fmt.Sprintf("Task %d", i)})
+	}
+	finished := true
+
+	t.Run("ReadsPageByPage", func(t *testing.T) {
+		firstPage := mockRepo.EXPECT().QueryTasks(ctx, &models.TaskQuery{
+			Finished: &finished,
+			Limit:    MaxPageLimit + 1,
+		}).Return(stored[:MaxPageLimit+1], nil)
+		mockRepo.EXPECT().QueryTasks(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, query *models.TaskQuery) ([]*models.TaskDomain, error) {
+				assert.Equal(t, &finished, query.Finished)
+				assert.Equal(t, MaxPageLimit+1, query.Limit)
+				assert.Equal(t, uint(MaxPageLimit), query.After.ID)
+				return stored[MaxPageLimit:], nil
+			}).After(firstPage)
+
+		var exported []*models.TaskDomain
+		err := service.ExportTasks(ctx, &models.TaskQuery{Finished: &finished, Limit: 10, Cursor: "ignored", Tree: true},
+			func(task *models.TaskDomain) error {
+				exported = append(exported, task)
+				return nil
+			})
+		require.NoError(t, err)
+		assert.Equal(t, stored, exported)
+	})
+
+	t.Run("WriteErrorStopsExport", func(t *testing.T) {
+		mockRepo.EXPECT().QueryTasks(ctx, &models.TaskQuery{Limit: MaxPageLimit + 1}).
+			Return(stored[:MaxPageLimit+1], nil)
+
+		calls := 0
+		err := service.ExportTasks(ctx, &models.TaskQuery{}, func(task *models.TaskDomain) error {
+			calls++
+			return assert.AnError
+		})
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("RepositoryError", func(t *testing.T) {
+		mockRepo.EXPECT().QueryTasks(ctx, &models.TaskQuery{Limit: MaxPageLimit + 1}).Return(nil, assert.AnError)
+
+		err := service.ExportTasks(ctx, &models.TaskQuery{}, func(task *models.TaskDomain) error {
+			return nil
+		})
+		assert.ErrorContains(t, err, "service/export.go -")
+	})
+}
+
 func TestUpdateTask(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockRepo(ctrl)
-	service := New(mockRepo)
+	service := New(mockRepo, &fakeClock{now: testNow}, DefaultWorkflow(), nil)
 
 	testTask := &models.TaskDTO{
 		Header:      "Updated Task",
 		Description: "Updated Description",
-		Finished:    true,
+		Status:      models.StatusDone,
 	}
 
 	tests := []struct {
@@ -236,9 +553,11 @@ func TestUpdateTask(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx := context.Background()
-			mockRepo.EXPECT().SwapTask(ctx, tt.taskID, tt.task).Return(tt.repoReturnErr)
+			mockRepo.EXPECT().SwapTask(ctx, tt.taskID, gomock.Any()).
+				DoAndReturn(swapStored(&models.TaskDomain{ID: tt.taskID, Status: models.StatusTodo, Version: 1},
+					tt.repoReturnErr))
 
-			err := service.UpdateTask(ctx, tt.taskID, tt.task)
+			err := service.UpdateTask(ctx, tt.taskID, tt.task, false)
 
 			if tt.expectedErr == "" {
 				require.NoError(t, err)
@@ -250,12 +569,85 @@ func TestUpdateTask(t *testing.T) {
 	}
 }
 
+func TestUpdateTask_Timestamps(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepo(ctrl)
+	clock := &fakeClock{now: testNow}
+	service := New(mockRepo, clock, DefaultWorkflow(), nil)
+
+	ctx := context.Background()
+	stored := &models.TaskDomain{ID: 1, Header: "Task", Status: models.StatusTodo, Version: 1, CreatedAt: testNow,
+		UpdatedAt: testNow}
+	mockRepo.EXPECT().SwapTask(ctx, uint(1), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, taskID uint, update func(task *models.TaskDomain) error) (*models.TaskDomain, error) {
+			updated, err := swapStored(stored, nil)(ctx, taskID, update)
+			stored = updated
+			return updated, err
+		}).Times(3)
+
+	finishedAt := testNow.Add(time.Hour)
+	clock.now = finishedAt
+	require.NoError(t, service.UpdateTask(ctx, 1, &models.TaskDTO{Header: "Task", Status: models.StatusDone}, false))
+	assert.Equal(t, testNow, stored.CreatedAt)
+	assert.Equal(t, finishedAt, stored.UpdatedAt)
+	require.NotNil(t, stored.CompletedAt)
+	assert.Equal(t, finishedAt, *stored.CompletedAt)
+
+	clock.now = finishedAt.Add(time.Hour)
+	require.NoError(t, service.UpdateTask(ctx, 1, &models.TaskDTO{Header: "Renamed"}, false))
+	assert.Equal(t, clock.now, stored.UpdatedAt)
+	assert.Equal(t, finishedAt, *stored.CompletedAt)
+
+	require.NoError(t, service.UpdateTask(ctx, 1, &models.TaskDTO{Header: "Renamed", Status: models.StatusTodo}, false))
+	assert.Nil(t, stored.CompletedAt)
+	assert.False(t, stored.Finished)
+}
+
+func TestUpsertTask(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepo(ctrl)
+	service := New(mockRepo, &fakeClock{now: testNow}, DefaultWorkflow(), nil)
+
+	ctx := context.Background()
+	task := &models.TaskDTO{Header: "New Task"}
+
+	var upserted models.TaskDomain
+	mockRepo.EXPECT().UpsertTask(ctx, uint(42), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, taskID uint, update func(task *models.TaskDomain) error) (*models.TaskDomain, bool, error) {
+			upserted.ID = taskID
+			return &upserted, true, update(&upserted)
+		})
+	created, err := service.UpsertTask(ctx, 42, task, false)
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, models.TaskDomain{
+		ID:        42,
+		Header:    "New Task",
+		Status:    models.StatusTodo,
+		CreatedAt: testNow,
+		UpdatedAt: testNow,
+	}, upserted)
+
+	mockRepo.EXPECT().UpsertTask(ctx, uint(42), gomock.Any()).Return(nil, false, assert.AnError)
+	_, err = service.UpsertTask(ctx, 42, task, false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "service/update_task.go -")
+
+	_, err = service.UpsertTask(ctx, 42, &models.TaskDTO{}, false)
+	var validationErr *ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+}
+
 func TestDeleteTask(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockRepo(ctrl)
-	service := New(mockRepo)
+	service := New(mockRepo, &fakeClock{now: testNow}, DefaultWorkflow(), nil)
 
 	tests := []struct {
 		name          string
@@ -280,12 +672,71 @@ func TestDeleteTask(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ctx := context.Background()
-			mockRepo.EXPECT().DeleteTask(ctx, tt.taskID).Return(tt.repoReturnErr)
+			mockRepo.EXPECT().DeleteTask(ctx, tt.taskID, models.ChildrenReject, testNow, gomock.Any()).Return(nil, tt.repoReturnErr)
+
+			err := service.DeleteTask(ctx, tt.taskID, "")
+
+			if tt.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+			}
+		})
+	}
+}
+
+func TestUpdateTaskIfMatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepo(ctrl)
+	service := New(mockRepo, &fakeClock{now: testNow}, DefaultWorkflow(), nil)
+
+	testTask := &models.TaskDTO{
+		Header:      "Updated Task",
+		Description: "Updated Description",
+		Status:      models.StatusDone,
+	}
+
+	tests := []struct {
+		name            string
+		version         uint64
+		repoReturnErr   error
+		expectedVersion uint64
+		expectedErr     string
+	}{
+		{
+			name:            "Success",
+			version:         3,
+			repoReturnErr:   nil,
+			expectedVersion: 4,
+			expectedErr:     "",
+		},
+		{
+			name:            "RepositoryError",
+			version:         3,
+			repoReturnErr:   assert.AnError,
+			expectedVersion: 0,
+			expectedErr:     "service/update_task.go -",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			stored := &models.TaskDomain{ID: 123, Version: tt.version}
+			mockRepo.EXPECT().CompareAndSwapTask(ctx, uint(123), tt.version, gomock.Any()).DoAndReturn(
+				func(ctx context.Context, taskID uint, version uint64,
+					update func(task *models.TaskDomain) error) (*models.TaskDomain, error) {
+					return swapStored(stored, tt.repoReturnErr)(ctx, taskID, update)
+				})
 
-			err := service.DeleteTask(ctx, tt.taskID)
+			version, err := service.UpdateTaskIfMatch(ctx, 123, tt.version, testTask, false)
 
 			if tt.expectedErr == "" {
 				require.NoError(t, err)
+				assert.Equal(t, tt.expectedVersion, version)
 			} else {
 				require.Error(t, err)
 				assert.Contains(t, err.Error(), tt.expectedErr)
@@ -293,3 +744,1814 @@ func TestDeleteTask(t *testing.T) {
 		})
 	}
 }
+
+func TestDeleteTaskIfMatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepo(ctrl)
+	service := New(mockRepo, &fakeClock{now: testNow}, DefaultWorkflow(), nil)
+
+	ctx := context.Background()
+	mockRepo.EXPECT().CompareAndDeleteTask(ctx, uint(123), uint64(3), models.ChildrenCascade, testNow, gomock.Any()).
+		Return(nil, assert.AnError)
+
+	err := service.DeleteTaskIfMatch(ctx, 123, 3, models.ChildrenCascade)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "service/delete_task.go -")
+}
+
+func TestPatchTask(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepo(ctrl)
+	service := New(mockRepo, &fakeClock{now: testNow}, DefaultWorkflow(), nil)
+
+	createdAt := testNow.Add(-time.Hour)
+	storedTask := &models.TaskDomain{
+		ID:          123,
+		Header:      "Test Task",
+		Description: "Test Description",
+		Status:      models.StatusTodo,
+		Version:     2,
+		CreatedAt:   createdAt,
+		UpdatedAt:   createdAt,
+	}
+	parentID := uint(7)
+
+	tests := []struct {
+		name         string
+		patch        string
+		repoMock     func()
+		expectedTask *models.TaskDomain
+		expectedErr  error
+	}{
+		{
+			name:  "StatusOnly",
+			patch: `{"status": "done"}`,
+			repoMock: func() {
+				mockRepo.EXPECT().SwapTask(gomock.Any(), uint(123), gomock.Any()).
+					DoAndReturn(swapStored(storedTask, nil))
+			},
+			expectedTask: &models.TaskDomain{
+				ID:          123,
+				Header:      "Test Task",
+				Description: "Test Description",
+				Status:      models.StatusDone,
+				Finished:    true,
+				Version:     3,
+				CreatedAt:   createdAt,
+				UpdatedAt:   testNow,
+				CompletedAt: &testNow,
+			},
+		},
+		{
+			name:  "IllegalStatus",
+			patch: `{"status": "in_review"}`,
+			repoMock: func() {
+				mockRepo.EXPECT().SwapTask(gomock.Any(), uint(123), gomock.Any()).
+					DoAndReturn(swapStored(storedTask, nil))
+			},
+			expectedErr: &TransitionError{
+				From:    models.StatusTodo,
+				To:      models.StatusInReview,
+				Allowed: []string{"start", "complete", "cancel"},
+			},
+		},
+		{
+			name:  "FinishedMovesToDone",
+			patch: `{"finished": true}`,
+			repoMock: func() {
+				mockRepo.EXPECT().SwapTask(gomock.Any(), uint(123), gomock.Any()).
+					DoAndReturn(swapStored(storedTask, nil))
+			},
+			expectedTask: &models.TaskDomain{
+				ID:          123,
+				Header:      "Test Task",
+				Description: "Test Description",
+				Status:      models.StatusDone,
+				Finished:    true,
+				Version:     3,
+				CreatedAt:   createdAt,
+				UpdatedAt:   testNow,
+				CompletedAt: &testNow,
+			},
+		},
+		{
+			name:  "FinishedContradictsStatus",
+			patch: `{"status": "in_progress", "finished": true}`,
+			repoMock: func() {
+				mockRepo.EXPECT().SwapTask(gomock.Any(), uint(123), gomock.Any()).
+					DoAndReturn(swapStored(storedTask, nil))
+			},
+			expectedErr: &ValidationError{Fields: []FieldError{{
+				Field:   "finished",
+				Rule:    RuleStatus,
+				Message: `must be false for status "in_progress"`,
+			}}},
+		},
+		{
+			name:  "KeepsUnpatchedFields",
+			patch: `{"header": "Renamed"}`,
+			repoMock: func() {
+				tagged := *storedTask
+				tagged.DueAt = &testNow
+				tagged.Tags = []string{"ops"}
+				tagged.ParentID = &parentID
+				mockRepo.EXPECT().SwapTask(gomock.Any(), uint(123), gomock.Any()).
+					DoAndReturn(swapStored(&tagged, nil))
+			},
+			expectedTask: &models.TaskDomain{
+				ID:          123,
+				Header:      "Renamed",
+				Description: "Test Description",
+				Status:      models.StatusTodo,
+				DueAt:       &testNow,
+				Tags:        []string{"ops"},
+				ParentID:    &parentID,
+				Version:     3,
+				CreatedAt:   createdAt,
+				UpdatedAt:   testNow,
+			},
+		},
+		{
+			name:  "NullResetsField",
+			patch: `{"description": null}`,
+			repoMock: func() {
+				mockRepo.EXPECT().SwapTask(gomock.Any(), uint(123), gomock.Any()).
+					DoAndReturn(swapStored(storedTask, nil))
+			},
+			expectedTask: &models.TaskDomain{
+				ID:        123,
+				Header:    "Test Task",
+				Status:    models.StatusTodo,
+				Version:   3,
+				CreatedAt: createdAt,
+				UpdatedAt: testNow,
+			},
+		},
+		{
+			name:        "NotAnObject",
+			patch:       `[1, 2]`,
+			expectedErr: ErrInvalidPatch,
+		},
+		{
+			name:  "UnknownField",
+			patch: `{"priority": 1}`,
+			repoMock: func() {
+				mockRepo.EXPECT().SwapTask(gomock.Any(), uint(123), gomock.Any()).
+					DoAndReturn(swapStored(storedTask, nil))
+			},
+			expectedErr: ErrInvalidPatch,
+		},
+		{
+			name:  "ChangesVersion",
+			patch: `{"version": 5}`,
+			repoMock: func() {
+				mockRepo.EXPECT().SwapTask(gomock.Any(), uint(123), gomock.Any()).
+					DoAndReturn(swapStored(storedTask, nil))
+			},
+			expectedErr: ErrInvalidPatch,
+		},
+		{
+			name:  "RepositoryError",
+			patch: `{"status": "done"}`,
+			repoMock: func() {
+				mockRepo.EXPECT().SwapTask(gomock.Any(), uint(123), gomock.Any()).
+					DoAndReturn(swapStored(storedTask, assert.AnError))
+			},
+			expectedErr: assert.AnError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.repoMock != nil {
+				tt.repoMock()
+			}
+
+			task, err := service.PatchTask(context.Background(), 123, []byte(tt.patch), false)
+
+			if tt.expectedErr == nil {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedTask, task)
+			} else {
+				require.Error(t, err)
+				var transitionErr *TransitionError
+				var validationErr *ValidationError
+				switch expected := tt.expectedErr.(type) {
+				case *TransitionError:
+					require.ErrorAs(t, err, &transitionErr)
+					assert.Equal(t, expected, transitionErr)
+				case *ValidationError:
+					require.ErrorAs(t, err, &validationErr)
+					assert.Equal(t, expected, validationErr)
+				default:
+					assert.ErrorIs(t, err, tt.expectedErr)
+				}
+				assert.Contains(t, err.Error(), "service/patch_task.go -")
+			}
+		})
+	}
+}
+
+func TestJSONPatchTask(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepo(ctrl)
+	service := New(mockRepo, &fakeClock{now: testNow}, DefaultWorkflow(), nil)
+
+	createdAt := testNow.Add(-time.Hour)
+	storedTask := &models.TaskDomain{
+		ID:          123,
+		Header:      "Test Task",
+		Description: "Test Description",
+		Status:      models.StatusTodo,
+		Version:     2,
+		CreatedAt:   createdAt,
+		UpdatedAt:   createdAt,
+	}
+
+	tests := []struct {
+		name         string
+		patch        string
+		expectedTask *models.TaskDomain
+		expectedErr  error
+	}{
+		{
+			name: "TestAndReplace",
+			patch: `[
+				{"op": "test", "path": "/status", "value": "todo"},
+				{"op": "replace", "path": "/status", "value": "done"},
+				{"op": "remove", "path": "/description"},
+				{"op": "add", "path": "/header", "value": "New Header"}
+			]`,
+			expectedTask: &models.TaskDomain{
+				ID:          123,
+				Header:      "New Header",
+				Status:      models.StatusDone,
+				Finished:    true,
+				Version:     3,
+				CreatedAt:   createdAt,
+				UpdatedAt:   testNow,
+				CompletedAt: &testNow,
+			},
+		},
+		{
+			name: "TestFailed",
+			patch: `[
+				{"op": "replace", "path": "/header", "value": "New Header"},
+				{"op": "test", "path": "/status", "value": "done"}
+			]`,
+			expectedErr: &PatchTestError{Index: 1, Path: "/status"},
+		},
+		{
+			name: "TestVersion",
+			patch: `[
+				{"op": "test", "path": "/version", "value": 2},
+				{"op": "test", "path": "/id", "value": 123},
+				{"op": "replace", "path": "/header", "value": "New Header"}
+			]`,
+			expectedTask: &models.TaskDomain{
+				ID:          123,
+				Header:      "New Header",
+				Description: "Test Description",
+				Status:      models.StatusTodo,
+				Version:     3,
+				CreatedAt:   createdAt,
+				UpdatedAt:   testNow,
+			},
+		},
+		{
+			name: "StaleVersion",
+			patch: `[
+				{"op": "test", "path": "/version", "value": 1},
+				{"op": "replace", "path": "/header", "value": "New Header"}
+			]`,
+			expectedErr: &PatchTestError{Index: 0, Path: "/version"},
+		},
+		{
+			name:        "ReplaceVersion",
+			patch:       `[{"op": "replace", "path": "/version", "value": 2}]`,
+			expectedErr: ErrInvalidPatch,
+		},
+		{
+			name:        "ReplaceWholeDocument",
+			patch:       `[{"op": "replace", "path": "", "value": {"header": "New Header"}}]`,
+			expectedErr: ErrInvalidPatch,
+		},
+		{
+			name:        "ReplaceMissingMember",
+			patch:       `[{"op": "replace", "path": "/priority", "value": 1}]`,
+			expectedErr: ErrInvalidPatch,
+		},
+		{
+			name:        "UnknownOp",
+			patch:       `[{"op": "move", "from": "/header", "path": "/description"}]`,
+			expectedErr: ErrInvalidPatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo.EXPECT().SwapTask(gomock.Any(), uint(123), gomock.Any()).
+				DoAndReturn(swapStored(storedTask, nil))
+
+			task, err := service.JSONPatchTask(context.Background(), 123, []byte(tt.patch), false)
+
+			if tt.expectedErr == nil {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedTask, task)
+				return
+			}
+
+			require.Error(t, err)
+			var testErr *PatchTestError
+			if expected, ok := tt.expectedErr.(*PatchTestError); ok {
+				require.ErrorAs(t, err, &testErr)
+				assert.Equal(t, expected, testErr)
+			} else {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			}
+		})
+	}
+}
+
+func TestSearchTasks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepo(ctrl)
+	service := New(mockRepo, &fakeClock{now: testNow}, DefaultWorkflow(), nil)
+
+	tests := []struct {
+		name          string
+		query         string
+		limit         int
+		repoLimit     int
+		repoReturnErr error
+		expectedErr   error
+	}{
+		{
+			name:      "DefaultLimit",
+			query:     "деплой",
+			limit:     0,
+			repoLimit: DefaultSearchLimit,
+		},
+		{
+			name:      "ClampedLimit",
+			query:     "деплой",
+			limit:     MaxSearchLimit + 1,
+			repoLimit: MaxSearchLimit,
+		},
+		{
+			name:        "EmptyQuery",
+			query:       "  ",
+			expectedErr: ErrEmptySearchQuery,
+		},
+		{
+			name:          "RepositoryError",
+			query:         "деплой",
+			repoLimit:     DefaultSearchLimit,
+			repoReturnErr: assert.AnError,
+			expectedErr:   assert.AnError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.repoLimit != 0 {
+				mockRepo.EXPECT().SearchTasks(ctx, tt.query, tt.repoLimit).
+					Return([]*models.SearchResult{}, tt.repoReturnErr)
+			}
+
+			_, err := service.SearchTasks(ctx, tt.query, tt.limit)
+
+			if tt.expectedErr == nil {
+				require.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Contains(t, err.Error(), "service/search_tasks.go -")
+			}
+		})
+	}
+}
+
+func TestRenameTag(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepo(ctrl)
+	service := New(mockRepo, &fakeClock{now: testNow}, DefaultWorkflow(), nil)
+	ctx := context.Background()
+
+	t.Run("Success", func(t *testing.T) {
+		var renamed []*models.TaskDomain
+		mockRepo.EXPECT().SwapTaggedTasks(ctx, "ops", gomock.Any()).DoAndReturn(
+			func(ctx context.Context, tag string, update func(task *models.TaskDomain) error) (int, error) {
+				for _, task := range []*models.TaskDomain{
+					{ID: 1, Tags: []string{"ops", "urgent"}},
+					{ID: 2, Tags: []string{"ops", "platform"}},
+				} {
+					if err := update(task); err != nil {
+						return 0, err
+					}
+					renamed = append(renamed, task)
+				}
+				return len(renamed), nil
+			})
+
+		tag, err := service.RenameTag(ctx, "Ops", " Platform ")
+		require.NoError(t, err)
+		assert.Equal(t, &models.TagCount{Name: "platform", Count: 2}, tag)
+		assert.Equal(t, []string{"platform", "urgent"}, renamed[0].Tags)
+		assert.Equal(t, []string{"platform"}, renamed[1].Tags)
+		assert.Equal(t, testNow, renamed[0].UpdatedAt)
+	})
+
+	t.Run("InvalidName", func(t *testing.T) {
+		_, err := service.RenameTag(ctx, "ops", "   ")
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "name", validationErr.Fields[0].Field)
+	})
+
+	t.Run("RepositoryError", func(t *testing.T) {
+		mockRepo.EXPECT().SwapTaggedTasks(ctx, "ops", gomock.Any()).Return(0, assert.AnError)
+
+		_, err := service.RenameTag(ctx, "ops", "platform")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "service/tags.go -")
+	})
+}
+
+func TestSubtasks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepo(ctrl)
+	service := New(mockRepo, &fakeClock{now: testNow}, DefaultWorkflow(), nil)
+	ctx := context.Background()
+	parentID := uint(7)
+
+	t.Run("DanglingParent", func(t *testing.T) {
+		mockRepo.EXPECT().StoreTask(ctx, gomock.Any()).Return(uint(0), repoTasks.ErrParentNotFound)
+
+		_, err := service.CreateTask(ctx, &models.TaskDTO{Header: "Subtask", ParentID: &parentID})
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, []FieldError{
+			{Field: "parent_id", Rule: RuleParent, Message: "parent task does not exist"},
+		}, validationErr.Fields)
+	})
+
+	t.Run("ParentCycle", func(t *testing.T) {
+		mockRepo.EXPECT().SwapTask(ctx, uint(7), gomock.Any()).Return(nil, repoTasks.ErrParentCycle)
+
+		_, err := service.PatchTask(ctx, 7, []byte(`{"parent_id": 7}`), false)
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, RuleParent, validationErr.Fields[0].Rule)
+	})
+
+	t.Run("OrphanTouchesChildren", func(t *testing.T) {
+		mockRepo.EXPECT().DeleteTask(ctx, uint(7), models.ChildrenOrphan, testNow, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, taskID uint, mode string, deletedAt time.Time,
+				detach func(task *models.TaskDomain) error) ([]uint, error) {
+				child := &models.TaskDomain{ID: 8}
+				require.NoError(t, detach(child))
+				assert.Equal(t, testNow, child.UpdatedAt)
+				return []uint{7}, nil
+			})
+
+		require.NoError(t, service.DeleteTask(ctx, 7, models.ChildrenOrphan))
+	})
+
+	t.Run("TreeMode", func(t *testing.T) {
+		roots := []*models.TaskDomain{{ID: 1}, {ID: 4}}
+		trees := []*models.TaskTree{{TaskDomain: roots[0]}, {TaskDomain: roots[1]}}
+		mockRepo.EXPECT().QueryTasks(ctx, &models.TaskQuery{Tree: true}).Return(roots, nil)
+		mockRepo.EXPECT().LoadTaskTrees(ctx, []uint{1, 4}).Return(trees, nil)
+
+		page, err := service.GetAllTasks(ctx, &models.TaskQuery{Tree: true})
+		require.NoError(t, err)
+		assert.Equal(t, trees, page.Trees)
+	})
+}
+
+func TestDependencies(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepo(ctrl)
+	service := New(mockRepo, &fakeClock{now: testNow}, DefaultWorkflow(), nil)
+	ctx := context.Background()
+	blocked := &models.TaskDomain{
+		ID:        1,
+		Header:    "Deploy",
+		Status:    models.StatusInProgress,
+		DependsOn: []uint{2},
+		Blocked:   true,
+		Version:   3,
+	}
+
+	t.Run("AddTouchesTask", func(t *testing.T) {
+		mockRepo.EXPECT().AddDependency(ctx, uint(1), uint(2), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, taskID, dependencyID uint, update func(task *models.TaskDomain) error) (
+				*models.TaskDomain, error) {
+				task := &models.TaskDomain{ID: taskID, DependsOn: []uint{dependencyID}}
+				require.NoError(t, update(task))
+				return task, nil
+			})
+
+		task, err := service.AddDependency(ctx, 1, 2)
+		require.NoError(t, err)
+		assert.Equal(t, testNow, task.UpdatedAt)
+	})
+
+	t.Run("Cycle", func(t *testing.T) {
+		cycleErr := &repoTasks.DependencyCycleError{Path: []uint{1, 2, 1}}
+		mockRepo.EXPECT().AddDependency(ctx, uint(1), uint(2), gomock.Any()).Return(nil, cycleErr)
+
+		_, err := service.AddDependency(ctx, 1, 2)
+		var gotErr *repoTasks.DependencyCycleError
+		require.ErrorAs(t, err, &gotErr)
+		assert.Equal(t, "dependency cycle: 1 -> 2 -> 1", gotErr.Error())
+	})
+
+	t.Run("FinishBlocked", func(t *testing.T) {
+		mockRepo.EXPECT().SwapTask(ctx, uint(1), gomock.Any()).DoAndReturn(swapStored(blocked, nil))
+
+		err := service.UpdateTask(ctx, 1, &models.TaskDTO{Header: "Deploy", Status: models.StatusDone}, false)
+		assert.ErrorIs(t, err, ErrTaskBlocked)
+	})
+
+	t.Run("EditBlocked", func(t *testing.T) {
+		mockRepo.EXPECT().SwapTask(ctx, uint(1), gomock.Any()).DoAndReturn(swapStored(blocked, nil))
+
+		err := service.UpdateTask(ctx, 1, &models.TaskDTO{Header: "Deploy to prod"}, false)
+		assert.NoError(t, err)
+	})
+
+	t.Run("ForceFinishBlocked", func(t *testing.T) {
+		mockRepo.EXPECT().SwapTask(ctx, uint(1), gomock.Any()).DoAndReturn(swapStored(blocked, nil))
+
+		task, err := service.PatchTask(ctx, 1, []byte(`{"status": "done"}`), true)
+		require.NoError(t, err)
+		assert.True(t, task.Finished)
+		assert.Equal(t, []uint{2}, task.DependsOn)
+	})
+
+	t.Run("PatchFinishBlocked", func(t *testing.T) {
+		mockRepo.EXPECT().SwapTask(ctx, uint(1), gomock.Any()).DoAndReturn(swapStored(blocked, nil))
+
+		_, err := service.JSONPatchTask(ctx, 1, []byte(`[{"op": "replace", "path": "/status", "value": "done"}]`), false)
+		assert.ErrorIs(t, err, ErrTaskBlocked)
+	})
+}
+
+func TestWorkflow(t *testing.T) {
+	t.Run("InvalidTable", func(t *testing.T) {
+		_, err := NewWorkflow([]models.Transition{{Name: "ship", From: []string{"todo"}, To: "shipped"}})
+		assert.ErrorIs(t, err, ErrInvalidWorkflow)
+
+		_, err = NewWorkflow([]models.Transition{
+			{Name: "start", From: []string{models.StatusTodo}, To: models.StatusInProgress},
+			{Name: "start", From: []string{models.StatusInReview}, To: models.StatusInProgress},
+		})
+		assert.ErrorIs(t, err, ErrInvalidWorkflow)
+	})
+
+	t.Run("CustomTable", func(t *testing.T) {
+		workflow, err := NewWorkflow([]models.Transition{
+			{Name: "finish", From: []string{models.StatusTodo}, To: models.StatusDone},
+		})
+		require.NoError(t, err)
+
+		status, err := workflow.next(models.StatusTodo, "finish")
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusDone, status)
+		assert.False(t, workflow.allows(models.StatusTodo, models.StatusInProgress))
+	})
+}
+
+func TestTransitionTask(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepo(ctrl)
+	service := New(mockRepo, &fakeClock{now: testNow}, DefaultWorkflow(), nil)
+	ctx := context.Background()
+	createdAt := testNow.Add(-time.Hour)
+
+	stored := func(status string) *models.TaskDomain {
+		return &models.TaskDomain{ID: 1, Header: "Task", Status: status, Version: 1, CreatedAt: createdAt}
+	}
+
+	t.Run("Start", func(t *testing.T) {
+		mockRepo.EXPECT().SwapTask(ctx, uint(1), gomock.Any()).DoAndReturn(swapStored(stored(models.StatusTodo), nil))
+
+		task, err := service.TransitionTask(ctx, 1, "start", false)
+		require.NoError(t, err)
+		assert.Equal(t, &models.TaskDomain{
+			ID:        1,
+			Header:    "Task",
+			Status:    models.StatusInProgress,
+			Version:   2,
+			CreatedAt: createdAt,
+			UpdatedAt: testNow,
+		}, task)
+	})
+
+	t.Run("ApproveFinishes", func(t *testing.T) {
+		mockRepo.EXPECT().SwapTask(ctx, uint(1), gomock.Any()).DoAndReturn(swapStored(stored(models.StatusInReview), nil))
+
+		task, err := service.TransitionTask(ctx, 1, "approve", false)
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusDone, task.Status)
+		assert.True(t, task.Finished)
+		assert.Equal(t, &testNow, task.CompletedAt)
+	})
+
+	t.Run("Illegal", func(t *testing.T) {
+		mockRepo.EXPECT().SwapTask(ctx, uint(1), gomock.Any()).DoAndReturn(swapStored(stored(models.StatusDone), nil))
+
+		_, err := service.TransitionTask(ctx, 1, "start", false)
+		var transitionErr *TransitionError
+		require.ErrorAs(t, err, &transitionErr)
+		assert.Equal(t, &TransitionError{Transition: "start", From: models.StatusDone, Allowed: []string{"reopen"}},
+			transitionErr)
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		mockRepo.EXPECT().SwapTask(ctx, uint(1), gomock.Any()).DoAndReturn(swapStored(stored(models.StatusTodo), nil))
+
+		_, err := service.TransitionTask(ctx, 1, "ship", false)
+		assert.ErrorIs(t, err, ErrTransitionNotFound)
+	})
+
+	t.Run("Blocked", func(t *testing.T) {
+		blocked := stored(models.StatusInProgress)
+		blocked.Blocked = true
+		mockRepo.EXPECT().SwapTask(ctx, uint(1), gomock.Any()).DoAndReturn(swapStored(blocked, nil)).Times(2)
+
+		_, err := service.TransitionTask(ctx, 1, "complete", false)
+		assert.ErrorIs(t, err, ErrTaskBlocked)
+
+		task, err := service.TransitionTask(ctx, 1, "complete", true)
+		require.NoError(t, err)
+		assert.True(t, task.Finished)
+	})
+
+	t.Run("CreateInAnyStatus", func(t *testing.T) {
+		mockRepo.EXPECT().StoreTask(ctx, &models.TaskDomain{
+			Header:      "Imported",
+			Status:      models.StatusDone,
+			Finished:    true,
+			CreatedAt:   testNow,
+			UpdatedAt:   testNow,
+			CompletedAt: &testNow,
+		}).Return(uint(5), nil)
+
+		_, err := service.CreateTask(ctx, &models.TaskDTO{Header: "Imported", Status: models.StatusDone})
+		require.NoError(t, err)
+	})
+}
+
+func TestParseRecurrence(t *testing.T) {
+	until := time.Date(2024, 6, 30, 23, 59, 59, 999999999, time.UTC)
+	valid := map[string]*Recurrence{
+		"FREQ=DAILY": {Freq: FreqDaily, Interval: 1},
+		"RRULE:freq=weekly;byday=MO,TH,MO;interval=2": {
+			Freq:     FreqWeekly,
+			Interval: 2,
+			ByDay:    []time.Weekday{time.Monday, time.Thursday},
+		},
+		"FREQ=MONTHLY;BYMONTHDAY=1,-1;COUNT=6": {Freq: FreqMonthly, Interval: 1, ByMonthDay: []int{1, -1}, Count: 6},
+		"FREQ=DAILY;UNTIL=20240630":            {Freq: FreqDaily, Interval: 1, Until: &until},
+	}
+	for rule, expected := range valid {
+		recurrence, err := ParseRecurrence(rule)
+		require.NoError(t, err, rule)
+		assert.Equal(t, expected, recurrence, rule)
+	}
+
+	invalid := []string{
+		"",
+		"INTERVAL=2",
+		"FREQ=YEARLY",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=3;UNTIL=20240630",
+		"FREQ=DAILY;BYDAY=MO",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=DAILY;UNTIL=2024-06-30",
+		"FREQ=DAILY;BYHOUR=9",
+	}
+	for _, rule := range invalid {
+		_, err := ParseRecurrence(rule)
+		assert.ErrorIs(t, err, ErrInvalidRecurrence, rule)
+	}
+}
+
+func TestRecurrence_Occurrences(t *testing.T) {
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2024, month, day, 9, 30, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		rule     string
+		start    time.Time
+		index    int
+		n        int
+		expected []time.Time
+	}{
+		{
+			name:     "Daily",
+			rule:     "FREQ=DAILY;INTERVAL=2",
+			start:    date(5, 30),
+			n:        3,
+			expected: []time.Time{date(6, 1), date(6, 3), date(6, 5)},
+		},
+		{
+			name:     "WeeklyOnDays",
+			rule:     "FREQ=WEEKLY;BYDAY=TH,MO",
+			start:    date(5, 1),
+			n:        3,
+			expected: []time.Time{date(5, 2), date(5, 6), date(5, 9)},
+		},
+		{
+			name:     "EveryOtherWeek",
+			rule:     "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,FR",
+			start:    date(5, 6),
+			n:        3,
+			expected: []time.Time{date(5, 10), date(5, 20), date(5, 24)},
+		},
+		{
+			name:     "WeeklyOnStartDay",
+			rule:     "FREQ=WEEKLY",
+			start:    date(5, 1),
+			n:        2,
+			expected: []time.Time{date(5, 8), date(5, 15)},
+		},
+		{
+			name:     "MonthlySkipsShortMonths",
+			rule:     "FREQ=MONTHLY",
+			start:    date(1, 31),
+			n:        3,
+			expected: []time.Time{date(3, 31), date(5, 31), date(7, 31)},
+		},
+		{
+			name:     "LastDayOfMonth",
+			rule:     "FREQ=MONTHLY;BYMONTHDAY=-1",
+			start:    date(1, 31),
+			n:        3,
+			expected: []time.Time{date(2, 29), date(3, 31), date(4, 30)},
+		},
+		{
+			name:     "MonthlyOnDaysWithInterval",
+			rule:     "FREQ=MONTHLY;INTERVAL=2;BYMONTHDAY=15,1",
+			start:    date(1, 15),
+			n:        3,
+			expected: []time.Time{date(3, 1), date(3, 15), date(5, 1)},
+		},
+		{
+			name:     "Count",
+			rule:     "FREQ=DAILY;COUNT=3",
+			start:    date(5, 1),
+			index:    1,
+			n:        10,
+			expected: []time.Time{date(5, 2), date(5, 3)},
+		},
+		{
+			name:     "Until",
+			rule:     "FREQ=DAILY;UNTIL=20240503",
+			start:    date(5, 1),
+			n:        10,
+			expected: []time.Time{date(5, 2), date(5, 3)},
+		},
+		{
+			name:  "NeverAgain",
+			rule:  "FREQ=MONTHLY;INTERVAL=12;BYMONTHDAY=30",
+			start: date(2, 10),
+			n:     1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recurrence, err := ParseRecurrence(tt.rule)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, recurrence.Occurrences(tt.start, tt.index, tt.n))
+		})
+	}
+}
+
+func TestRecurringTasks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepo(ctrl)
+	service := New(mockRepo, &fakeClock{now: testNow}, DefaultWorkflow(), nil)
+	ctx := context.Background()
+
+	monday := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	thursday := time.Date(2024, 5, 9, 9, 0, 0, 0, time.UTC)
+	rule := "FREQ=WEEKLY;BYDAY=MO,TH;COUNT=4"
+	stored := func(status string, occurrence int) *models.TaskDomain {
+		return &models.TaskDomain{
+			ID:         1,
+			Header:     "Rotate logs",
+			Status:     status,
+			DueAt:      &monday,
+			Tags:       []string{"ops"},
+			Recurrence: rule,
+			Occurrence: occurrence,
+			Version:    1,
+		}
+	}
+	previousID := uint(1)
+	nextID := uint(7)
+	next := &models.TaskDomain{
+		Header:     "Rotate logs",
+		Status:     models.StatusTodo,
+		DueAt:      &thursday,
+		Tags:       []string{"ops"},
+		Recurrence: rule,
+		Occurrence: 3,
+		CreatedAt:  testNow,
+		UpdatedAt:  testNow,
+	}
+
+	t.Run("FinishCreatesNext", func(t *testing.T) {
+		mockRepo.EXPECT().SwapTask(ctx, uint(1), gomock.Any()).
+			DoAndReturn(swapStored(stored(models.StatusInProgress, 2), nil))
+		mockRepo.EXPECT().StoreNextOccurrence(ctx, uint(1), next, gomock.Any()).
+			Return(&models.TaskDomain{ID: 1, Version: 3, NextOccurrenceID: &nextID}, nil)
+
+		err := service.UpdateTask(ctx, 1, &models.TaskDTO{
+			Header:     "Rotate logs",
+			Status:     models.StatusDone,
+			DueAt:      &monday,
+			Tags:       []string{"ops"},
+			Recurrence: rule,
+		}, false)
+		require.NoError(t, err)
+	})
+
+	t.Run("TransitionReturnsLinkedTask", func(t *testing.T) {
+		mockRepo.EXPECT().SwapTask(ctx, uint(1), gomock.Any()).
+			DoAndReturn(swapStored(stored(models.StatusInProgress, 2), nil))
+		mockRepo.EXPECT().StoreNextOccurrence(ctx, uint(1), next, gomock.Any()).
+			Return(&models.TaskDomain{ID: 1, Version: 3, NextOccurrenceID: &nextID}, nil)
+
+		task, err := service.TransitionTask(ctx, 1, "complete", false)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(3), task.Version)
+		assert.Equal(t, &nextID, task.NextOccurrenceID)
+	})
+
+	t.Run("NextFailureKeepsWrite", func(t *testing.T) {
+		mockRepo.EXPECT().SwapTask(ctx, uint(1), gomock.Any()).
+			DoAndReturn(swapStored(stored(models.StatusInProgress, 2), nil))
+		mockRepo.EXPECT().StoreNextOccurrence(ctx, uint(1), next, gomock.Any()).Return(nil, assert.AnError)
+
+		task, err := service.TransitionTask(ctx, 1, "complete", false)
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusDone, task.Status)
+		assert.Nil(t, task.NextOccurrenceID)
+	})
+
+	t.Run("AlreadyLinked", func(t *testing.T) {
+		linked := stored(models.StatusDone, 2)
+		linked.NextOccurrenceID = &nextID
+		mockRepo.EXPECT().SwapTask(ctx, uint(1), gomock.Any()).DoAndReturn(swapStored(linked, nil))
+
+		_, err := service.PatchTask(ctx, 1, []byte(`{"header": "Rotate all logs"}`), false)
+		require.NoError(t, err)
+	})
+
+	t.Run("SeriesOver", func(t *testing.T) {
+		mockRepo.EXPECT().SwapTask(ctx, uint(1), gomock.Any()).
+			DoAndReturn(swapStored(stored(models.StatusInProgress, 4), nil))
+
+		task, err := service.TransitionTask(ctx, 1, "complete", false)
+		require.NoError(t, err)
+		assert.Nil(t, task.NextOccurrenceID)
+	})
+
+	t.Run("FirstOccurrenceIsNumbered", func(t *testing.T) {
+		mockRepo.EXPECT().StoreTask(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, task *models.TaskDomain) (uint, error) {
+				assert.Equal(t, 1, task.Occurrence)
+				return previousID, nil
+			})
+
+		_, err := service.CreateTask(ctx, &models.TaskDTO{Header: "Rotate logs", DueAt: &monday, Recurrence: rule})
+		require.NoError(t, err)
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		_, err := service.CreateTask(ctx, &models.TaskDTO{Header: "Rotate logs", Recurrence: rule})
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, []FieldError{{Field: "due_at", Rule: RuleRequired, Message: "is required for a recurring task"}},
+			validationErr.Fields)
+
+		_, err = service.CreateTask(ctx, &models.TaskDTO{
+			Header:     "Rotate logs",
+			DueAt:      &monday,
+			Recurrence: rule,
+			TimeZone:   "Mars/Olympus",
+		})
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, []FieldError{{
+			Field:   "time_zone",
+			Rule:    RuleTimeZone,
+			Message: "must be an IANA time zone such as Europe/Berlin",
+		}}, validationErr.Fields)
+
+		_, err = service.CreateTask(ctx, &models.TaskDTO{Header: "Rotate logs", DueAt: &monday, Recurrence: "FREQ=YEARLY"})
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, []FieldError{{
+			Field:   "recurrence",
+			Rule:    RuleRecurrence,
+			Message: "FREQ must be DAILY, WEEKLY or MONTHLY",
+		}}, validationErr.Fields)
+	})
+
+	t.Run("Preview", func(t *testing.T) {
+		mockRepo.EXPECT().LoadTask(ctx, uint(1)).Return(stored(models.StatusTodo, 1), nil)
+
+		dates, err := service.PreviewOccurrences(ctx, 1, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []time.Time{
+			thursday,
+			monday.AddDate(0, 0, 7),
+			thursday.AddDate(0, 0, 7),
+		}, dates)
+	})
+
+	t.Run("PreviewKeepsLocalTimeAcrossDST", func(t *testing.T) {
+		// 09:00 in Berlin is 08:00 UTC before the switch to summer time on
+		// 31 March 2024 and 07:00 UTC after it.
+		dueAt := time.Date(2024, 3, 29, 8, 0, 0, 0, time.UTC)
+		mockRepo.EXPECT().LoadTask(ctx, uint(3)).Return(&models.TaskDomain{
+			ID:         3,
+			DueAt:      &dueAt,
+			Recurrence: "FREQ=WEEKLY;COUNT=3",
+			TimeZone:   "Europe/Berlin",
+		}, nil)
+
+		dates, err := service.PreviewOccurrences(ctx, 3, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []time.Time{
+			time.Date(2024, 4, 5, 7, 0, 0, 0, time.UTC),
+			time.Date(2024, 4, 12, 7, 0, 0, 0, time.UTC),
+		}, dates)
+	})
+
+	t.Run("PreviewNotRecurring", func(t *testing.T) {
+		mockRepo.EXPECT().LoadTask(ctx, uint(2)).Return(&models.TaskDomain{ID: 2, DueAt: &monday}, nil)
+
+		_, err := service.PreviewOccurrences(ctx, 2, 5)
+		assert.ErrorIs(t, err, ErrNotRecurring)
+	})
+}
+
+type fakeNotifier struct {
+	mu        sync.Mutex
+	reminders []*models.DueReminder
+	err       error
+}
+
+func (n *fakeNotifier) Notify(ctx context.Context, reminder *models.DueReminder) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.err != nil {
+		return n.err
+	}
+	n.reminders = append(n.reminders, reminder)
+	return nil
+}
+
+func TestReminders(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepo(ctrl)
+	clock := &fakeClock{now: testNow}
+	service := New(mockRepo, clock, DefaultWorkflow(), nil)
+	ctx := context.Background()
+
+	dueAt := testNow.Add(time.Hour)
+	hour := models.Duration(time.Hour)
+	quarter := models.Duration(15 * time.Minute)
+	stored := func() *models.TaskDomain {
+		return &models.TaskDomain{
+			ID:        1,
+			Header:    "Standup",
+			Status:    models.StatusTodo,
+			DueAt:     &dueAt,
+			Reminders: []models.Reminder{{Before: hour}, {Before: quarter}},
+			Version:   1,
+		}
+	}
+	horizon := testNow.Add(MaxReminderOffset + time.Nanosecond)
+	expectQuery := func(tasks ...*models.TaskDomain) {
+		mockRepo.EXPECT().QueryTasks(ctx, &models.TaskQuery{
+			Statuses:  []string{models.StatusTodo, models.StatusInProgress, models.StatusInReview},
+			DueBefore: &horizon,
+		}).Return(tasks, nil)
+	}
+
+	t.Run("FiresDueReminders", func(t *testing.T) {
+		notifier := &fakeNotifier{}
+		scheduler := NewReminderScheduler(service, notifier, 0)
+		var claimed *models.TaskDomain
+		expectQuery(stored())
+		mockRepo.EXPECT().SwapReminders(gomock.Any(), uint(1), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, taskID uint, update func(task *models.TaskDomain) error) error {
+				claimed = stored()
+				return update(claimed)
+			})
+
+		fired, err := scheduler.FireDue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, fired)
+		assert.Equal(t, []*models.DueReminder{{
+			TaskID: 1,
+			Header: "Standup",
+			DueAt:  dueAt,
+			Before: hour,
+			FireAt: testNow,
+		}}, notifier.reminders)
+		assert.Equal(t, []models.Reminder{{Before: hour, FiredAt: &testNow}, {Before: quarter}}, claimed.Reminders)
+	})
+
+	t.Run("SkipsFiredAndFutureReminders", func(t *testing.T) {
+		notifier := &fakeNotifier{}
+		scheduler := NewReminderScheduler(service, notifier, 0)
+		task := stored()
+		task.Reminders[0].FiredAt = &testNow
+		expectQuery(task)
+
+		fired, err := scheduler.FireDue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, fired)
+		assert.Empty(t, notifier.reminders)
+	})
+
+	t.Run("LaterPassFiresTheRest", func(t *testing.T) {
+		defer func() { clock.now = testNow }()
+		clock.now = testNow.Add(50 * time.Minute)
+		laterHorizon := clock.now.Add(MaxReminderOffset + time.Nanosecond)
+		firedAt := testNow
+		task := stored()
+		task.Reminders[0].FiredAt = &firedAt
+		mockRepo.EXPECT().QueryTasks(ctx, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, query *models.TaskQuery) ([]*models.TaskDomain, error) {
+				assert.Equal(t, &laterHorizon, query.DueBefore)
+				return []*models.TaskDomain{task}, nil
+			})
+		mockRepo.EXPECT().SwapReminders(gomock.Any(), uint(1), gomock.Any()).DoAndReturn(swapReminders(task))
+		notifier := &fakeNotifier{}
+
+		fired, err := NewReminderScheduler(service, notifier, 0).FireDue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, fired)
+		require.Len(t, notifier.reminders, 1)
+		assert.Equal(t, quarter, notifier.reminders[0].Before)
+	})
+
+	t.Run("ClaimLost", func(t *testing.T) {
+		notifier := &fakeNotifier{}
+		scheduler := NewReminderScheduler(service, notifier, 0)
+		moved := stored()
+		movedDue := dueAt.Add(24 * time.Hour)
+		moved.DueAt = &movedDue
+		expectQuery(stored())
+		mockRepo.EXPECT().SwapReminders(gomock.Any(), uint(1), gomock.Any()).DoAndReturn(swapReminders(moved))
+
+		fired, err := scheduler.FireDue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, fired)
+		assert.Empty(t, notifier.reminders)
+	})
+
+	t.Run("NotifyFailureReleasesClaim", func(t *testing.T) {
+		notifier := &fakeNotifier{err: assert.AnError}
+		scheduler := NewReminderScheduler(service, notifier, 0)
+		claimed := stored()
+		claimed.Reminders[0].FiredAt = &testNow
+		expectQuery(stored())
+		gomock.InOrder(
+			mockRepo.EXPECT().SwapReminders(gomock.Any(), uint(1), gomock.Any()).DoAndReturn(swapReminders(stored())),
+			mockRepo.EXPECT().SwapReminders(gomock.Any(), uint(1), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, taskID uint, update func(task *models.TaskDomain) error) error {
+					require.NoError(t, update(claimed))
+					assert.Nil(t, claimed.Reminders[0].FiredAt)
+					return nil
+				}),
+		)
+
+		fired, err := scheduler.FireDue(ctx)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, 0, fired)
+	})
+
+	t.Run("StartAndStop", func(t *testing.T) {
+		passes := make(chan struct{}, 1)
+		mockRepo.EXPECT().QueryTasks(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, query *models.TaskQuery) ([]*models.TaskDomain, error) {
+				select {
+				case passes <- struct{}{}:
+				default:
+				}
+				return nil, nil
+			}).MinTimes(1)
+
+		scheduler := NewReminderScheduler(service, &fakeNotifier{}, time.Millisecond)
+		scheduler.Start()
+		<-passes
+		scheduler.Stop()
+	})
+
+	t.Run("KeepFiredUntilDueMoves", func(t *testing.T) {
+		task := stored()
+		task.Reminders[0].FiredAt = &testNow
+		dto := &models.TaskDTO{
+			Header:    "Standup",
+			DueAt:     &dueAt,
+			Reminders: []models.Duration{quarter, hour, quarter},
+		}
+		mockRepo.EXPECT().SwapTask(ctx, uint(1), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, taskID uint, update func(task *models.TaskDomain) error) (*models.TaskDomain, error) {
+				updated, err := swapStored(task, nil)(ctx, taskID, update)
+				require.NoError(t, err)
+				assert.Equal(t, []models.Reminder{{Before: hour, FiredAt: &testNow}, {Before: quarter}}, updated.Reminders)
+				return updated, nil
+			})
+		require.NoError(t, service.UpdateTask(ctx, 1, dto, false))
+
+		moved := dueAt.Add(time.Hour)
+		dto = &models.TaskDTO{Header: "Standup", DueAt: &moved, Reminders: []models.Duration{hour}}
+		mockRepo.EXPECT().SwapTask(ctx, uint(1), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, taskID uint, update func(task *models.TaskDomain) error) (*models.TaskDomain, error) {
+				updated, err := swapStored(task, nil)(ctx, taskID, update)
+				require.NoError(t, err)
+				assert.Equal(t, []models.Reminder{{Before: hour}}, updated.Reminders)
+				return updated, nil
+			})
+		require.NoError(t, service.UpdateTask(ctx, 1, dto, false))
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		_, err := service.CreateTask(ctx, &models.TaskDTO{Header: "Standup", Reminders: []models.Duration{hour}})
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "due_at", validationErr.Fields[0].Field)
+
+		_, err = service.CreateTask(ctx, &models.TaskDTO{
+			Header:    "Standup",
+			DueAt:     &dueAt,
+			Reminders: []models.Duration{models.Duration(-time.Minute)},
+		})
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, []FieldError{{
+			Field:   "reminders",
+			Rule:    RuleReminders,
+			Message: "reminder -1m0s must be positive and at most 720h0m0s",
+		}}, validationErr.Fields)
+	})
+}
+
+type fakePublisher struct {
+	events []*models.TaskEvent
+}
+
+func (p *fakePublisher) Publish(event *models.TaskEvent) {
+	p.events = append(p.events, event)
+}
+
+// take returns the type and task ID of every event published since the last
+// call.
+func (p *fakePublisher) take() []string {
+	var events []string
+	for _, event := range p.events {
+		events = append(events, fmt.Sprintf("%s %d", event.Type, event.TaskID))
+	}
+	p.events = nil
+
+	return events
+}
+
+func TestEvents(t *testing.T) {
+	publisher := &fakePublisher{}
+	service := New(repoTasks.New(), &fakeClock{now: testNow}, DefaultWorkflow(), publisher)
+	ctx := context.Background()
+
+	parentID, err := service.CreateTask(ctx, &models.TaskDTO{Header: "Release"})
+	require.NoError(t, err)
+	childID, err := service.CreateTask(ctx, &models.TaskDTO{Header: "Build", ParentID: &parentID})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"task.created 0", "task.created 1"}, publisher.take())
+
+	t.Run("CreatedCarriesTask", func(t *testing.T) {
+		_, err := service.CreateTask(ctx, &models.TaskDTO{Header: "Docs"})
+		require.NoError(t, err)
+		require.Len(t, publisher.events, 1)
+		event := publisher.events[0]
+		assert.NotEmpty(t, event.ID)
+		assert.Equal(t, testNow, event.OccurredAt)
+		require.NotNil(t, event.Task)
+		assert.Equal(t, "Docs", event.Task.Header)
+		publisher.take()
+	})
+
+	t.Run("UpdateAndFinish", func(t *testing.T) {
+		_, err := service.TransitionTask(ctx, childID, "start", false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"task.updated 1"}, publisher.take())
+
+		_, err = service.TransitionTask(ctx, childID, "complete", false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"task.updated 1", "task.finished 1"}, publisher.take())
+
+		_, err = service.PatchTask(ctx, childID, []byte(`{"description": "again"}`), false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"task.updated 1"}, publisher.take(), "staying done is not finishing")
+	})
+
+	t.Run("FailedWriteIsSilent", func(t *testing.T) {
+		_, err := service.TransitionTask(ctx, childID, "start", false)
+		require.Error(t, err)
+		assert.Empty(t, publisher.take())
+	})
+
+	t.Run("RecurringCreatesOccurrence", func(t *testing.T) {
+		dueAt := testNow.Add(time.Hour)
+		taskID, err := service.CreateTask(ctx, &models.TaskDTO{Header: "Standup", DueAt: &dueAt,
+			Recurrence: "FREQ=DAILY"})
+		require.NoError(t, err)
+		publisher.take()
+
+		task, err := service.TransitionTask(ctx, taskID, "complete", false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			fmt.Sprintf("task.updated %d", taskID),
+			fmt.Sprintf("task.finished %d", taskID),
+			fmt.Sprintf("task.created %d", *task.NextOccurrenceID),
+			fmt.Sprintf("task.updated %d", taskID),
+		}, publisher.take())
+	})
+
+	t.Run("RenameTag", func(t *testing.T) {
+		taskID, err := service.CreateTask(ctx, &models.TaskDTO{Header: "Tagged", Tags: []string{"ops"}})
+		require.NoError(t, err)
+		publisher.take()
+
+		_, err = service.RenameTag(ctx, "ops", "infra")
+		require.NoError(t, err)
+		events := publisher.events
+		assert.Equal(t, []string{fmt.Sprintf("task.updated %d", taskID)}, publisher.take())
+		assert.Equal(t, []string{"infra"}, events[0].Task.Tags)
+	})
+
+	t.Run("DeleteOrphansChildren", func(t *testing.T) {
+		require.NoError(t, service.DeleteTask(ctx, parentID, models.ChildrenOrphan))
+		events := publisher.events
+		assert.Equal(t, []string{"task.deleted 0", "task.updated 1"}, publisher.take())
+		assert.Nil(t, events[0].Task)
+		assert.Nil(t, events[1].Task.ParentID)
+	})
+}
+
+func TestHistory(t *testing.T) {
+	clock := &fakeClock{now: testNow}
+	repo := repoTasks.New()
+	service := New(NewHistoryRepo(repo, repo, clock), clock, DefaultWorkflow(), nil)
+	ctx := context.Background()
+
+	ops := func(diffs []*models.RevisionDiff) []string {
+		result := []string{}
+		for _, diff := range diffs {
+			result = append(result, fmt.Sprintf("%d %s", diff.Number, diff.Op))
+		}
+		return result
+	}
+
+	parentID, err := service.CreateTask(ctx, &models.TaskDTO{Header: "Release"})
+	require.NoError(t, err)
+	taskID, err := service.CreateTask(ctx, &models.TaskDTO{Header: "Write notes", Description: "Draft",
+		ParentID: &parentID})
+	require.NoError(t, err)
+	clock.now = testNow.Add(time.Hour)
+	require.NoError(t, service.UpdateTask(ctx, taskID, &models.TaskDTO{Header: "Write release notes",
+		Description: "Draft", ParentID: &parentID, Tags: []string{"docs"}}, false))
+
+	t.Run("Diffs", func(t *testing.T) {
+		history, err := service.GetHistory(ctx, taskID)
+		require.NoError(t, err)
+		require.Equal(t, []string{"1 created", "2 updated"}, ops(history))
+
+		fields := []string{}
+		for _, change := range history[0].Changes {
+			fields = append(fields, change.Field)
+		}
+		assert.Equal(t, []string{"header", "description", "status", "parent_id", "created_at"}, fields)
+
+		assert.Equal(t, uint64(2), history[1].Version)
+		assert.Equal(t, testNow.Add(time.Hour), history[1].RecordedAt)
+		assert.Equal(t, []models.FieldChange{
+			{Field: "header", From: "Write notes", To: "Write release notes"},
+			{Field: "tags", To: []interface{}{"docs"}},
+		}, history[1].Changes)
+	})
+
+	t.Run("Revert", func(t *testing.T) {
+		task, err := service.RevertTask(ctx, taskID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "Write notes", task.Header)
+		assert.Empty(t, task.Tags)
+		assert.Equal(t, uint64(3), task.Version)
+		assert.Equal(t, testNow, task.CreatedAt)
+
+		history, err := service.GetHistory(ctx, taskID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1 created", "2 updated", "3 updated"}, ops(history))
+		assert.Equal(t, []models.FieldChange{
+			{Field: "header", From: "Write release notes", To: "Write notes"},
+			{Field: "tags", From: []interface{}{"docs"}},
+		}, history[2].Changes)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		_, err := service.RevertTask(ctx, taskID, 9)
+		assert.ErrorIs(t, err, ErrRevisionNotFound)
+
+		_, err = service.GetHistory(ctx, 99)
+		assert.ErrorIs(t, err, repoTasks.ErrTaskNotFound)
+
+		ctrl := gomock.NewController(t)
+		plain := New(mocks.NewMockRepo(ctrl), clock, DefaultWorkflow(), nil)
+		_, err = plain.GetHistory(ctx, taskID)
+		assert.ErrorIs(t, err, ErrNoHistory)
+	})
+
+	t.Run("DeleteAndRestore", func(t *testing.T) {
+		require.NoError(t, service.DeleteTask(ctx, parentID, models.ChildrenOrphan))
+
+		history, err := service.GetHistory(ctx, parentID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1 created", "2 deleted"}, ops(history))
+		assert.Contains(t, history[1].Changes, models.FieldChange{Field: "header", From: "Release"})
+
+		history, err = service.GetHistory(ctx, taskID)
+		require.NoError(t, err)
+		assert.Equal(t, []models.FieldChange{{Field: "parent_id", From: float64(parentID)}}, history[3].Changes)
+
+		_, err = service.RevertTask(ctx, parentID, 2)
+		assert.ErrorIs(t, err, ErrNotRevertible)
+
+		_, err = service.RevertTask(ctx, taskID, 1)
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr, "the parent is gone")
+		assert.Equal(t, "parent_id", validationErr.Fields[0].Field)
+
+		_, err = service.RevertTask(ctx, parentID, 1)
+		assert.ErrorIs(t, err, repoTasks.ErrTaskTrashed)
+
+		task, err := service.RestoreTask(ctx, parentID)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2), task.Version)
+		require.NoError(t, service.DeleteTask(ctx, parentID, ""))
+		require.NoError(t, service.PurgeTask(ctx, parentID))
+
+		task, err = service.RevertTask(ctx, parentID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "Release", task.Header)
+		assert.Equal(t, uint64(1), task.Version)
+
+		history, err = service.GetHistory(ctx, parentID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1 created", "2 deleted", "3 restored", "4 deleted", "5 created"}, ops(history))
+	})
+
+	t.Run("Actor", func(t *testing.T) {
+		taskID, err := service.CreateTask(actor.With(ctx, "alice"), &models.TaskDTO{Header: "Audit"})
+		require.NoError(t, err)
+
+		history, err := service.GetHistory(ctx, taskID)
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+		assert.Equal(t, "alice", history[0].Actor)
+	})
+
+	t.Run("RemindersAreNotRevisions", func(t *testing.T) {
+		dueAt := clock.now.Add(time.Hour)
+		taskID, err := service.CreateTask(ctx, &models.TaskDTO{Header: "Call", DueAt: &dueAt,
+			Reminders: []models.Duration{models.Duration(2 * time.Hour)}})
+		require.NoError(t, err)
+
+		fired, err := NewReminderScheduler(service, &fakeNotifier{}, time.Minute).FireDue(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 1, fired)
+
+		history, err := service.GetHistory(ctx, taskID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1 created"}, ops(history))
+	})
+}
+
+func TestTrash(t *testing.T) {
+	publisher := &fakePublisher{}
+	clock := &fakeClock{now: testNow}
+	service := New(repoTasks.New(), clock, DefaultWorkflow(), publisher)
+	ctx := context.Background()
+
+	parentID, err := service.CreateTask(ctx, &models.TaskDTO{Header: "Release"})
+	require.NoError(t, err)
+	childID, err := service.CreateTask(ctx, &models.TaskDTO{Header: "Build", ParentID: &parentID})
+	require.NoError(t, err)
+	publisher.take()
+
+	t.Run("DeleteAndRestore", func(t *testing.T) {
+		require.NoError(t, service.DeleteTask(ctx, parentID, models.ChildrenCascade))
+		assert.Equal(t, []string{"task.deleted 1", "task.deleted 0"}, publisher.take())
+		_, err := service.GetTask(ctx, parentID)
+		assert.ErrorIs(t, err, repoTasks.ErrTaskNotFound)
+
+		trash, err := service.GetTrash(ctx)
+		require.NoError(t, err)
+		require.Len(t, trash, 2)
+		assert.Equal(t, testNow, *trash[0].DeletedAt)
+
+		clock.now = testNow.Add(time.Hour)
+		task, err := service.RestoreTask(ctx, parentID)
+		require.NoError(t, err)
+		assert.Nil(t, task.DeletedAt)
+		assert.Equal(t, clock.now, task.UpdatedAt)
+		assert.Equal(t, []string{"task.created 0", "task.created 1"}, publisher.take())
+
+		_, err = service.RestoreTask(ctx, parentID)
+		assert.ErrorIs(t, err, repoTasks.ErrTaskNotFound)
+	})
+
+	t.Run("RestoreWithoutParent", func(t *testing.T) {
+		require.NoError(t, service.DeleteTask(ctx, childID, ""))
+		require.NoError(t, service.DeleteTask(ctx, parentID, ""))
+		publisher.take()
+
+		_, err := service.RestoreTask(ctx, childID)
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "parent_id", validationErr.Fields[0].Field)
+	})
+
+	t.Run("Purge", func(t *testing.T) {
+		purged, err := service.PurgeTrash(ctx, 2*time.Hour)
+		require.NoError(t, err)
+		assert.Zero(t, purged)
+
+		clock.now = clock.now.Add(3 * time.Hour)
+		require.NoError(t, service.PurgeTask(ctx, childID))
+		purged, err = service.PurgeTrash(ctx, 2*time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, 1, purged)
+
+		trash, err := service.GetTrash(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, trash)
+		assert.ErrorIs(t, service.PurgeTask(ctx, childID), repoTasks.ErrTaskNotFound)
+		assert.Empty(t, publisher.take())
+	})
+
+	t.Run("Purger", func(t *testing.T) {
+		_, err := service.CreateTask(ctx, &models.TaskDTO{Header: "Docs"})
+		require.NoError(t, err)
+		require.NoError(t, service.DeleteTask(ctx, 2, ""))
+		clock.now = clock.now.Add(DefaultTrashRetention + time.Second)
+
+		purger := NewTrashPurger(service, 0, time.Millisecond)
+		purger.Start()
+		require.Eventually(t, func() bool {
+			trash, err := service.GetTrash(ctx)
+			return err == nil && len(trash) == 0
+		}, time.Second, time.Millisecond)
+		purger.Stop()
+	})
+}
+
+func TestBatchTasks(t *testing.T) {
+	publisher := &fakePublisher{}
+	clock := &fakeClock{now: testNow}
+	repo := repoTasks.New()
+	service := New(NewHistoryRepo(repo, repo, clock), clock, DefaultWorkflow(), publisher)
+	ctx := context.Background()
+
+	idOf := func(taskID uint) *uint { return &taskID }
+	statuses := func(results []*models.BatchResult) []string {
+		result := []string{}
+		for _, r := range results {
+			result = append(result, r.Status)
+		}
+		return result
+	}
+
+	parentID, err := service.CreateTask(ctx, &models.TaskDTO{Header: "Release"})
+	require.NoError(t, err)
+	publisher.take()
+
+	t.Run("Atomic", func(t *testing.T) {
+		results, err := service.BatchTasks(ctx, []models.BatchOperation{
+			{Op: models.BatchCreate, Task: &models.TaskDTO{Header: "Build", ParentID: &parentID}},
+			{Op: models.BatchUpdate, ID: idOf(parentID), Version: versionOf(1),
+				Task: &models.TaskDTO{Header: "Release 2", Status: models.StatusInProgress}},
+			{Op: models.BatchCreate, Task: &models.TaskDTO{Header: "Docs"}},
+			{Op: models.BatchDelete, ID: idOf(2)},
+		}, true)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"done", "done", "done", "done"}, statuses(results))
+		assert.Equal(t, uint(1), *results[0].ID)
+		assert.Equal(t, uint64(2), results[1].Version)
+		assert.Equal(t, []uint{2}, results[3].Deleted)
+		assert.Equal(t, []string{"task.created 1", "task.updated 0", "task.created 2", "task.deleted 2"},
+			publisher.take())
+
+		history, err := service.GetHistory(ctx, parentID)
+		require.NoError(t, err)
+		assert.Len(t, history, 2)
+		history, err = service.GetHistory(ctx, 2)
+		require.NoError(t, err)
+		assert.Equal(t, models.RevisionDeleted, history[0].Op, "created and deleted in one batch")
+	})
+
+	t.Run("AtomicFailure", func(t *testing.T) {
+		results, err := service.BatchTasks(ctx, []models.BatchOperation{
+			{Op: models.BatchCreate, Task: &models.TaskDTO{Header: "Deploy"}},
+			{Op: models.BatchUpdate, ID: idOf(parentID), Task: &models.TaskDTO{Header: "Release 3"}},
+			{Op: models.BatchDelete, ID: idOf(99)},
+			{Op: models.BatchCreate, Task: &models.TaskDTO{Header: "Never"}},
+		}, true)
+		var batchErr *BatchError
+		require.ErrorAs(t, err, &batchErr)
+		assert.Equal(t, 2, batchErr.Index)
+		assert.ErrorIs(t, err, repoTasks.ErrTaskNotFound)
+		assert.Equal(t, []string{"rolled_back", "rolled_back", "failed", "skipped"}, statuses(results))
+		assert.ErrorIs(t, results[2].Err, repoTasks.ErrTaskNotFound)
+		assert.Empty(t, publisher.take())
+
+		task, err := service.GetTask(ctx, parentID)
+		require.NoError(t, err)
+		assert.Equal(t, "Release 2", task.Header)
+		taskID, err := service.CreateTask(ctx, &models.TaskDTO{Header: "Next"})
+		require.NoError(t, err)
+		assert.Equal(t, uint(3), taskID)
+		publisher.take()
+	})
+
+	t.Run("BestEffort", func(t *testing.T) {
+		results, err := service.BatchTasks(ctx, []models.BatchOperation{
+			{Op: models.BatchCreate, Task: &models.TaskDTO{Header: ""}},
+			{Op: models.BatchUpdate, ID: idOf(3), Task: &models.TaskDTO{Header: "Next 2"}},
+			{Op: models.BatchDelete, ID: idOf(1), Version: versionOf(7)},
+			{Op: "rename", ID: idOf(1)},
+			{Op: models.BatchDelete, Children: "all"},
+		}, false)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"failed", "done", "failed", "failed", "failed"}, statuses(results))
+		var validationErr *ValidationError
+		require.ErrorAs(t, results[0].Err, &validationErr)
+		assert.ErrorIs(t, results[2].Err, repoTasks.ErrVersionMismatch)
+		require.ErrorAs(t, results[3].Err, &validationErr)
+		assert.Equal(t, "op", validationErr.Fields[0].Field)
+		require.ErrorAs(t, results[4].Err, &validationErr)
+		assert.Equal(t, []FieldError{
+			{Field: "id", Rule: RuleRequired, Message: "is required"},
+			{Field: "children", Rule: RuleOneOf, Message: "must be reject, cascade or orphan"},
+		}, validationErr.Fields)
+		assert.Equal(t, []string{"task.updated 3"}, publisher.take())
+	})
+}
+
+func TestImportTasks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepo(ctrl)
+	service := New(mockRepo, &fakeClock{now: testNow}, DefaultWorkflow(), nil)
+	ctx := context.Background()
+
+	parentID := uint(1)
+	missingID := uint(9)
+	rows := []*models.ImportRow{
+		{Row: 2, Task: &models.TaskDTO{Header: "First"}},
+		{Row: 3, Task: &models.TaskDTO{Header: " "}},
+		{Row: 4, Err: assert.AnError},
+		{Row: 5, Task: &models.TaskDTO{Header: "Orphan", ParentID: &missingID}},
+		{Row: 6, Task: &models.TaskDTO{Header: "Child", ParentID: &parentID}},
+	}
+	// read hands out rows one at a time and counts how many were read.
+	read := func(rows []*models.ImportRow, count *int) func() (*models.ImportRow, error) {
+		return func() (*models.ImportRow, error) {
+			if *count == len(rows) {
+				return nil, io.EOF
+			}
+			*count++
+			return rows[*count-1], nil
+		}
+	}
+	failedRows := func(report *models.ImportReport) []int {
+		var failed []int
+		for _, importErr := range report.Errors {
+			failed = append(failed, importErr.Row)
+		}
+		return failed
+	}
+
+	t.Run("SkipsFailedRows", func(t *testing.T) {
+		mockRepo.EXPECT().StoreTask(ctx, gomock.Any()).Return(uint(10), nil)
+		mockRepo.EXPECT().StoreTask(ctx, gomock.Any()).Return(uint(0), repoTasks.ErrParentNotFound)
+		mockRepo.EXPECT().StoreTask(ctx, gomock.Any()).Return(uint(11), nil)
+
+		var count int
+		report, err := service.ImportTasks(ctx, read(rows, &count), false, false)
+		require.NoError(t, err)
+		assert.Equal(t, 5, report.Rows)
+		assert.Equal(t, 2, report.Imported)
+		assert.Equal(t, 3, report.Failed)
+		assert.False(t, report.Aborted)
+		assert.Equal(t, []int{3, 4, 5}, failedRows(report))
+
+		var validationErr *ValidationError
+		require.ErrorAs(t, report.Errors[2].Err, &validationErr)
+		assert.Equal(t, "parent_id", validationErr.Fields[0].Field)
+		assert.ErrorIs(t, report.Errors[1].Err, assert.AnError)
+	})
+
+	t.Run("AbortCreatesNothing", func(t *testing.T) {
+		publisher := &fakePublisher{}
+		service := New(repoTasks.New(), &fakeClock{now: testNow}, DefaultWorkflow(), publisher)
+
+		var count int
+		report, err := service.ImportTasks(ctx, read(rows, &count), false, true)
+		require.NoError(t, err)
+		assert.Equal(t, 2, report.Rows)
+		assert.Equal(t, 0, report.Imported)
+		assert.Equal(t, 1, report.Failed)
+		assert.True(t, report.Aborted)
+		assert.Equal(t, []int{3}, failedRows(report))
+		all, err := service.GetAllTasks(ctx, &models.TaskQuery{})
+		require.NoError(t, err)
+		assert.Empty(t, all.Tasks)
+		assert.Empty(t, publisher.take())
+	})
+
+	t.Run("AbortCommitsCleanFile", func(t *testing.T) {
+		publisher := &fakePublisher{}
+		service := New(repoTasks.New(), &fakeClock{now: testNow}, DefaultWorkflow(), publisher)
+		clean := []*models.ImportRow{
+			{Row: 2, Task: &models.TaskDTO{Header: "Build"}, ParentUID: "release"},
+			{Row: 3, Task: &models.TaskDTO{Header: "Release"}, UID: "release"},
+		}
+
+		var count int
+		report, err := service.ImportTasks(ctx, read(clean, &count), false, true)
+		require.NoError(t, err)
+		assert.Equal(t, 2, report.Imported)
+		assert.False(t, report.Aborted)
+		assert.Equal(t, []string{"task.created 0", "task.created 1"}, publisher.take())
+		build, err := service.GetTask(ctx, 1)
+		require.NoError(t, err)
+		require.NotNil(t, build.ParentID)
+		assert.Equal(t, uint(0), *build.ParentID)
+	})
+
+	t.Run("DryRunCreatesNothing", func(t *testing.T) {
+		mockRepo.EXPECT().LoadTask(ctx, missingID).Return(nil, repoTasks.ErrTaskNotFound)
+		mockRepo.EXPECT().LoadTask(ctx, parentID).Return(&models.TaskDomain{ID: parentID}, nil)
+
+		var count int
+		report, err := service.ImportTasks(ctx, read(rows, &count), true, false)
+		require.NoError(t, err)
+		assert.True(t, report.DryRun)
+		assert.Equal(t, 2, report.Imported)
+		assert.Equal(t, []int{3, 4, 5}, failedRows(report))
+	})
+
+	t.Run("ErrorsAreCapped", func(t *testing.T) {
+		blank := make([]*models.ImportRow, MaxImportErrors+1)
+		for i := range blank {
+			blank[i] = &models.ImportRow{Row: i + 2, Task: &models.TaskDTO{}}
+		}
+
+		var count int
+		report, err := service.ImportTasks(ctx, read(blank, &count), true, false)
+		require.NoError(t, err)
+		assert.Equal(t, MaxImportErrors+1, report.Failed)
+		assert.Len(t, report.Errors, MaxImportErrors)
+	})
+
+	t.Run("RepositoryErrorStopsImport", func(t *testing.T) {
+		mockRepo.EXPECT().StoreTask(ctx, gomock.Any()).Return(uint(0), assert.AnError)
+
+		var count int
+		report, err := service.ImportTasks(ctx, read(rows, &count), false, false)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "service/import.go - row 2")
+		assert.Equal(t, 1, report.Rows)
+	})
+
+	t.Run("ReadErrorStopsImport", func(t *testing.T) {
+		report, err := service.ImportTasks(ctx, func() (*models.ImportRow, error) {
+			return nil, assert.AnError
+		}, false, false)
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, 0, report.Rows)
+	})
+
+	t.Run("ParentUIDLinksRowsOfTheFile", func(t *testing.T) {
+		service := New(repoTasks.New(), &fakeClock{now: testNow}, DefaultWorkflow(), nil)
+		_, err := service.CreateTask(ctx, &models.TaskDTO{Header: "Existing"})
+		require.NoError(t, err)
+		existingID := uint(0)
+		uidRows := []*models.ImportRow{
+			{Row: 2, Task: &models.TaskDTO{Header: "Compile"}, UID: "compile", ParentUID: "build"},
+			{Row: 3, Task: &models.TaskDTO{Header: "Orphan"}, ParentUID: "missing"},
+			{Row: 4, Task: &models.TaskDTO{Header: "Build"}, UID: "build", ParentUID: "release"},
+			{Row: 5, Task: &models.TaskDTO{Header: "Release"}, UID: "release"},
+			{Row: 6, Task: &models.TaskDTO{Header: "Fallback", ParentID: &existingID}, ParentUID: "existing"},
+		}
+		parentOf := func(t *testing.T, taskID uint) uint {
+			task, err := service.GetTask(ctx, taskID)
+			require.NoError(t, err)
+			require.NotNil(t, task.ParentID, task.Header)
+			return *task.ParentID
+		}
+
+		var count int
+		report, err := service.ImportTasks(ctx, read(uidRows, &count), true, false)
+		require.NoError(t, err)
+		assert.Equal(t, 4, report.Imported)
+		assert.Equal(t, []int{3}, failedRows(report))
+
+		count = 0
+		report, err = service.ImportTasks(ctx, read(uidRows, &count), false, false)
+		require.NoError(t, err)
+		assert.Equal(t, 4, report.Imported)
+		assert.Equal(t, []int{3}, failedRows(report))
+		// Release, Build and Compile are created in that order once Release
+		// is read, Fallback last under the existing task.
+		release, err := service.GetTask(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "Release", release.Header)
+		assert.Equal(t, uint(1), parentOf(t, 2))
+		assert.Equal(t, uint(2), parentOf(t, 3))
+		assert.Equal(t, existingID, parentOf(t, 4))
+	})
+
+	t.Run("ParentUIDCycleFails", func(t *testing.T) {
+		service := New(repoTasks.New(), &fakeClock{now: testNow}, DefaultWorkflow(), nil)
+		cycle := []*models.ImportRow{
+			{Row: 2, Task: &models.TaskDTO{Header: "A"}, UID: "a", ParentUID: "b"},
+			{Row: 3, Task: &models.TaskDTO{Header: "B"}, UID: "b", ParentUID: "a"},
+		}
+
+		var count int
+		report, err := service.ImportTasks(ctx, read(cycle, &count), false, false)
+		require.NoError(t, err)
+		assert.Equal(t, []int{2, 3}, failedRows(report))
+	})
+
+	t.Run("DoneRecurringRowRecurs", func(t *testing.T) {
+		service := New(repoTasks.New(), &fakeClock{now: testNow}, DefaultWorkflow(), nil)
+		dueAt := testNow.Add(time.Hour)
+		row := &models.ImportRow{Row: 2, Task: &models.TaskDTO{Header: "Standup", Status: models.StatusDone,
+			DueAt: &dueAt, Recurrence: "FREQ=DAILY"}}
+
+		var count int
+		report, err := service.ImportTasks(ctx, read([]*models.ImportRow{row}, &count), false, false)
+		require.NoError(t, err)
+		assert.Equal(t, 1, report.Imported)
+		task, err := service.GetTask(ctx, 0)
+		require.NoError(t, err)
+		require.NotNil(t, task.NextOccurrenceID)
+		next, err := service.GetTask(ctx, *task.NextOccurrenceID)
+		require.NoError(t, err)
+		assert.Equal(t, models.StatusTodo, next.Status)
+		assert.Equal(t, dueAt.AddDate(0, 0, 1), *next.DueAt)
+	})
+}
+
+func versionOf(version uint64) *uint64 {
+	return &version
+}
@@ -0,0 +1,44 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/avraam311/tasks-service/internal/models"
+	repoTasks "github.com/avraam311/tasks-service/internal/repository/tasks"
+)
+
+const RuleParent = "parent"
+
+func (s *Service) GetChildren(ctx context.Context, taskID uint) ([]*models.TaskDomain, error) {
+	children, err := s.repo.LoadChildren(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("service/subtasks.go - %w", err)
+	}
+
+	return children, nil
+}
+
+func (s *Service) GetTaskTree(ctx context.Context, taskID uint) (*models.TaskTree, error) {
+	trees, err := s.repo.LoadTaskTrees(ctx, []uint{taskID})
+	if err != nil {
+		return nil, fmt.Errorf("service/subtasks.go - %w", err)
+	}
+
+	return trees[0], nil
+}
+
+func parentError(err error) error {
+	var message string
+	switch {
+	case errors.Is(err, repoTasks.ErrParentNotFound):
+		message = "parent task does not exist"
+	case errors.Is(err, repoTasks.ErrParentCycle):
+		message = "must not make the task its own ancestor"
+	default:
+		return err
+	}
+
+	return &ValidationError{Fields: []FieldError{{Field: "parent_id", Rule: RuleParent, Message: message}}}
+}
@@ -0,0 +1,72 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+const (
+	MaxTags      = 20
+	MaxTagLength = 50
+)
+
+func (s *Service) GetTags(ctx context.Context) ([]*models.TagCount, error) {
+	tags, err := s.repo.LoadTags(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("service/tags.go - %w", err)
+	}
+
+	return tags, nil
+}
+
+// Tasks that already carry newName simply lose oldName.
+func (s *Service) RenameTag(ctx context.Context, oldName, newName string) (*models.TagCount, error) {
+	oldName, newName = normalizeTag(oldName), normalizeTag(newName)
+	if fieldErr := checkRule("name", RuleTags, reflect.ValueOf([]string{newName})); fieldErr != nil {
+		return nil, fmt.Errorf("service/tags.go - %w", &ValidationError{Fields: []FieldError{*fieldErr}})
+	}
+
+	var renamedIDs []uint
+	renamed, err := s.repo.SwapTaggedTasks(ctx, oldName, recording(func(task *models.TaskDomain) error {
+		tags := make([]string, 0, len(task.Tags))
+		for _, tag := range task.Tags {
+			if tag == oldName {
+				tag = newName
+			}
+			tags = append(tags, tag)
+		}
+		task.Tags = normalizeTags(tags)
+		task.UpdatedAt = s.clock.Now().UTC()
+		return nil
+	}, &renamedIDs))
+	if err != nil {
+		return nil, fmt.Errorf("service/tags.go - %w", err)
+	}
+	s.publishLoaded(ctx, models.EventTaskUpdated, renamedIDs...)
+
+	return &models.TagCount{Name: newName, Count: renamed}, nil
+}
+
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// Blank tags are kept so that validation reports them.
+func normalizeTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		normalized = append(normalized, normalizeTag(tag))
+	}
+	slices.Sort(normalized)
+
+	return slices.Compact(normalized)
+}
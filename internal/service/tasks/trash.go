@@ -0,0 +1,117 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+const (
+	DefaultTrashRetention     = 30 * 24 * time.Hour
+	DefaultTrashPurgeInterval = time.Hour
+)
+
+func (s *Service) GetTrash(ctx context.Context) ([]*models.TaskDomain, error) {
+	tasks, err := s.repo.LoadTrash(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("service/trash.go - %w", err)
+	}
+
+	return tasks, nil
+}
+
+func (s *Service) RestoreTask(ctx context.Context, taskID uint) (*models.TaskDomain, error) {
+	restored, err := s.repo.RestoreTask(ctx, taskID, s.touch)
+	if err != nil {
+		return nil, fmt.Errorf("service/trash.go - %w", parentError(err))
+	}
+	s.publishLoaded(ctx, models.EventTaskCreated, restored...)
+
+	task, err := s.repo.LoadTask(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("service/trash.go - %w", err)
+	}
+
+	return task, nil
+}
+
+func (s *Service) PurgeTask(ctx context.Context, taskID uint) error {
+	if _, err := s.repo.PurgeTask(ctx, taskID); err != nil {
+		return fmt.Errorf("service/trash.go - %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) PurgeTrash(ctx context.Context, retention time.Duration) (int, error) {
+	purged, err := s.repo.PurgeTrash(ctx, s.clock.Now().UTC().Add(-retention))
+	if err != nil {
+		return 0, fmt.Errorf("service/trash.go - %w", err)
+	}
+
+	return len(purged), nil
+}
+
+type TrashPurger struct {
+	service   *Service
+	retention time.Duration
+	interval  time.Duration
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+func NewTrashPurger(service *Service, retention, interval time.Duration) *TrashPurger {
+	if retention <= 0 {
+		retention = DefaultTrashRetention
+	}
+	if interval <= 0 {
+		interval = DefaultTrashPurgeInterval
+	}
+
+	return &TrashPurger{
+		service:   service,
+		retention: retention,
+		interval:  interval,
+	}
+}
+
+func (p *TrashPurger) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	p.wg.Add(1)
+	go p.loop(ctx)
+}
+
+func (p *TrashPurger) Stop() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.wg.Wait()
+}
+
+func (p *TrashPurger) loop(ctx context.Context) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		purged, err := p.service.PurgeTrash(ctx, p.retention)
+		switch {
+		case err != nil:
+			slog.Error("failed to purge trash", slog.Any("error", err))
+		case purged > 0:
+			slog.Info("purged trash", slog.Int("tasks", purged))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -7,11 +7,60 @@ import (
 	"github.com/avraam311/tasks-service/internal/models"
 )
 
-func (s *Service) UpdateTask(ctx context.Context, taskID uint, task *models.TaskDTO) error {
-	err := s.repo.SwapTask(ctx, taskID, task)
-	if err != nil {
+func (s *Service) UpdateTask(ctx context.Context, taskID uint, task *models.TaskDTO, force bool) error {
+	if err := prepareTask(task); err != nil {
 		return fmt.Errorf("service/update_task.go - %w", err)
 	}
 
+	var finished bool
+	updated, err := s.repo.SwapTask(ctx, taskID, finishing(s.updateWith(task, force), &finished))
+	if err != nil {
+		return fmt.Errorf("service/update_task.go - %w", parentError(err))
+	}
+	s.publishUpdate(updated, finished)
+	s.recur(ctx, updated)
+
 	return nil
 }
+
+func (s *Service) UpdateTaskIfMatch(ctx context.Context, taskID uint, version uint64, task *models.TaskDTO,
+	force bool) (uint64, error) {
+	if err := prepareTask(task); err != nil {
+		return 0, fmt.Errorf("service/update_task.go - %w", err)
+	}
+
+	var finished bool
+	updated, err := s.repo.CompareAndSwapTask(ctx, taskID, version, finishing(s.updateWith(task, force), &finished))
+	if err != nil {
+		return 0, fmt.Errorf("service/update_task.go - %w", parentError(err))
+	}
+	s.publishUpdate(updated, finished)
+
+	return s.recur(ctx, updated).Version, nil
+}
+
+func (s *Service) UpsertTask(ctx context.Context, taskID uint, task *models.TaskDTO, force bool) (bool, error) {
+	if err := prepareTask(task); err != nil {
+		return false, fmt.Errorf("service/update_task.go - %w", err)
+	}
+
+	var finished bool
+	updated, created, err := s.repo.UpsertTask(ctx, taskID, finishing(s.updateWith(task, force), &finished))
+	if err != nil {
+		return false, fmt.Errorf("service/update_task.go - %w", parentError(err))
+	}
+	if created {
+		s.publish(models.EventTaskCreated, updated.ID, updated)
+	} else {
+		s.publishUpdate(updated, finished)
+	}
+	s.recur(ctx, updated)
+
+	return created, nil
+}
+
+func (s *Service) updateWith(dto *models.TaskDTO, force bool) func(task *models.TaskDomain) error {
+	return func(task *models.TaskDomain) error {
+		return s.applyDTO(task, dto, force)
+	}
+}
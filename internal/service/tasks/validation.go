@@ -0,0 +1,202 @@
+package tasks
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+const (
+	RuleRequired   = "required"
+	RuleNotBlank   = "notblank"
+	RuleSingleLine = "singleline"
+	RuleNoControl  = "nocontrol"
+	RuleMax        = "max"
+	RuleTimeRange  = "timerange"
+	RuleTags       = "tags"
+	RuleStatus     = "status"
+	RuleRecurrence = "recurrence"
+	RuleReminders  = "reminders"
+	RuleTimeZone   = "timezone"
+)
+
+// Timestamps outside [minTime, maxTime) cannot be written as four-digit
+// RFC 3339 years.
+var (
+	minTime = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+	maxTime = time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		parts = append(parts, field.Field+": "+field.Message)
+	}
+
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Fields are reported by their JSON names.
+func validate(v interface{}) error {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	typ := value.Type()
+
+	var fieldErrors []FieldError
+	for i := 0; i < typ.NumField(); i++ {
+		tag := typ.Field(i).Tag.Get("validate")
+		if tag == "" {
+			continue
+		}
+
+		name := strings.Split(typ.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = typ.Field(i).Name
+		}
+		for _, rule := range strings.Split(tag, ",") {
+			if fieldErr := checkRule(name, rule, value.Field(i)); fieldErr != nil {
+				fieldErrors = append(fieldErrors, *fieldErr)
+				break
+			}
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return &ValidationError{Fields: fieldErrors}
+	}
+
+	return nil
+}
+
+func prepareTask(task *models.TaskDTO) error {
+	task.Tags = normalizeTags(task.Tags)
+	task.Reminders = normalizeReminders(task.Reminders)
+
+	if err := validate(task); err != nil {
+		return err
+	}
+	if task.DueAt == nil {
+		switch {
+		case task.Recurrence != "":
+			return &ValidationError{Fields: []FieldError{
+				{Field: "due_at", Rule: RuleRequired, Message: "is required for a recurring task"},
+			}}
+		case len(task.Reminders) > 0:
+			return &ValidationError{Fields: []FieldError{
+				{Field: "due_at", Rule: RuleRequired, Message: "is required for a task with reminders"},
+			}}
+		}
+	}
+
+	return nil
+}
+
+func checkRule(field, rule string, value reflect.Value) *FieldError {
+	ruleName, arg, _ := strings.Cut(rule, "=")
+	fail := func(message string) *FieldError {
+		return &FieldError{Field: field, Rule: ruleName, Message: message}
+	}
+
+	switch ruleName {
+	case RuleRequired:
+		if value.IsZero() {
+			return fail("is required")
+		}
+		return nil
+	case RuleTimeRange:
+		t, ok := value.Interface().(*time.Time)
+		if ok && t != nil && (t.Before(minTime) || !t.Before(maxTime)) {
+			return fail("must be between years 1970 and 9999")
+		}
+		return nil
+	case RuleTags:
+		tags, _ := value.Interface().([]string)
+		if len(tags) > MaxTags {
+			return fail(fmt.Sprintf("must have at most %d tags", MaxTags))
+		}
+		for _, tag := range tags {
+			if strings.TrimSpace(tag) == "" {
+				return fail("must not contain blank tags")
+			}
+			if utf8.RuneCountInString(tag) > MaxTagLength {
+				return fail(fmt.Sprintf("tag %q must be at most %d characters long", tag, MaxTagLength))
+			}
+			if strings.IndexFunc(tag, unicode.IsControl) >= 0 {
+				return fail(fmt.Sprintf("tag %q must not contain control characters", tag))
+			}
+		}
+		return nil
+	case RuleReminders:
+		offsets, _ := value.Interface().([]models.Duration)
+		if len(offsets) > MaxReminders {
+			return fail(fmt.Sprintf("must have at most %d reminders", MaxReminders))
+		}
+		for _, offset := range offsets {
+			if offset <= 0 || time.Duration(offset) > MaxReminderOffset {
+				return fail(fmt.Sprintf("reminder %s must be positive and at most %s", time.Duration(offset),
+					MaxReminderOffset))
+			}
+		}
+		return nil
+	}
+
+	if value.Kind() != reflect.String {
+		return nil
+	}
+	str := value.String()
+
+	switch ruleName {
+	case RuleNotBlank:
+		if strings.TrimSpace(str) == "" {
+			return fail("must not be blank")
+		}
+	case RuleSingleLine:
+		if strings.ContainsAny(str, "\r\n") {
+			return fail("must be a single line")
+		}
+	case RuleNoControl:
+		for _, r := range str {
+			if unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r' {
+				return fail("must not contain control characters")
+			}
+		}
+	case RuleMax:
+		limit, err := strconv.Atoi(arg)
+		if err == nil && utf8.RuneCountInString(str) > limit {
+			return fail(fmt.Sprintf("must be at most %d characters long", limit))
+		}
+	case RuleStatus:
+		if str != "" && !slices.Contains(models.Statuses, str) {
+			return fail("must be one of " + strings.Join(models.Statuses, ", "))
+		}
+	case RuleRecurrence:
+		if str == "" {
+			return nil
+		}
+		if _, err := ParseRecurrence(str); err != nil {
+			return fail(strings.TrimPrefix(err.Error(), ErrInvalidRecurrence.Error()+": "))
+		}
+	case RuleTimeZone:
+		if _, err := time.LoadLocation(str); err != nil || str == "Local" {
+			return fail("must be an IANA time zone such as Europe/Berlin")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/avraam311/tasks-service/internal/models"
+)
+
+var (
+	ErrTransitionNotFound = errors.New("transition not found")
+	ErrInvalidWorkflow    = errors.New("invalid workflow")
+)
+
+// TransitionError names either the Transition or, for a direct status write,
+// the To status.
+type TransitionError struct {
+	Transition string   `json:"transition,omitempty"`
+	From       string   `json:"from"`
+	To         string   `json:"to,omitempty"`
+	Allowed    []string `json:"allowed"`
+}
+
+func (e *TransitionError) Error() string {
+	if e.Transition != "" {
+		return fmt.Sprintf("transition %q is not allowed from status %q", e.Transition, e.From)
+	}
+
+	return fmt.Sprintf("status cannot change from %q to %q", e.From, e.To)
+}
+
+var DefaultTransitions = []models.Transition{
+	{Name: "start", From: []string{models.StatusTodo}, To: models.StatusInProgress},
+	{Name: "submit", From: []string{models.StatusInProgress}, To: models.StatusInReview},
+	{Name: "approve", From: []string{models.StatusInReview}, To: models.StatusDone},
+	{Name: "reject", From: []string{models.StatusInReview}, To: models.StatusInProgress},
+	{Name: "complete", From: []string{models.StatusTodo, models.StatusInProgress}, To: models.StatusDone},
+	{
+		Name: "cancel",
+		From: []string{models.StatusTodo, models.StatusInProgress, models.StatusInReview},
+		To:   models.StatusCancelled,
+	},
+	{Name: "reopen", From: []string{models.StatusDone, models.StatusCancelled}, To: models.StatusTodo},
+}
+
+type Workflow struct {
+	transitions []models.Transition
+}
+
+func NewWorkflow(transitions []models.Transition) (*Workflow, error) {
+	names := make(map[string]bool, len(transitions))
+	for _, transition := range transitions {
+		if transition.Name == "" || names[transition.Name] {
+			return nil, fmt.Errorf("%w: transition names must be unique and not empty, got %q",
+				ErrInvalidWorkflow, transition.Name)
+		}
+		names[transition.Name] = true
+		for _, status := range append(slices.Clone(transition.From), transition.To) {
+			if !slices.Contains(models.Statuses, status) {
+				return nil, fmt.Errorf("%w: transition %q uses unknown status %q",
+					ErrInvalidWorkflow, transition.Name, status)
+			}
+		}
+	}
+
+	return &Workflow{transitions: slices.Clone(transitions)}, nil
+}
+
+func DefaultWorkflow() *Workflow {
+	return &Workflow{transitions: DefaultTransitions}
+}
+
+func (w *Workflow) next(from, name string) (string, error) {
+	for _, transition := range w.transitions {
+		if transition.Name != name {
+			continue
+		}
+		if !slices.Contains(transition.From, from) {
+			return "", &TransitionError{Transition: name, From: from, Allowed: w.allowed(from)}
+		}
+		return transition.To, nil
+	}
+
+	return "", ErrTransitionNotFound
+}
+
+func (w *Workflow) allows(from, to string) bool {
+	for _, transition := range w.transitions {
+		if transition.To == to && slices.Contains(transition.From, from) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w *Workflow) allowed(status string) []string {
+	names := []string{}
+	for _, transition := range w.transitions {
+		if slices.Contains(transition.From, status) {
+			names = append(names, transition.Name)
+		}
+	}
+
+	return names
+}
+
+func (s *Service) TransitionTask(ctx context.Context, taskID uint, name string, force bool) (*models.TaskDomain, error) {
+	var finished bool
+	task, err := s.repo.SwapTask(ctx, taskID, finishing(func(task *models.TaskDomain) error {
+		status, err := s.workflow.next(task.Status, name)
+		if err != nil {
+			return err
+		}
+		if err := checkBlocked(task, status, force); err != nil {
+			return err
+		}
+
+		now := s.clock.Now().UTC()
+		setStatus(task, status, now)
+		task.UpdatedAt = now
+		return nil
+	}, &finished))
+	if err != nil {
+		return nil, fmt.Errorf("service/workflow.go - %w", err)
+	}
+	s.publishUpdate(task, finished)
+
+	return s.recur(ctx, task), nil
+}
+
+// A task that does not exist yet (empty Status) may start in any status.
+// Without a status, finished true moves the task to done and false moves a
+// done task back to todo.
+func (s *Service) applyDTO(task *models.TaskDomain, dto *models.TaskDTO, force bool) error {
+	status := dto.Status
+	if dto.Finished != nil {
+		switch {
+		case status != "" && *dto.Finished != (status == models.StatusDone):
+			return &ValidationError{Fields: []FieldError{{
+				Field:   "finished",
+				Rule:    RuleStatus,
+				Message: fmt.Sprintf("must be %t for status %q", status == models.StatusDone, status),
+			}}}
+		case status == "" && *dto.Finished:
+			status = models.StatusDone
+		case status == "" && task.Status == models.StatusDone:
+			status = models.StatusTodo
+		}
+	}
+	switch {
+	case status == "" && task.Status == "":
+		status = models.StatusTodo
+	case status == "":
+		status = task.Status
+	case task.Status != "" && status != task.Status && !s.workflow.allows(task.Status, status):
+		return &TransitionError{From: task.Status, To: status, Allowed: s.workflow.allowed(task.Status)}
+	}
+	if err := checkBlocked(task, status, force); err != nil {
+		return err
+	}
+
+	now := s.clock.Now()
+	applyTaskDTO(task, dto, now)
+	setStatus(task, status, now.UTC())
+	return nil
+}
+
+func setStatus(task *models.TaskDomain, status string, now time.Time) {
+	switch {
+	case status == models.StatusDone && task.Status != models.StatusDone:
+		task.CompletedAt = &now
+	case status != models.StatusDone:
+		task.CompletedAt = nil
+	}
+	task.Status = status
+	task.Finished = status == models.StatusDone
+}
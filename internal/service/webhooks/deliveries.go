@@ -0,0 +1,173 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/avraam311/tasks-service/internal/models"
+	repoWebhooks "github.com/avraam311/tasks-service/internal/repository/webhooks"
+)
+
+const (
+	// HeaderSignature is "sha256=" and the hex HMAC-SHA256 of the body keyed
+	// with the webhook secret.
+	HeaderSignature = "X-Webhook-Signature"
+	HeaderEvent     = "X-Webhook-Event"
+	// HeaderDelivery stays the same across retries so receivers can drop
+	// duplicates.
+	HeaderDelivery = "X-Webhook-Delivery"
+)
+
+const maxResponseBody = 64 << 10
+
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Deliveries run in the background, so receivers may get events out of order.
+// When the queue is full the event is dropped for that webhook.
+func (s *Service) Publish(event *models.TaskEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		slog.Error("failed to encode task event", slog.String("event_id", event.ID), slog.Any("error", err))
+		return
+	}
+	webhooks, err := s.repo.LoadWebhooks(s.ctx)
+	if err != nil {
+		slog.Error("failed to load webhooks", slog.Any("error", err))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	for _, webhook := range webhooks {
+		if !webhook.Active || (len(webhook.Events) > 0 && !slices.Contains(webhook.Events, event.Type)) {
+			continue
+		}
+		select {
+		case s.queue <- job{webhook: webhook, event: event, body: body}:
+		default:
+			slog.Warn("webhook delivery queue is full, event dropped", slog.Any("webhook_id", webhook.ID),
+				slog.String("event_id", event.ID))
+		}
+	}
+}
+
+// The webhook is reloaded before a retry, so a retry goes to the current URL
+// and stops once the webhook is deleted or deactivated.
+func (s *Service) deliver(webhook *models.Webhook, event *models.TaskEvent, body []byte) {
+	backoff := s.options.Backoff
+	for attempt := 1; ; attempt++ {
+		delivery := s.send(webhook, event, body, attempt)
+		if s.ctx.Err() != nil {
+			return
+		}
+		if _, err := s.repo.StoreDelivery(s.ctx, delivery); err != nil {
+			if !errors.Is(err, repoWebhooks.ErrWebhookNotFound) {
+				slog.Error("failed to store webhook delivery", slog.Any("webhook_id", webhook.ID),
+					slog.Any("error", err))
+			}
+			return
+		}
+		if delivery.Success || attempt == s.options.MaxAttempts {
+			s.recordResult(webhook.ID, delivery.Success)
+			return
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		case <-s.draining:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		backoff = min(2*backoff, s.options.MaxBackoff)
+
+		current, err := s.repo.LoadWebhook(s.ctx, webhook.ID)
+		if err != nil || !current.Active {
+			return
+		}
+		webhook = current
+	}
+}
+
+func (s *Service) send(webhook *models.Webhook, event *models.TaskEvent, body []byte,
+	attempt int) *models.Delivery {
+	delivery := &models.Delivery{
+		WebhookID: webhook.ID,
+		EventID:   event.ID,
+		EventType: event.Type,
+		Attempt:   attempt,
+		SentAt:    s.clock.Now().UTC(),
+	}
+
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "tasks-service-webhooks")
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, body))
+	req.Header.Set(HeaderEvent, event.Type)
+	req.Header.Set(HeaderDelivery, event.ID)
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	delivery.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+	_ = resp.Body.Close()
+
+	delivery.StatusCode = resp.StatusCode
+	delivery.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !delivery.Success {
+		delivery.Error = fmt.Sprintf("unexpected status %s", resp.Status)
+	}
+
+	return delivery
+}
+
+func (s *Service) recordResult(webhookID uint, success bool) {
+	_, err := s.repo.SwapWebhook(s.ctx, webhookID, func(webhook *models.Webhook) error {
+		if success {
+			webhook.Failures = 0
+			return nil
+		}
+
+		webhook.Failures++
+		if webhook.Active && webhook.Failures >= s.options.DisableAfter {
+			now := s.clock.Now().UTC()
+			webhook.Active = false
+			webhook.DisabledAt = &now
+			slog.Warn("webhook disabled after failed deliveries", slog.Any("webhook_id", webhookID),
+				slog.Int("failures", webhook.Failures))
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, repoWebhooks.ErrWebhookNotFound) {
+		slog.Error("failed to record webhook delivery", slog.Any("webhook_id", webhookID), slog.Any("error", err))
+	}
+}
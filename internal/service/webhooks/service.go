@@ -0,0 +1,142 @@
+package webhooks
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/avraam311/tasks-service/internal/models"
+	serviceTasks "github.com/avraam311/tasks-service/internal/service/tasks"
+)
+
+const (
+	DefaultMaxAttempts  = 5
+	DefaultBackoff      = time.Second
+	DefaultMaxBackoff   = 5 * time.Minute
+	DefaultTimeout      = 10 * time.Second
+	DefaultDisableAfter = 10
+	DefaultWorkers      = 4
+	DefaultQueueSize    = 256
+)
+
+type Repo interface {
+	StoreWebhook(ctx context.Context, webhook *models.Webhook) (uint, error)
+	LoadWebhook(ctx context.Context, webhookID uint) (*models.Webhook, error)
+	LoadWebhooks(ctx context.Context) ([]*models.Webhook, error)
+	SwapWebhook(ctx context.Context, webhookID uint,
+		update func(webhook *models.Webhook) error) (*models.Webhook, error)
+	DeleteWebhook(ctx context.Context, webhookID uint) error
+	StoreDelivery(ctx context.Context, delivery *models.Delivery) (uint, error)
+	LoadDeliveries(ctx context.Context, webhookID uint) ([]*models.Delivery, error)
+}
+
+// Zero fields take the defaults. Backoff doubles after each failed attempt up
+// to MaxBackoff. Workers deliveries run at once and QueueSize more wait for a
+// worker.
+type Options struct {
+	MaxAttempts  int
+	Backoff      time.Duration
+	MaxBackoff   time.Duration
+	Timeout      time.Duration
+	DisableAfter int
+	Workers      int
+	QueueSize    int
+}
+
+type job struct {
+	webhook *models.Webhook
+	event   *models.TaskEvent
+	body    []byte
+}
+
+type Service struct {
+	repo    Repo
+	clock   serviceTasks.Clock
+	client  *http.Client
+	options Options
+
+	queue    chan job
+	draining chan struct{}
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	closed   bool
+}
+
+func New(repo Repo, clock serviceTasks.Clock, options Options) *Service {
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = DefaultMaxAttempts
+	}
+	if options.Backoff <= 0 {
+		options.Backoff = DefaultBackoff
+	}
+	if options.MaxBackoff <= 0 {
+		options.MaxBackoff = DefaultMaxBackoff
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultTimeout
+	}
+	if options.DisableAfter <= 0 {
+		options.DisableAfter = DefaultDisableAfter
+	}
+	if options.Workers <= 0 {
+		options.Workers = DefaultWorkers
+	}
+	if options.QueueSize <= 0 {
+		options.QueueSize = DefaultQueueSize
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Service{
+		repo:     repo,
+		clock:    clock,
+		client:   &http.Client{Timeout: options.Timeout},
+		options:  options,
+		queue:    make(chan job, options.QueueSize),
+		draining: make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	s.wg.Add(options.Workers)
+	for range options.Workers {
+		go s.work()
+	}
+
+	return s
+}
+
+// Close makes one attempt at each queued delivery but schedules no more
+// retries. Once ctx is done, deliveries still running are cancelled. Events
+// published after Close are dropped.
+func (s *Service) Close(ctx context.Context) {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.draining)
+		close(s.queue)
+	}
+	s.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		s.cancel()
+		<-drained
+	}
+	s.cancel()
+}
+
+func (s *Service) work() {
+	defer s.wg.Done()
+
+	for j := range s.queue {
+		s.deliver(j.webhook, j.event, j.body)
+	}
+}
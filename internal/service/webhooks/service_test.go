@@ -0,0 +1,362 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/avraam311/tasks-service/internal/models"
+	repoWebhooks "github.com/avraam311/tasks-service/internal/repository/webhooks"
+	serviceTasks "github.com/avraam311/tasks-service/internal/service/tasks"
+)
+
+var testOptions = Options{MaxAttempts: 3, Backoff: time.Millisecond, MaxBackoff: 4 * time.Millisecond, DisableAfter: 2}
+
+// receiver is an httptest server that answers with the status codes of
+// statuses in turn, repeating the last one, and records what it was sent.
+type receiver struct {
+	server   *httptest.Server
+	statuses []int
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newReceiver(t *testing.T, statuses ...int) *receiver {
+	rcv := &receiver{statuses: statuses}
+	rcv.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rcv.mu.Lock()
+		rcv.requests = append(rcv.requests, r)
+		rcv.bodies = append(rcv.bodies, body)
+		status := rcv.statuses[min(len(rcv.requests), len(rcv.statuses))-1]
+		rcv.mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(rcv.server.Close)
+
+	return rcv
+}
+
+func (rcv *receiver) count() int {
+	rcv.mu.Lock()
+	defer rcv.mu.Unlock()
+
+	return len(rcv.requests)
+}
+
+func newEvent(eventType string) *models.TaskEvent {
+	return &models.TaskEvent{
+		ID:         "evt-" + eventType,
+		Type:       eventType,
+		TaskID:     7,
+		Task:       &models.TaskDomain{ID: 7, Header: "Release"},
+		OccurredAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+// settle waits until the webhook logged want delivery attempts and returns
+// them, newest first.
+func settle(t *testing.T, service *Service, webhookID uint, want int) []*models.Delivery {
+	var deliveries []*models.Delivery
+	require.Eventually(t, func() bool {
+		var err error
+		deliveries, err = service.GetDeliveries(context.Background(), webhookID)
+		require.NoError(t, err)
+		return len(deliveries) >= want
+	}, 2*time.Second, time.Millisecond)
+
+	return deliveries
+}
+
+func TestWebhooks_CRUD(t *testing.T) {
+	service := New(repoWebhooks.New(), serviceTasks.SystemClock{}, testOptions)
+	defer service.Close(context.Background())
+	ctx := context.Background()
+
+	t.Run("Validation", func(t *testing.T) {
+		_, err := service.CreateWebhook(ctx, &models.WebhookDTO{URL: "ftp://example.com",
+			Events: []string{"task.created", "task.exploded"}})
+		var validationErr *serviceTasks.ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		require.Len(t, validationErr.Fields, 2)
+		assert.Equal(t, RuleURL, validationErr.Fields[0].Rule)
+		assert.Equal(t, RuleEvents, validationErr.Fields[1].Rule)
+	})
+
+	t.Run("SecretShownOnCreateOnly", func(t *testing.T) {
+		webhook, err := service.CreateWebhook(ctx, &models.WebhookDTO{URL: "https://example.com/hook",
+			Events: []string{"task.updated", "task.created", "task.updated"}})
+		require.NoError(t, err)
+		assert.NotEmpty(t, webhook.Secret)
+		assert.True(t, webhook.Active)
+		assert.Equal(t, []string{"task.created", "task.updated"}, webhook.Events)
+
+		loaded, err := service.GetWebhook(ctx, webhook.ID)
+		require.NoError(t, err)
+		assert.Empty(t, loaded.Secret)
+
+		webhooks, err := service.GetWebhooks(ctx)
+		require.NoError(t, err)
+		require.Len(t, webhooks, 1)
+		assert.Empty(t, webhooks[0].Secret)
+	})
+
+	t.Run("UpdateKeepsSecret", func(t *testing.T) {
+		repo := repoWebhooks.New()
+		service := New(repo, serviceTasks.SystemClock{}, testOptions)
+		defer service.Close(context.Background())
+
+		webhook, err := service.CreateWebhook(ctx, &models.WebhookDTO{URL: "https://example.com", Secret: "s3cret"})
+		require.NoError(t, err)
+		inactive := false
+		updated, err := service.UpdateWebhook(ctx, webhook.ID, &models.WebhookDTO{URL: "https://example.org",
+			Active: &inactive})
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.org", updated.URL)
+		assert.False(t, updated.Active)
+		assert.Empty(t, updated.Secret)
+
+		stored, err := repo.LoadWebhook(ctx, webhook.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "s3cret", stored.Secret)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := service.GetWebhook(ctx, 42)
+		require.ErrorIs(t, err, repoWebhooks.ErrWebhookNotFound)
+		_, err = service.UpdateWebhook(ctx, 42, &models.WebhookDTO{URL: "https://example.com"})
+		require.ErrorIs(t, err, repoWebhooks.ErrWebhookNotFound)
+		require.ErrorIs(t, service.DeleteWebhook(ctx, 42), repoWebhooks.ErrWebhookNotFound)
+		_, err = service.GetDeliveries(ctx, 42)
+		require.ErrorIs(t, err, repoWebhooks.ErrWebhookNotFound)
+	})
+}
+
+func TestWebhooks_Delivery(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("SignedDelivery", func(t *testing.T) {
+		service := New(repoWebhooks.New(), serviceTasks.SystemClock{}, testOptions)
+		defer service.Close(context.Background())
+		rcv := newReceiver(t, http.StatusOK)
+		webhook, err := service.CreateWebhook(ctx, &models.WebhookDTO{URL: rcv.server.URL + "/hook",
+			Secret: "s3cret"})
+		require.NoError(t, err)
+
+		event := newEvent(models.EventTaskFinished)
+		service.Publish(event)
+		deliveries := settle(t, service, webhook.ID, 1)
+
+		require.Equal(t, 1, rcv.count())
+		req, body := rcv.requests[0], rcv.bodies[0]
+		assert.Equal(t, http.MethodPost, req.Method)
+		assert.Equal(t, "/hook", req.URL.Path)
+		assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+		assert.Equal(t, Sign("s3cret", body), req.Header.Get(HeaderSignature))
+		assert.Equal(t, models.EventTaskFinished, req.Header.Get(HeaderEvent))
+		assert.Equal(t, event.ID, req.Header.Get(HeaderDelivery))
+		var received models.TaskEvent
+		require.NoError(t, json.Unmarshal(body, &received))
+		assert.Equal(t, *event, received)
+
+		assert.Equal(t, event.ID, deliveries[0].EventID)
+		assert.Equal(t, 1, deliveries[0].Attempt)
+		assert.Equal(t, http.StatusOK, deliveries[0].StatusCode)
+		assert.True(t, deliveries[0].Success)
+	})
+
+	t.Run("Sign", func(t *testing.T) {
+		// echo -n '{"id":"1"}' | openssl dgst -sha256 -hmac key
+		assert.Equal(t, "sha256=77cb8fd154ecfa2865657b2915c997c624ee47fe122e8a8bb91b10a09b47fb3c",
+			Sign("key", []byte(`{"id":"1"}`)))
+	})
+
+	t.Run("EventFilter", func(t *testing.T) {
+		service := New(repoWebhooks.New(), serviceTasks.SystemClock{}, testOptions)
+		defer service.Close(context.Background())
+		rcv := newReceiver(t, http.StatusNoContent)
+		webhook, err := service.CreateWebhook(ctx, &models.WebhookDTO{URL: rcv.server.URL,
+			Events: []string{models.EventTaskDeleted}})
+		require.NoError(t, err)
+		inactive := false
+		_, err = service.CreateWebhook(ctx, &models.WebhookDTO{URL: rcv.server.URL, Active: &inactive})
+		require.NoError(t, err)
+
+		service.Publish(newEvent(models.EventTaskCreated))
+		service.Publish(newEvent(models.EventTaskDeleted))
+		deliveries := settle(t, service, webhook.ID, 1)
+
+		assert.Equal(t, models.EventTaskDeleted, deliveries[0].EventType)
+		service.Close(context.Background())
+		assert.Equal(t, 1, rcv.count(), "only the subscribed active webhook gets the event")
+	})
+
+	t.Run("RetriesWithBackoff", func(t *testing.T) {
+		repo := repoWebhooks.New()
+		service := New(repo, serviceTasks.SystemClock{}, testOptions)
+		defer service.Close(context.Background())
+		rcv := newReceiver(t, http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK)
+		webhook, err := service.CreateWebhook(ctx, &models.WebhookDTO{URL: rcv.server.URL})
+		require.NoError(t, err)
+
+		service.Publish(newEvent(models.EventTaskCreated))
+		deliveries := settle(t, service, webhook.ID, 3)
+
+		require.Len(t, deliveries, 3)
+		assert.Equal(t, []int{3, 2, 1}, []int{deliveries[0].Attempt, deliveries[1].Attempt, deliveries[2].Attempt})
+		assert.True(t, deliveries[0].Success)
+		assert.False(t, deliveries[2].Success)
+		assert.Equal(t, http.StatusInternalServerError, deliveries[2].StatusCode)
+		assert.Contains(t, deliveries[2].Error, "500")
+		assert.False(t, deliveries[1].SentAt.Before(deliveries[2].SentAt.Add(testOptions.Backoff)))
+		assert.False(t, deliveries[0].SentAt.Before(deliveries[1].SentAt.Add(2*testOptions.Backoff)))
+
+		service.Close(context.Background())
+		stored, err := repo.LoadWebhook(ctx, webhook.ID)
+		require.NoError(t, err)
+		assert.Zero(t, stored.Failures)
+	})
+
+	t.Run("DisablesAfterFailures", func(t *testing.T) {
+		repo := repoWebhooks.New()
+		service := New(repo, serviceTasks.SystemClock{}, testOptions)
+		defer service.Close(context.Background())
+		rcv := newReceiver(t, http.StatusServiceUnavailable)
+		webhook, err := service.CreateWebhook(ctx, &models.WebhookDTO{URL: rcv.server.URL})
+		require.NoError(t, err)
+
+		for i := range testOptions.DisableAfter {
+			service.Publish(newEvent(models.EventTaskCreated))
+			settle(t, service, webhook.ID, (i+1)*testOptions.MaxAttempts)
+		}
+		require.Eventually(t, func() bool {
+			stored, err := repo.LoadWebhook(ctx, webhook.ID)
+			require.NoError(t, err)
+			return !stored.Active
+		}, 2*time.Second, time.Millisecond)
+
+		stored, err := repo.LoadWebhook(ctx, webhook.ID)
+		require.NoError(t, err)
+		assert.Equal(t, testOptions.DisableAfter, stored.Failures)
+		assert.NotNil(t, stored.DisabledAt)
+
+		sent := rcv.count()
+		service.Publish(newEvent(models.EventTaskCreated))
+		service.Close(context.Background())
+		assert.Equal(t, sent, rcv.count(), "a disabled webhook gets nothing")
+
+		reopened := New(repo, serviceTasks.SystemClock{}, testOptions)
+		defer reopened.Close(context.Background())
+		updated, err := reopened.UpdateWebhook(ctx, webhook.ID, &models.WebhookDTO{URL: rcv.server.URL})
+		require.NoError(t, err)
+		assert.True(t, updated.Active)
+		assert.Zero(t, updated.Failures)
+		assert.Nil(t, updated.DisabledAt)
+	})
+
+	t.Run("UnreachableReceiver", func(t *testing.T) {
+		service := New(repoWebhooks.New(), serviceTasks.SystemClock{}, Options{MaxAttempts: 1})
+		defer service.Close(context.Background())
+		rcv := newReceiver(t, http.StatusOK)
+		rcv.server.Close()
+		webhook, err := service.CreateWebhook(ctx, &models.WebhookDTO{URL: rcv.server.URL})
+		require.NoError(t, err)
+
+		service.Publish(newEvent(models.EventTaskCreated))
+		deliveries := settle(t, service, webhook.ID, 1)
+
+		assert.Zero(t, deliveries[0].StatusCode)
+		assert.NotEmpty(t, deliveries[0].Error)
+	})
+
+	t.Run("CloseStopsRetries", func(t *testing.T) {
+		var attempts atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+		service := New(repoWebhooks.New(), serviceTasks.SystemClock{}, Options{MaxAttempts: 5, Backoff: time.Hour})
+		webhook, err := service.CreateWebhook(ctx, &models.WebhookDTO{URL: server.URL})
+		require.NoError(t, err)
+
+		service.Publish(newEvent(models.EventTaskCreated))
+		settle(t, service, webhook.ID, 1)
+		service.Close(context.Background())
+
+		assert.Equal(t, int32(1), attempts.Load())
+		service.Publish(newEvent(models.EventTaskCreated))
+		assert.Equal(t, int32(1), attempts.Load())
+	})
+
+	t.Run("CloseDrainsQueue", func(t *testing.T) {
+		service := New(repoWebhooks.New(), serviceTasks.SystemClock{}, Options{Workers: 1})
+		rcv := newReceiver(t, http.StatusOK)
+		_, err := service.CreateWebhook(ctx, &models.WebhookDTO{URL: rcv.server.URL})
+		require.NoError(t, err)
+
+		service.Publish(newEvent(models.EventTaskCreated))
+		service.Publish(newEvent(models.EventTaskUpdated))
+		service.Close(context.Background())
+
+		assert.Equal(t, 2, rcv.count())
+	})
+
+	t.Run("CloseCancelsAfterDeadline", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.ReadAll(r.Body)
+			<-r.Context().Done()
+		}))
+		defer server.Close()
+		service := New(repoWebhooks.New(), serviceTasks.SystemClock{}, Options{Workers: 1})
+		_, err := service.CreateWebhook(ctx, &models.WebhookDTO{URL: server.URL})
+		require.NoError(t, err)
+
+		service.Publish(newEvent(models.EventTaskCreated))
+		closeCtx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		done := make(chan struct{})
+		go func() {
+			service.Close(closeCtx)
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(2 * time.Second):
+			t.Fatal("Close did not return after its deadline")
+		}
+	})
+
+	t.Run("FullQueueDropsEvents", func(t *testing.T) {
+		received := make(chan struct{}, 3)
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received <- struct{}{}
+			<-release
+		}))
+		defer server.Close()
+		service := New(repoWebhooks.New(), serviceTasks.SystemClock{}, Options{Workers: 1, QueueSize: 1})
+		_, err := service.CreateWebhook(ctx, &models.WebhookDTO{URL: server.URL})
+		require.NoError(t, err)
+
+		service.Publish(newEvent(models.EventTaskCreated))
+		<-received
+		service.Publish(newEvent(models.EventTaskUpdated))
+		service.Publish(newEvent(models.EventTaskDeleted))
+		close(release)
+		service.Close(context.Background())
+
+		assert.Len(t, received, 1, "the third event found the queue full")
+	})
+}
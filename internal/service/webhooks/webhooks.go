@@ -0,0 +1,156 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/url"
+	"slices"
+
+	"github.com/avraam311/tasks-service/internal/models"
+	serviceTasks "github.com/avraam311/tasks-service/internal/service/tasks"
+)
+
+const (
+	RuleURL    = "url"
+	RuleEvents = "events"
+	RuleSecret = "secret"
+
+	MaxSecretLength = 256
+)
+
+// The returned webhook is the only place a generated secret is shown.
+func (s *Service) CreateWebhook(ctx context.Context, dto *models.WebhookDTO) (*models.Webhook, error) {
+	if err := prepareWebhook(dto); err != nil {
+		return nil, fmt.Errorf("service/webhooks.go - %w", err)
+	}
+
+	now := s.clock.Now().UTC()
+	webhook := &models.Webhook{
+		URL:       dto.URL,
+		Events:    dto.Events,
+		Secret:    dto.Secret,
+		Active:    dto.Active == nil || *dto.Active,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if webhook.Secret == "" {
+		webhook.Secret = rand.Text()
+	}
+	webhookID, err := s.repo.StoreWebhook(ctx, webhook)
+	if err != nil {
+		return nil, fmt.Errorf("service/webhooks.go - %w", err)
+	}
+	webhook.ID = webhookID
+
+	return webhook, nil
+}
+
+func (s *Service) GetWebhooks(ctx context.Context) ([]*models.Webhook, error) {
+	webhooks, err := s.repo.LoadWebhooks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("service/webhooks.go - %w", err)
+	}
+	for _, webhook := range webhooks {
+		webhook.Secret = ""
+	}
+
+	return webhooks, nil
+}
+
+func (s *Service) GetWebhook(ctx context.Context, webhookID uint) (*models.Webhook, error) {
+	webhook, err := s.repo.LoadWebhook(ctx, webhookID)
+	if err != nil {
+		return nil, fmt.Errorf("service/webhooks.go - %w", err)
+	}
+	webhook.Secret = ""
+
+	return webhook, nil
+}
+
+// Updating a webhook that was disabled after failed deliveries reactivates it
+// unless active is false.
+func (s *Service) UpdateWebhook(ctx context.Context, webhookID uint, dto *models.WebhookDTO) (*models.Webhook, error) {
+	if err := prepareWebhook(dto); err != nil {
+		return nil, fmt.Errorf("service/webhooks.go - %w", err)
+	}
+
+	webhook, err := s.repo.SwapWebhook(ctx, webhookID, func(webhook *models.Webhook) error {
+		webhook.URL = dto.URL
+		webhook.Events = dto.Events
+		if dto.Secret != "" {
+			webhook.Secret = dto.Secret
+		}
+		webhook.Active = dto.Active == nil || *dto.Active
+		if webhook.Active {
+			webhook.Failures = 0
+			webhook.DisabledAt = nil
+		}
+		webhook.UpdatedAt = s.clock.Now().UTC()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("service/webhooks.go - %w", err)
+	}
+	webhook.Secret = ""
+
+	return webhook, nil
+}
+
+func (s *Service) DeleteWebhook(ctx context.Context, webhookID uint) error {
+	if err := s.repo.DeleteWebhook(ctx, webhookID); err != nil {
+		return fmt.Errorf("service/webhooks.go - %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) GetDeliveries(ctx context.Context, webhookID uint) ([]*models.Delivery, error) {
+	deliveries, err := s.repo.LoadDeliveries(ctx, webhookID)
+	if err != nil {
+		return nil, fmt.Errorf("service/webhooks.go - %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func prepareWebhook(dto *models.WebhookDTO) error {
+	if len(dto.Events) > 0 {
+		events := slices.Clone(dto.Events)
+		slices.Sort(events)
+		dto.Events = slices.Compact(events)
+	} else {
+		dto.Events = []string{}
+	}
+
+	var fields []serviceTasks.FieldError
+	if target, err := url.Parse(dto.URL); err != nil || (target.Scheme != "http" && target.Scheme != "https") ||
+		target.Host == "" {
+		fields = append(fields, serviceTasks.FieldError{
+			Field:   "url",
+			Rule:    RuleURL,
+			Message: "must be an absolute http or https URL",
+		})
+	}
+	for _, event := range dto.Events {
+		if !slices.Contains(models.EventTypes, event) {
+			fields = append(fields, serviceTasks.FieldError{
+				Field:   "events",
+				Rule:    RuleEvents,
+				Message: fmt.Sprintf("unknown event %q", event),
+			})
+		}
+	}
+	if len(dto.Secret) > MaxSecretLength {
+		fields = append(fields, serviceTasks.FieldError{
+			Field:   "secret",
+			Rule:    RuleSecret,
+			Message: fmt.Sprintf("must be at most %d characters long", MaxSecretLength),
+		})
+	}
+	if len(fields) > 0 {
+		return &serviceTasks.ValidationError{Fields: fields}
+	}
+
+	return nil
+}